@@ -41,6 +41,11 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.AddCommand(report.ReportCmd)
+	rootCmd.AddCommand(report.UploadPendingCmd)
+	rootCmd.AddCommand(report.VerifyCmd)
+	rootCmd.AddCommand(report.SchemaCmd)
+	rootCmd.AddCommand(report.InspectCmd)
+	rootCmd.AddCommand(report.ReportOfflineCmd)
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.cobra.yaml)")
 	rootCmd.PersistentFlags().AddFlagSet(zap.FlagSet())
 }
@@ -0,0 +1,167 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"emperror.dev/errors"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/reporter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	inspectOutput string
+	inspectSlices bool
+	inspectDiff   string
+)
+
+var InspectCmd = &cobra.Command{
+	Use:   "inspect <report>",
+	Short: "Inspect a report tarball or directory",
+	Long: `Prints the totals of a report tarball or directory per domain, kind, namespace and workload.
+Use --slices to print every metric value, or --diff to compare with another report by metric id.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := inspect(os.Stdout, args[0])
+
+		if err != nil {
+			log.Error(err, "failed to inspect report", "report", args[0])
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	},
+}
+
+func init() {
+	InspectCmd.Flags().StringVarP(&inspectOutput, "output", "o", "table", "output format, table or csv")
+	InspectCmd.Flags().BoolVar(&inspectSlices, "slices", false, "print every metric value of the report slices")
+	InspectCmd.Flags().StringVar(&inspectDiff, "diff", "", "report tarball or directory to diff against")
+}
+
+func inspect(out io.Writer, path string) error {
+	if inspectOutput != "table" && inspectOutput != "csv" {
+		return errors.Errorf("unsupported output %s", inspectOutput)
+	}
+
+	report, err := reporter.InspectReport(path)
+
+	if err != nil {
+		return err
+	}
+
+	var (
+		header []string
+		rows   [][]string
+	)
+
+	switch {
+	case inspectDiff != "":
+		other, err := reporter.InspectReport(inspectDiff)
+
+		if err != nil {
+			return err
+		}
+
+		diffs, err := reporter.DiffReports(report, other)
+
+		if err != nil {
+			return err
+		}
+
+		header = []string{"METRIC_ID", "METRIC", "CHANGE", "DOMAIN", "KIND", "NAMESPACE", "RESOURCE", "LEFT", "RIGHT"}
+
+		for _, diff := range diffs {
+			row := diff.Left
+			if row == nil {
+				row = diff.Right
+			}
+
+			rows = append(rows, []string{
+				diff.MetricID, diff.Metric, string(diff.Change),
+				row.MeterDomain, row.MeterKind, row.Namespace, row.ResourceName,
+				formatRowValue(diff.Left), formatRowValue(diff.Right),
+			})
+		}
+	case inspectSlices:
+		metricRows, err := report.Rows()
+
+		if err != nil {
+			return err
+		}
+
+		header = []string{"METRIC_ID", "INTERVAL_START", "INTERVAL_END", "DOMAIN", "KIND", "NAMESPACE", "WORKLOAD", "RESOURCE", "METRIC", "VALUE"}
+
+		for _, row := range metricRows {
+			rows = append(rows, []string{
+				row.MetricID, row.IntervalStart, row.IntervalEnd,
+				row.MeterDomain, row.MeterKind, row.Namespace, row.Workload, row.ResourceName,
+				row.Metric, formatValue(row.Value),
+			})
+		}
+	default:
+		summary, err := report.Summary()
+
+		if err != nil {
+			return err
+		}
+
+		if inspectOutput == "table" {
+			fmt.Fprintf(out, "report %s: %d slices, %d metrics\n\n",
+				report.Metadata.ReportID, len(report.Metadata.ReportSlices), len(report.Metrics))
+		}
+
+		header = []string{"DOMAIN", "KIND", "NAMESPACE", "WORKLOAD", "METRIC", "COUNT", "TOTAL"}
+
+		for _, row := range summary {
+			rows = append(rows, []string{
+				row.MeterDomain, row.MeterKind, row.Namespace, row.Workload,
+				row.Metric, strconv.Itoa(row.Count), formatValue(row.Total),
+			})
+		}
+	}
+
+	if inspectOutput == "csv" {
+		w := csv.NewWriter(out)
+		w.Write(header)
+		w.WriteAll(rows)
+		return w.Error()
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatRowValue(row *reporter.ReportMetricRow) string {
+	if row == nil {
+		return "-"
+	}
+	return formatValue(row.Value)
+}
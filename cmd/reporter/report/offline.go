@@ -0,0 +1,119 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/reporter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prometheusURL, prometheusFixture     string
+	meterDefinitionFiles                 []string
+	clusterID, accountID, environment    string
+	offlineStart, offlineEnd, signingKey string
+)
+
+var ReportOfflineCmd = &cobra.Command{
+	Use:   "report-offline",
+	Short: "Run a report without a cluster",
+	Long: `Writes a report tarball from MeterDefinition files and a Prometheus url or a recorded
+response fixture. No cluster resources are read and nothing is uploaded.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		start, err := time.Parse(time.RFC3339, offlineStart)
+
+		if err != nil {
+			log.Error(err, "failed to parse start")
+			os.Exit(1)
+		}
+
+		end, err := time.Parse(time.RFC3339, offlineEnd)
+
+		if err != nil {
+			log.Error(err, "failed to parse end")
+			os.Exit(1)
+		}
+
+		offline := &reporter.OfflineConfig{
+			PrometheusURL:        prometheusURL,
+			PrometheusFixture:    prometheusFixture,
+			MeterDefinitionFiles: meterDefinitionFiles,
+			ClusterID:            clusterID,
+			AccountID:            accountID,
+			Environment:          reporter.ReportEnvironment(environment),
+			StartTime:            start,
+			EndTime:              end,
+		}
+
+		if signingKey != "" {
+			offline.SigningKey, err = ioutil.ReadFile(signingKey)
+
+			if err != nil {
+				log.Error(err, "failed to read signing key")
+				os.Exit(1)
+			}
+		}
+
+		cfg := &reporter.Config{
+			OutputDirectory: outputDir,
+			CaFile:          cafile,
+			TokenFile:       tokenFile,
+		}
+		cfg.SetDefaults()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		result, err := reporter.RunOffline(ctx, cfg, offline)
+
+		if err != nil {
+			log.Error(err, "error running offline report")
+			os.Exit(1)
+		}
+
+		for _, err := range result.Errors {
+			log.Error(err, "query error")
+		}
+
+		fmt.Printf("report %s written to %s: %d metrics\n", result.ReportID, result.File, result.Count)
+		os.Exit(0)
+	},
+}
+
+func init() {
+	ReportOfflineCmd.Flags().StringVar(&prometheusURL, "prometheusUrl", "", "base url of the prometheus to query")
+	ReportOfflineCmd.Flags().StringVar(&prometheusFixture, "prometheusFixture", "", "recorded prometheus responses to use instead of a prometheus")
+	ReportOfflineCmd.Flags().StringSliceVar(&meterDefinitionFiles, "meterDefinition", []string{}, "MeterDefinition yaml file, can be repeated")
+	ReportOfflineCmd.Flags().StringVar(&clusterID, "clusterId", "", "cluster id to write to the report metadata")
+	ReportOfflineCmd.Flags().StringVar(&accountID, "accountId", "", "account id to write to the report metadata")
+	ReportOfflineCmd.Flags().StringVar(&environment, "environment", reporter.ReportProductionEnv.String(), "production or sandbox")
+	ReportOfflineCmd.Flags().StringVar(&offlineStart, "start", "", "start of the report window, RFC3339")
+	ReportOfflineCmd.Flags().StringVar(&offlineEnd, "end", "", "end of the report window, RFC3339")
+	ReportOfflineCmd.Flags().StringVar(&signingKey, "signingKey", "", "PEM private key file to sign the report with")
+	ReportOfflineCmd.Flags().StringVar(&outputDir, "outputDir", os.TempDir(), "directory to write the report to")
+	ReportOfflineCmd.Flags().StringVar(&cafile, "cafile", "", "cafile for prometheus")
+	ReportOfflineCmd.Flags().StringVar(&tokenFile, "tokenfile", "", "token file for prometheus")
+
+	ReportOfflineCmd.MarkFlagRequired("meterDefinition")
+	ReportOfflineCmd.MarkFlagRequired("clusterId")
+	ReportOfflineCmd.MarkFlagRequired("start")
+	ReportOfflineCmd.MarkFlagRequired("end")
+}
@@ -9,14 +9,20 @@ import (
 	"github.com/gotidy/ptr"
 	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/reporter"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 var log = logf.Log.WithName("reporter_report_cmd")
 
-var name, namespace, cafile, tokenFile, uploadTarget string
-var local, upload bool
-var retry int
+var name, namespace, cafile, tokenFile, outputDir string
+var uploadTargets []string
+var s3Endpoint, s3Region, s3Bucket, s3Prefix, s3SecretName, localUploadDir string
+var spoolDir, signingKeySecret string
+var local, upload, spool, retryFailedUploads, releaseHeld, dryRun, checkCoverage bool
+var retry, uploadAttempts int
+var minCoverage float64
+var uploadTimeout, uploadDeadline time.Duration
 
 var ReportCmd = &cobra.Command{
 	Use:   "report",
@@ -33,18 +39,7 @@ var ReportCmd = &cobra.Command{
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 		defer cancel()
 
-		tmpDir := os.TempDir()
-
-		cfg := &reporter.Config{
-			OutputDirectory: tmpDir,
-			Retry:           ptr.Int(retry),
-			CaFile:          cafile,
-			TokenFile:       tokenFile,
-			Local:           local,
-			Upload:          upload,
-			UploaderTarget:  reporter.MustParseUploaderTarget(uploadTarget),
-		}
-		cfg.SetDefaults()
+		cfg := newConfig()
 
 		task, err := reporter.NewTask(
 			ctx,
@@ -57,7 +52,15 @@ var ReportCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		err = task.Run()
+		switch {
+		case retryFailedUploads:
+			err = task.RetryFailedUploads()
+		case releaseHeld:
+			err = task.ReleaseHeld()
+		default:
+			err = task.Run()
+		}
+
 		if err != nil {
 			log.Error(err, "error running task")
 			os.Exit(1)
@@ -67,16 +70,86 @@ var ReportCmd = &cobra.Command{
 	},
 }
 
+func newConfig() *reporter.Config {
+	cfg := &reporter.Config{
+		OutputDirectory: outputDir,
+		Retry:           ptr.Int(retry),
+		CaFile:          cafile,
+		TokenFile:       tokenFile,
+		Local:           local,
+		Upload:          upload,
+		Spool:           spool,
+		SpoolDirectory:  spoolDir,
+		DryRun:          dryRun,
+		Coverage: reporter.CoverageConfig{
+			Enabled:    checkCoverage,
+			MinPercent: minCoverage,
+		},
+		UploaderTargets: reporter.MustParseUploaderTargets(uploadTargets),
+		S3Uploader: reporter.S3UploaderConfig{
+			Endpoint: s3Endpoint,
+			Region:   s3Region,
+			Bucket:   s3Bucket,
+			Prefix:   s3Prefix,
+			CredentialsSecret: types.NamespacedName{
+				Name:      s3SecretName,
+				Namespace: namespace,
+			},
+		},
+		LocalUploader: reporter.LocalUploaderConfig{
+			Directory: localUploadDir,
+		},
+		PrometheusFixture: prometheusFixture,
+		UploadRetry: reporter.UploadRetryConfig{
+			MaxAttempts:    uploadAttempts,
+			AttemptTimeout: uploadTimeout,
+			Deadline:       uploadDeadline,
+		},
+		Signing: reporter.SigningConfig{
+			KeySecret: types.NamespacedName{
+				Name:      signingKeySecret,
+				Namespace: namespace,
+			},
+		},
+	}
+	cfg.SetDefaults()
+	return cfg
+}
+
+func addUploadFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&uploadTargets, "uploadTarget", []string{"redhat-insights"}, "targets to upload to: redhat-insights, s3, local or noop; the first is the primary target")
+	cmd.Flags().StringVar(&s3Endpoint, "s3Endpoint", "", "endpoint of the s3 compatible store, used with the s3 upload target")
+	cmd.Flags().StringVar(&s3Region, "s3Region", "", "region of the s3 bucket")
+	cmd.Flags().StringVar(&s3Bucket, "s3Bucket", "", "bucket to upload reports to")
+	cmd.Flags().StringVar(&s3Prefix, "s3Prefix", "", "key prefix for uploaded reports")
+	cmd.Flags().StringVar(&s3SecretName, "s3SecretName", "", "secret in the report namespace with accessKeyId and secretAccessKey")
+	cmd.Flags().StringVar(&localUploadDir, "localUploadDir", "", "directory to copy reports to, used with the local upload target")
+	cmd.Flags().IntVar(&uploadAttempts, "uploadAttempts", 5, "number of attempts to upload the report to redhat-insights")
+	cmd.Flags().DurationVar(&uploadTimeout, "uploadTimeout", 2*time.Minute, "timeout of a single upload attempt")
+	cmd.Flags().DurationVar(&uploadDeadline, "uploadDeadline", 15*time.Minute, "time to keep retrying an upload to redhat-insights, including waits the server requests")
+	cmd.Flags().StringVar(&spoolDir, "spoolDir", "", "spool directory for reports waiting to be uploaded, use a persistent volume; report also spools reports here for targets that failed")
+}
+
 func init() {
 	ReportCmd.Flags().StringVar(&name, "name", "", "name of the report")
 	ReportCmd.Flags().StringVar(&namespace, "namespace", "", "namespace of the report")
 	ReportCmd.Flags().StringVar(&cafile, "cafile", "", "cafile for prometheus")
 	ReportCmd.Flags().StringVar(&tokenFile, "tokenfile", "", "token file for prometheus")
-	ReportCmd.Flags().StringVar(&uploadTarget, "uploadTarget", "redhat-insights", "target to upload to")
+	ReportCmd.Flags().StringVar(&outputDir, "outputDir", os.TempDir(), "directory to write the report to, use a persistent volume to retry failed uploads")
 	ReportCmd.Flags().BoolVar(&local, "local", false, "run locally")
 	ReportCmd.Flags().BoolVar(&upload, "upload", true, "to upload the payload")
 	ReportCmd.Flags().IntVar(&retry, "retry", 3, "number of retries")
+	ReportCmd.Flags().BoolVar(&spool, "spool", false, "write the report to the spool directory instead of uploading it, upload later with upload-pending")
+	ReportCmd.Flags().StringVar(&signingKeySecret, "signingKeySecret", "", "secret in the report namespace with a PEM private key in signing.key to sign the report with")
+	ReportCmd.Flags().BoolVar(&dryRun, "dryRun", false, "do not upload the report, write a summary of it to the report status")
+	ReportCmd.Flags().BoolVar(&checkCoverage, "coverage", true, "check the scrape health of the targets the meter definitions depend on during the report window")
+	ReportCmd.Flags().Float64Var(&minCoverage, "minCoverage", 0, "hold the upload if coverage is below this percent, 0 never holds; held reports are kept in the spool directory")
+	ReportCmd.Flags().BoolVar(&retryFailedUploads, "retryFailedUploads", false, "upload the existing report file to targets that failed, without querying")
+	ReportCmd.Flags().BoolVar(&releaseHeld, "releaseHeld", false, "release the report's upload held in the spool for low coverage, upload-pending uploads it")
+	ReportCmd.Flags().StringVar(&prometheusFixture, "prometheusFixture", "", "recorded prometheus responses to use instead of the report's prometheus")
+
+	addUploadFlags(ReportCmd)
 
-	ReportCmd.Flags().MarkHidden("uploadTarget")
 	ReportCmd.Flags().MarkHidden("local")
+	ReportCmd.Flags().MarkHidden("prometheusFixture")
 }
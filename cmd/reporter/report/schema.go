@@ -0,0 +1,49 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"os"
+
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/reporter"
+	"github.com/spf13/cobra"
+)
+
+var schemaOutputDir string
+
+var SchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Write the report JSON schemas",
+	Long:  `Writes the JSON schemas of the report metadata and slice files`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := os.MkdirAll(schemaOutputDir, 0755)
+
+		if err == nil {
+			err = reporter.WriteSchemas(schemaOutputDir)
+		}
+
+		if err != nil {
+			log.Error(err, "failed to write schemas")
+			os.Exit(1)
+		}
+
+		log.Info("wrote schemas", "dir", schemaOutputDir, "version", reporter.ReportSchemaVersion)
+		os.Exit(0)
+	},
+}
+
+func init() {
+	SchemaCmd.Flags().StringVar(&schemaOutputDir, "outputDir", ".", "directory to write the schemas to")
+}
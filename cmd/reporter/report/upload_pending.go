@@ -0,0 +1,73 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/reporter"
+	"github.com/spf13/cobra"
+)
+
+var UploadPendingCmd = &cobra.Command{
+	Use:   "upload-pending",
+	Short: "Upload spooled reports",
+	Long:  `Uploads the reports in the spool directory, written by report --spool or kept for targets that failed, in the order they were spooled`,
+	// the operator passes a report's extra args, which may hold report flags
+	FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Info("running the upload-pending command")
+
+		if spoolDir == "" {
+			log.Error(errors.New("spoolDir not provided"), "spoolDir not provided")
+			os.Exit(1)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+
+		task, err := reporter.NewTask(
+			ctx,
+			reporter.ReportName{},
+			newConfig(),
+		)
+
+		if err != nil {
+			log.Error(err, "couldn't initialize task")
+			os.Exit(1)
+		}
+
+		err = task.UploadPending()
+
+		if err != nil {
+			log.Error(err, "error uploading pending reports")
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	},
+}
+
+func init() {
+	UploadPendingCmd.Flags().StringVar(&namespace, "namespace", "", "namespace of the reports, used to find the s3 credentials secret")
+	UploadPendingCmd.Flags().BoolVar(&local, "local", false, "run locally")
+
+	addUploadFlags(UploadPendingCmd)
+
+	UploadPendingCmd.Flags().MarkHidden("local")
+}
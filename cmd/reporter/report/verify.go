@@ -0,0 +1,61 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/reporter"
+	"github.com/spf13/cobra"
+)
+
+var publicKeyFile string
+
+var VerifyCmd = &cobra.Command{
+	Use:   "verify <tarball>",
+	Short: "Verify a report tarball",
+	Long:  `Verifies the structure, slice digests and optionally the signature of a report tarball offline`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var publicKey []byte
+
+		if publicKeyFile != "" {
+			var err error
+			publicKey, err = ioutil.ReadFile(publicKeyFile)
+
+			if err != nil {
+				log.Error(err, "failed to read public key")
+				os.Exit(1)
+			}
+		}
+
+		result, err := reporter.VerifyReport(args[0], publicKey)
+
+		if err != nil {
+			log.Error(err, "report failed verification", "file", args[0])
+			os.Exit(1)
+		}
+
+		fmt.Printf("report %s verified: %d slices, %d metrics, signature verified: %t\n",
+			result.ReportID, result.Slices, result.Metrics, result.SignatureVerified)
+		os.Exit(0)
+	},
+}
+
+func init() {
+	VerifyCmd.Flags().StringVar(&publicKeyFile, "publicKey", "", "PEM encoded public key to verify the metadata signature with")
+}
@@ -16,6 +16,7 @@ package metrics
 
 import (
 	"context"
+	"io"
 	"reflect"
 	"strings"
 
@@ -81,9 +82,16 @@ func (b *Builder) WithMeterDefinitionStores(stores meter_definition.MeterDefinit
 	b.meterDefStores = stores
 }
 
-func (b *Builder) Build() []*MetricsStore {
-	stores := []*MetricsStore{}
-	activeStoreNames := []string{"pods", "services", "persistentvolumeclaims", "meterdefinitions"}
+// MetricStore is started by the metrics server and writes its metrics on
+// every scrape.
+type MetricStore interface {
+	Start(context.Context)
+	WriteAll(io.Writer)
+}
+
+func (b *Builder) Build() []MetricStore {
+	stores := []MetricStore{}
+	activeStoreNames := []string{"pods", "services", "persistentvolumeclaims", "meterdefinitions", "generic", "nodes"}
 
 	klog.Info("Active resources", "resources", strings.Join(activeStoreNames, ","))
 
@@ -95,11 +103,13 @@ func (b *Builder) Build() []*MetricsStore {
 	return stores
 }
 
-var availableStores = map[string]func(f *Builder) *MetricsStore{
-	"pods":                   func(b *Builder) *MetricsStore { return b.buildPodStore() },
-	"services":               func(b *Builder) *MetricsStore { return b.buildServiceStore() },
-	"persistentvolumeclaims": func(b *Builder) *MetricsStore { return b.buildPVCStore() },
-	"meterdefinitions":       func(b *Builder) *MetricsStore { return b.buildMeterDefinitionStore() },
+var availableStores = map[string]func(f *Builder) MetricStore{
+	"pods":                   func(b *Builder) MetricStore { return b.buildPodStore() },
+	"services":               func(b *Builder) MetricStore { return b.buildServiceStore() },
+	"persistentvolumeclaims": func(b *Builder) MetricStore { return b.buildPVCStore() },
+	"meterdefinitions":       func(b *Builder) MetricStore { return b.buildMeterDefinitionStore() },
+	"generic":                func(b *Builder) MetricStore { return b.buildGenericStore() },
+	"nodes":                  func(b *Builder) MetricStore { return b.buildNodeStore() },
 }
 
 var (
@@ -107,6 +117,7 @@ var (
 	podType = reflect.TypeOf(&v1.Pod{})
 	persistentVolType = reflect.TypeOf(&v1.PersistentVolumeClaim{})
 	meterDefinitionType = reflect.TypeOf(&marketplacev1alpha1.MeterDefinition{})
+	nodeType = reflect.TypeOf(&v1.Node{})
 )
 
 func (b *Builder) buildServiceStore() *MetricsStore {
@@ -136,6 +147,15 @@ func (b *Builder) buildPVCStore() *MetricsStore {
 	)
 }
 
+func (b *Builder) buildNodeStore() *MetricsStore {
+	return b.buildStore(
+		nodeMetricsFamilies,
+		nodeType,
+		&meterDefFetcher{b.cc, b.meterDefStores[meter_definition.NodeStore]},
+		b.meterDefStores[meter_definition.NodeStore],
+	)
+}
+
 func (b *Builder) buildMeterDefinitionStore() *MetricsStore {
 	return b.buildStore(
 		meterDefinitionMetricsFamilies,
@@ -145,6 +165,13 @@ func (b *Builder) buildMeterDefinitionStore() *MetricsStore {
 	)
 }
 
+func (b *Builder) buildGenericStore() *GenericMetricsStore {
+	return NewGenericMetricsStore(
+		b.meterDefStores[meter_definition.GenericStore],
+		&meterDefFetcher{b.cc, b.meterDefStores[meter_definition.GenericStore]},
+	)
+}
+
 func (b *Builder) buildStore(
 	metricFamilies []FamilyGenerator,
 	expectedType reflect.Type,
@@ -163,8 +190,8 @@ func (b *Builder) buildStore(
 	)
 }
 
-func ComposeMetricGenFuncs(familyGens []FamilyGenerator) func(interface{}, []*marketplacev1alpha1.MeterDefinition) []FamilyByteSlicer {
-	return func(obj interface{}, meterDefinitions []*marketplacev1alpha1.MeterDefinition) []FamilyByteSlicer {
+func ComposeMetricGenFuncs(familyGens []FamilyGenerator) func(interface{}, []*MeterDefinitionMatch) []FamilyByteSlicer {
+	return func(obj interface{}, meterDefinitions []*MeterDefinitionMatch) []FamilyByteSlicer {
 		families := make([]FamilyByteSlicer, len(familyGens))
 
 		for i, gen := range familyGens {
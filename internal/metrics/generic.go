@@ -0,0 +1,220 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"io"
+	"sort"
+
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/meter_definition"
+	prom "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/prometheus"
+	"github.com/sasha-s/go-deadlock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	kbsm "k8s.io/kube-state-metrics/pkg/metric"
+)
+
+// genericFamilyGenerator generates the meterdef_<kind>_info family of the
+// resources of Generic workloads. The labels are named after the kind, so
+// deployments have a deployment label as they do in kube-state-metrics.
+// The value is always 1 so the family can be joined to a query.
+func genericFamilyGenerator(kind string) FamilyGenerator {
+	kindLabel := prom.GenericKindLabel(kind)
+
+	return FamilyGenerator{
+		FamilyGenerator: kbsm.FamilyGenerator{
+			Name: prom.GenericInfoMetric(kind),
+			Type: kbsm.Gauge,
+			Help: "Metering info for " + kindLabel,
+		},
+		GenerateMeterFunc: func(obj interface{}, meterDefinitions []*MeterDefinitionMatch) *kbsm.Family {
+			return &kbsm.Family{
+				Metrics: MapMeterDefinitions(genericMetrics(obj, 1), meterDefinitions),
+			}
+		},
+	}
+}
+
+// genericReplicasFamilyGenerator generates the meterdef_<kind>_replicas
+// family of the scalable resources of Generic workloads, the resources
+// with a spec.replicas. Resources without one have no series.
+func genericReplicasFamilyGenerator(kind string) FamilyGenerator {
+	kindLabel := prom.GenericKindLabel(kind)
+
+	return FamilyGenerator{
+		FamilyGenerator: kbsm.FamilyGenerator{
+			Name: prom.GenericReplicasMetric(kind),
+			Type: kbsm.Gauge,
+			Help: "Desired replicas of " + kindLabel,
+		},
+		GenerateMeterFunc: func(obj interface{}, meterDefinitions []*MeterDefinitionMatch) *kbsm.Family {
+			replicas, ok, err := unstructured.NestedInt64(obj.(*unstructured.Unstructured).Object, "spec", "replicas")
+
+			if err != nil || !ok {
+				return &kbsm.Family{}
+			}
+
+			return &kbsm.Family{
+				Metrics: MapMeterDefinitions(genericMetrics(obj, float64(replicas)), meterDefinitions),
+			}
+		},
+	}
+}
+
+func genericMetrics(obj interface{}, value float64) []*kbsm.Metric {
+	u := obj.(*unstructured.Unstructured)
+	kindLabel := prom.GenericKindLabel(u.GetKind())
+
+	return []*kbsm.Metric{
+		{
+			LabelKeys:   []string{"namespace", kindLabel, kindLabel + "_uid"},
+			LabelValues: []string{u.GetNamespace(), u.GetName(), string(u.GetUID())},
+			Value:       value,
+		},
+	}
+}
+
+// GenericMetricsStore writes the meterdef_<kind>_info and
+// meterdef_<kind>_replicas families for each kind of the Generic workloads.
+// Unlike MetricsStore, its families are only known once the resources
+// arrive.
+type GenericMetricsStore struct {
+	// Protects metrics
+	mutex deadlock.RWMutex
+	// metrics are the generated metrics of each object by family name.
+	metrics map[string]map[types.UID][]byte
+	// headers are the header of each family.
+	headers map[string]string
+
+	meterDefStore   *meter_definition.MeterDefinitionStore
+	meterDefFetcher MeterDefinitionFetcher
+}
+
+// NewGenericMetricsStore returns a new GenericMetricsStore
+func NewGenericMetricsStore(
+	meterDefStore *meter_definition.MeterDefinitionStore,
+	meterDefFetcher MeterDefinitionFetcher,
+) *GenericMetricsStore {
+	return &GenericMetricsStore{
+		metrics:         map[string]map[types.UID][]byte{},
+		headers:         map[string]string{},
+		meterDefStore:   meterDefStore,
+		meterDefFetcher: meterDefFetcher,
+	}
+}
+
+func (s *GenericMetricsStore) Start(
+	ctx context.Context,
+) {
+	log.Info("starting metric store", "name", "metricStore-generic")
+
+	ch := make(chan *meter_definition.ObjectResourceMessage, 10)
+	s.meterDefStore.RegisterListener("metricStore-generic", ch)
+
+	go func() {
+		defer close(ch)
+
+		for {
+			select {
+			case msg := <-ch:
+				if msg == nil {
+					continue
+				}
+
+				u, ok := msg.Object.(*unstructured.Unstructured)
+				if !ok {
+					continue
+				}
+
+				switch msg.Action {
+				case meter_definition.AddMessageAction:
+					log.Info("addMessageAction", "message", msg, "kind", u.GetKind())
+					_ = s.Add(u)
+				case meter_definition.DeleteMessageAction:
+					log.Info("deleteMessageAction", "message", msg, "kind", u.GetKind())
+					_ = s.Delete(u)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Add generates the info and replicas metrics of the object.
+func (s *GenericMetricsStore) Add(obj *unstructured.Unstructured) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	meterDefs, err := s.meterDefFetcher.GetMeterDefinitions(obj)
+
+	if err != nil {
+		return err
+	}
+
+	for _, gen := range []FamilyGenerator{
+		genericFamilyGenerator(obj.GetKind()),
+		genericReplicasFamilyGenerator(obj.GetKind()),
+	} {
+		family := gen.GenerateMeterFunc(obj, meterDefs)
+		family.Name = gen.Name
+
+		if len(family.Metrics) == 0 {
+			delete(s.metrics[gen.Name], obj.GetUID())
+			continue
+		}
+
+		if _, ok := s.metrics[gen.Name]; !ok {
+			s.metrics[gen.Name] = map[types.UID][]byte{}
+			s.headers[gen.Name] = gen.generateHeader()
+		}
+
+		s.metrics[gen.Name][obj.GetUID()] = family.ByteSlice()
+	}
+
+	return nil
+}
+
+// Delete removes the metrics of the object.
+func (s *GenericMetricsStore) Delete(obj *unstructured.Unstructured) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.metrics[prom.GenericInfoMetric(obj.GetKind())], obj.GetUID())
+	delete(s.metrics[prom.GenericReplicasMetric(obj.GetKind())], obj.GetUID())
+
+	return nil
+}
+
+// WriteAll writes the metrics of every family, sorted by family name.
+func (s *GenericMetricsStore) WriteAll(w io.Writer) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	names := make([]string, 0, len(s.metrics))
+	for name := range s.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		w.Write([]byte(s.headers[name]))
+		w.Write([]byte{'\n'})
+		for _, metric := range s.metrics[name] {
+			w.Write(metric)
+		}
+	}
+}
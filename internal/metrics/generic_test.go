@@ -0,0 +1,98 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("GenericMetricsStore", func() {
+	var (
+		sut        *GenericMetricsStore
+		deployment *unstructured.Unstructured
+		database   *unstructured.Unstructured
+	)
+
+	newObject := func(apiVersion, kind, name, uid string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion(apiVersion)
+		u.SetKind(kind)
+		u.SetNamespace("metering-example-operator")
+		u.SetName(name)
+		u.SetUID(types.UID(uid))
+		return u
+	}
+
+	writeAll := func() string {
+		buf := &bytes.Buffer{}
+		sut.WriteAll(buf)
+		return buf.String()
+	}
+
+	BeforeEach(func() {
+		sut = NewGenericMetricsStore(nil, emptyFetcher)
+
+		deployment = newObject("apps/v1", "Deployment", "app", "deployment-uid")
+		Expect(unstructured.SetNestedField(deployment.Object, int64(3), "spec", "replicas")).To(Succeed())
+
+		database = newObject("db.example.com/v1", "Database", "db", "database-uid")
+	})
+
+	It("should write the info and replicas families of each kind", func() {
+		Expect(sut.Add(deployment)).To(Succeed())
+		Expect(sut.Add(database)).To(Succeed())
+
+		Expect(writeAll()).To(Equal(
+			"# HELP meterdef_database_info Metering info for database\n" +
+				"# TYPE meterdef_database_info gauge\n" +
+				`meterdef_database_info{namespace="metering-example-operator",database="db",database_uid="database-uid"} 1` + "\n" +
+				"# HELP meterdef_deployment_info Metering info for deployment\n" +
+				"# TYPE meterdef_deployment_info gauge\n" +
+				`meterdef_deployment_info{namespace="metering-example-operator",deployment="app",deployment_uid="deployment-uid"} 1` + "\n" +
+				"# HELP meterdef_deployment_replicas Desired replicas of deployment\n" +
+				"# TYPE meterdef_deployment_replicas gauge\n" +
+				`meterdef_deployment_replicas{namespace="metering-example-operator",deployment="app",deployment_uid="deployment-uid"} 3` + "\n"))
+	})
+
+	It("should update the replicas of an object", func() {
+		Expect(sut.Add(deployment)).To(Succeed())
+
+		Expect(unstructured.SetNestedField(deployment.Object, int64(5), "spec", "replicas")).To(Succeed())
+		Expect(sut.Add(deployment)).To(Succeed())
+		Expect(writeAll()).To(ContainSubstring(`deployment_uid="deployment-uid"} 5`))
+		Expect(writeAll()).ToNot(ContainSubstring(`deployment_uid="deployment-uid"} 3`))
+
+		unstructured.RemoveNestedField(deployment.Object, "spec", "replicas")
+		Expect(sut.Add(deployment)).To(Succeed())
+		Expect(writeAll()).ToNot(ContainSubstring("meterdef_deployment_replicas{"))
+	})
+
+	It("should delete the metrics of an object", func() {
+		Expect(sut.Add(deployment)).To(Succeed())
+		Expect(sut.Add(database)).To(Succeed())
+
+		Expect(sut.Delete(deployment)).To(Succeed())
+
+		out := writeAll()
+		Expect(out).ToNot(ContainSubstring("meterdef_deployment_info{"))
+		Expect(out).ToNot(ContainSubstring("meterdef_deployment_replicas{"))
+		Expect(out).To(ContainSubstring(`meterdef_database_info{namespace="metering-example-operator",database="db"`))
+	})
+})
@@ -18,6 +18,7 @@ import (
 	"context"
 	"strings"
 
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/common"
 	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
 	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/meter_definition"
 	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils/reconcileutils"
@@ -30,7 +31,7 @@ import (
 var log = logf.Log.WithName("meteric")
 
 type FamilyGenerator struct {
-	GenerateMeterFunc func(interface{}, []*marketplacev1alpha1.MeterDefinition) *kbsm.Family
+	GenerateMeterFunc func(interface{}, []*MeterDefinitionMatch) *kbsm.Family
 	kbsm.FamilyGenerator
 }
 
@@ -49,12 +50,33 @@ func (g *FamilyGenerator) generateHeader() string {
 	return header.String()
 }
 
-func GetMeterDefLabelsKeys(mdef *marketplacev1alpha1.MeterDefinition) ([]string, []string) {
-	return []string{"meter_def_name", "meter_def_namespace", "meter_def_domain", "meter_def_kind"},
-		[]string{mdef.Name, mdef.Namespace, mdef.Spec.Group, mdef.Spec.Kind}
+// MeterDefinitionMatch is a meter definition matching an object, with the
+// root owner the object's usage is attributed to.
+type MeterDefinitionMatch struct {
+	*marketplacev1alpha1.MeterDefinition
+
+	// RootOwner is nil when the object has no controller owner.
+	RootOwner *common.NamespacedNameReference
+}
+
+// GetMeterDefLabelsKeys are the labels of the meter definition and the root
+// owner added to each metric of a matched object.
+func GetMeterDefLabelsKeys(mdef *MeterDefinitionMatch) ([]string, []string) {
+	rootOwnerKind, rootOwnerName := "", ""
+
+	if mdef.RootOwner != nil {
+		rootOwnerName = mdef.RootOwner.Name
+
+		if mdef.RootOwner.GroupVersionKind != nil {
+			rootOwnerKind = mdef.RootOwner.GroupVersionKind.Kind
+		}
+	}
+
+	return []string{"meter_def_name", "meter_def_namespace", "meter_def_domain", "meter_def_kind", "root_owner_kind", "root_owner_name"},
+		[]string{mdef.Name, mdef.Namespace, mdef.Spec.Group, mdef.Spec.Kind, rootOwnerKind, rootOwnerName}
 }
 
-func GetAllMeterLabelsKeys(mdefs []*marketplacev1alpha1.MeterDefinition) ([]string, []string) {
+func GetAllMeterLabelsKeys(mdefs []*MeterDefinitionMatch) ([]string, []string) {
 	allMdefLabelKeys, allMdefLabelValues := []string{}, []string{}
 	for _, meterDef := range mdefs {
 		mdefLabelKeys, mdefLabelValues := GetMeterDefLabelsKeys(meterDef)
@@ -65,7 +87,7 @@ func GetAllMeterLabelsKeys(mdefs []*marketplacev1alpha1.MeterDefinition) ([]stri
 	return allMdefLabelKeys, allMdefLabelValues
 }
 
-func MapMeterDefinitions(metrics []*kbsm.Metric, mdefs []*marketplacev1alpha1.MeterDefinition) []*kbsm.Metric {
+func MapMeterDefinitions(metrics []*kbsm.Metric, mdefs []*MeterDefinitionMatch) []*kbsm.Metric {
 	if len(mdefs) == 0 {
 		return metrics
 	}
@@ -91,8 +113,8 @@ type emptyMeterDefFetcher struct {}
 
 var emptyFetcher MeterDefinitionFetcher = &emptyMeterDefFetcher{}
 
-func (p *emptyMeterDefFetcher) GetMeterDefinitions(obj interface{}) ([]*marketplacev1alpha1.MeterDefinition, error) {
-	return []*marketplacev1alpha1.MeterDefinition{}, nil
+func (p *emptyMeterDefFetcher) GetMeterDefinitions(obj interface{}) ([]*MeterDefinitionMatch, error) {
+	return []*MeterDefinitionMatch{}, nil
 }
 
 type meterDefFetcher struct {
@@ -102,8 +124,8 @@ type meterDefFetcher struct {
 
 var _ MeterDefinitionFetcher = &meterDefFetcher{}
 
-func (p *meterDefFetcher) GetMeterDefinitions(obj interface{}) ([]*marketplacev1alpha1.MeterDefinition, error) {
-	results := []*marketplacev1alpha1.MeterDefinition{}
+func (p *meterDefFetcher) GetMeterDefinitions(obj interface{}) ([]*MeterDefinitionMatch, error) {
+	results := []*MeterDefinitionMatch{}
 	metaobj, err := meta.Accessor(obj)
 
 	if err != nil {
@@ -115,8 +137,8 @@ func (p *meterDefFetcher) GetMeterDefinitions(obj interface{}) ([]*marketplacev1
 
 func (p *meterDefFetcher) getMeterDefs(
 	uid types.UID,
-) ([]*marketplacev1alpha1.MeterDefinition, error) {
-	results := []*marketplacev1alpha1.MeterDefinition{}
+) ([]*MeterDefinitionMatch, error) {
+	results := []*MeterDefinitionMatch{}
 	refs := p.meterDefinitionStore.GetMeterDefinitionRefs(uid)
 
 	for _, ref := range refs {
@@ -127,7 +149,10 @@ func (p *meterDefFetcher) getMeterDefs(
 			return results, err
 		}
 
-		results = append(results, meterDefinition)
+		results = append(results, &MeterDefinitionMatch{
+			MeterDefinition: meterDefinition,
+			RootOwner:       ref.RootOwner,
+		})
 	}
 
 	return results, nil
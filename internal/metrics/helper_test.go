@@ -0,0 +1,73 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/common"
+	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kbsm "k8s.io/kube-state-metrics/pkg/metric"
+)
+
+var _ = Describe("MapMeterDefinitions", func() {
+	var (
+		pod      *corev1.Pod
+		meterDef *marketplacev1alpha1.MeterDefinition
+	)
+
+	generate := func(matches ...*MeterDefinitionMatch) string {
+		gen := podMetricsFamilies[0]
+		family := gen.GenerateMeterFunc(pod, matches)
+		family.Name = gen.Name
+		return string(family.ByteSlice())
+	}
+
+	BeforeEach(func() {
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-pod", Namespace: "metering-example-operator", UID: "pod-uid"},
+		}
+		meterDef = &marketplacev1alpha1.MeterDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "example-meterdefinition", Namespace: "metering-example-operator"},
+			Spec: marketplacev1alpha1.MeterDefinitionSpec{
+				Group: "apps.partner.metering.com",
+				Kind:  "App",
+			},
+		}
+	})
+
+	It("should label the metrics with the root owner", func() {
+		Expect(generate(&MeterDefinitionMatch{
+			MeterDefinition: meterDef,
+			RootOwner: &common.NamespacedNameReference{
+				Namespace:        "metering-example-operator",
+				Name:             "app",
+				GroupVersionKind: &common.GroupVersionKind{APIVersion: "apps/v1", Kind: "Deployment"},
+			},
+		})).To(ContainSubstring(`root_owner_kind="Deployment",root_owner_name="app"} 1`))
+	})
+
+	It("should leave the root owner empty without an owner", func() {
+		Expect(generate(&MeterDefinitionMatch{MeterDefinition: meterDef})).To(
+			ContainSubstring(`meter_def_kind="App",root_owner_kind="",root_owner_name=""} 1`))
+	})
+
+	It("should keep the metrics without a meter definition", func() {
+		metrics := []*kbsm.Metric{{LabelKeys: []string{"pod"}, LabelValues: []string{"app-pod"}, Value: 1}}
+		Expect(MapMeterDefinitions(metrics, nil)).To(Equal(metrics))
+	})
+})
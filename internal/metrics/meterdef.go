@@ -30,7 +30,7 @@ var meterDefinitionMetricsFamilies = []FamilyGenerator{
 			Type: kbsm.Gauge,
 			Help: "Metering info for meterDefinition",
 		},
-		GenerateMeterFunc: wrapMeterDefinitionFunc(func(meterDefinition *marketplacev1alpha1.MeterDefinition, meterDefinitions []*marketplacev1alpha1.MeterDefinition) *kbsm.Family {
+		GenerateMeterFunc: wrapMeterDefinitionFunc(func(meterDefinition *marketplacev1alpha1.MeterDefinition, meterDefinitions []*MeterDefinitionMatch) *kbsm.Family {
 			metrics := []*kbsm.Metric{}
 
 			meterDefinitionUID := string(meterDefinition.UID)
@@ -70,11 +70,11 @@ var meterDefinitionMetricsFamilies = []FamilyGenerator{
 }
 
 // wrapMeterDefinitionFunc is a helper function for generating meterDefinition-based metrics
-func wrapMeterDefinitionFunc(f func(*marketplacev1alpha1.MeterDefinition, []*marketplacev1alpha1.MeterDefinition) *kbsm.Family) func(obj interface{}, mdefs []*marketplacev1alpha1.MeterDefinition) *kbsm.Family {
-	return func(obj interface{}, meterDefinitions []*marketplacev1alpha1.MeterDefinition) *kbsm.Family {
+func wrapMeterDefinitionFunc(f func(*marketplacev1alpha1.MeterDefinition, []*MeterDefinitionMatch) *kbsm.Family) func(obj interface{}, mdefs []*MeterDefinitionMatch) *kbsm.Family {
+	return func(obj interface{}, meterDefinitions []*MeterDefinitionMatch) *kbsm.Family {
 		meterDefinition := obj.(*marketplacev1alpha1.MeterDefinition)
 
-		metricFamily := f(meterDefinition, []*marketplacev1alpha1.MeterDefinition{})
+		metricFamily := f(meterDefinition, []*MeterDefinitionMatch{})
 
 		for _, m := range metricFamily.Metrics {
 			m.LabelKeys = append(descMeterDefinitionLabelsDefaultLabels, m.LabelKeys...)
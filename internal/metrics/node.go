@@ -0,0 +1,104 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	v1 "k8s.io/api/core/v1"
+	kbsm "k8s.io/kube-state-metrics/pkg/metric"
+)
+
+var (
+	descNodeLabelsDefaultLabels = []string{"node"}
+)
+
+var nodeMetricsFamilies = []FamilyGenerator{
+	{
+		FamilyGenerator: kbsm.FamilyGenerator{
+			Name: "meterdef_node_info",
+			Type: kbsm.Gauge,
+			Help: "Metering info for node",
+		},
+		GenerateMeterFunc: wrapNodeFunc(func(node *v1.Node, meterDefinitions []*MeterDefinitionMatch) *kbsm.Family {
+			return &kbsm.Family{
+				Metrics: []*kbsm.Metric{
+					{
+						LabelKeys:   []string{"node_uid"},
+						LabelValues: []string{string(node.UID)},
+						Value:       1,
+					},
+				},
+			}
+		}),
+	},
+	{
+		FamilyGenerator: kbsm.FamilyGenerator{
+			Name: "meterdef_node_capacity",
+			Type: kbsm.Gauge,
+			Help: "The capacity of a node, cpu in cores and memory in bytes",
+		},
+		GenerateMeterFunc: wrapNodeFunc(func(node *v1.Node, meterDefinitions []*MeterDefinitionMatch) *kbsm.Family {
+			return &kbsm.Family{
+				Metrics: nodeResourceMetrics(node.Status.Capacity),
+			}
+		}),
+	},
+	{
+		FamilyGenerator: kbsm.FamilyGenerator{
+			Name: "meterdef_node_allocatable",
+			Type: kbsm.Gauge,
+			Help: "The allocatable resources of a node, cpu in cores and memory in bytes",
+		},
+		GenerateMeterFunc: wrapNodeFunc(func(node *v1.Node, meterDefinitions []*MeterDefinitionMatch) *kbsm.Family {
+			return &kbsm.Family{
+				Metrics: nodeResourceMetrics(node.Status.Allocatable),
+			}
+		}),
+	},
+}
+
+// nodeResourceMetrics is a metric per resource with the cpu in cores and
+// the memory in bytes, labelled as kube_node_status_capacity is.
+func nodeResourceMetrics(resources v1.ResourceList) []*kbsm.Metric {
+	return []*kbsm.Metric{
+		{
+			LabelKeys:   []string{"resource", "unit"},
+			LabelValues: []string{string(v1.ResourceCPU), "core"},
+			Value:       float64(resources.Cpu().MilliValue()) / 1000,
+		},
+		{
+			LabelKeys:   []string{"resource", "unit"},
+			LabelValues: []string{string(v1.ResourceMemory), "byte"},
+			Value:       float64(resources.Memory().Value()),
+		},
+	}
+}
+
+// wrapNodeFunc is a helper function for generating node-based metrics
+func wrapNodeFunc(f func(*v1.Node, []*MeterDefinitionMatch) *kbsm.Family) func(obj interface{}, meterDefinitions []*MeterDefinitionMatch) *kbsm.Family {
+	return func(obj interface{}, meterDefinitions []*MeterDefinitionMatch) *kbsm.Family {
+		node := obj.(*v1.Node)
+
+		metricFamily := f(node, meterDefinitions)
+
+		for _, m := range metricFamily.Metrics {
+			m.LabelKeys = append(descNodeLabelsDefaultLabels, m.LabelKeys...)
+			m.LabelValues = append([]string{node.Name}, m.LabelValues...)
+		}
+
+		metricFamily.Metrics = MapMeterDefinitions(metricFamily.Metrics, meterDefinitions)
+
+		return metricFamily
+	}
+}
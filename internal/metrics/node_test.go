@@ -0,0 +1,76 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("nodeMetricsFamilies", func() {
+	var node *v1.Node
+
+	generate := func() map[string]string {
+		families := map[string]string{}
+
+		for _, gen := range nodeMetricsFamilies {
+			family := gen.GenerateMeterFunc(node, nil)
+			family.Name = gen.Name
+			families[gen.Name] = string(family.ByteSlice())
+		}
+
+		return families
+	}
+
+	BeforeEach(func() {
+		node = &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-0", UID: "node-uid"},
+			Status: v1.NodeStatus{
+				Capacity: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("4"),
+					v1.ResourceMemory: resource.MustParse("16Gi"),
+				},
+				Allocatable: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("3500m"),
+					v1.ResourceMemory: resource.MustParse("15Gi"),
+				},
+			},
+		}
+	})
+
+	It("should export capacity and allocatable as values", func() {
+		families := generate()
+
+		Expect(families).To(HaveKeyWithValue("meterdef_node_info",
+			`meterdef_node_info{node="worker-0",node_uid="node-uid"} 1`+"\n"))
+		Expect(families).To(HaveKeyWithValue("meterdef_node_capacity",
+			`meterdef_node_capacity{node="worker-0",resource="cpu",unit="core"} 4`+"\n"+
+				`meterdef_node_capacity{node="worker-0",resource="memory",unit="byte"} 1.7179869184e+10`+"\n"))
+		Expect(families).To(HaveKeyWithValue("meterdef_node_allocatable",
+			`meterdef_node_allocatable{node="worker-0",resource="cpu",unit="core"} 3.5`+"\n"+
+				`meterdef_node_allocatable{node="worker-0",resource="memory",unit="byte"} 1.610612736e+10`+"\n"))
+	})
+
+	It("should export zero for a resource the node does not report", func() {
+		node.Status.Allocatable = v1.ResourceList{}
+
+		Expect(generate()).To(HaveKeyWithValue("meterdef_node_allocatable",
+			`meterdef_node_allocatable{node="worker-0",resource="cpu",unit="core"} 0`+"\n"+
+				`meterdef_node_allocatable{node="worker-0",resource="memory",unit="byte"} 0`+"\n"))
+	})
+})
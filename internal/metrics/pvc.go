@@ -15,7 +15,6 @@
 package metrics
 
 import (
-	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 	kbsm "k8s.io/kube-state-metrics/pkg/metric"
@@ -32,7 +31,7 @@ var pvcMetricsFamilies = []FamilyGenerator{
 			Type: kbsm.Gauge,
 			Help: "Metering info for persistentvolumeclaim",
 		},
-		GenerateMeterFunc: wrapPersistentVolumeClaimFunc(func(pvc *corev1.PersistentVolumeClaim, meterDefinitions []*marketplacev1alpha1.MeterDefinition) *kbsm.Family {
+		GenerateMeterFunc: wrapPersistentVolumeClaimFunc(func(pvc *corev1.PersistentVolumeClaim, meterDefinitions []*MeterDefinitionMatch) *kbsm.Family {
 			metrics := []*kbsm.Metric{}
 
 			phase := pvc.Status.Phase
@@ -51,8 +50,8 @@ var pvcMetricsFamilies = []FamilyGenerator{
 }
 
 // wrapPersistentVolumeClaimFunc is a helper function for generating pvc-based metrics
-func wrapPersistentVolumeClaimFunc(f func(*v1.PersistentVolumeClaim, []*marketplacev1alpha1.MeterDefinition) *kbsm.Family) func(obj interface{}, meterDefinitions []*marketplacev1alpha1.MeterDefinition) *kbsm.Family {
-	return func(obj interface{}, meterDefinitions []*marketplacev1alpha1.MeterDefinition) *kbsm.Family {
+func wrapPersistentVolumeClaimFunc(f func(*v1.PersistentVolumeClaim, []*MeterDefinitionMatch) *kbsm.Family) func(obj interface{}, meterDefinitions []*MeterDefinitionMatch) *kbsm.Family {
+	return func(obj interface{}, meterDefinitions []*MeterDefinitionMatch) *kbsm.Family {
 		pvc := obj.(*v1.PersistentVolumeClaim)
 
 		metricFamily := f(pvc, meterDefinitions)
@@ -15,7 +15,6 @@
 package metrics
 
 import (
-	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
 	v1 "k8s.io/api/core/v1"
 	kbsm "k8s.io/kube-state-metrics/pkg/metric"
 )
@@ -31,7 +30,7 @@ var serviceMetricsFamilies = []FamilyGenerator{
 			Type: kbsm.Gauge,
 			Help: "Info about the service for servicemonitor",
 		},
-		GenerateMeterFunc: wrapServiceFunc(func(s *v1.Service, mdefs []*marketplacev1alpha1.MeterDefinition) *kbsm.Family {
+		GenerateMeterFunc: wrapServiceFunc(func(s *v1.Service, mdefs []*MeterDefinitionMatch) *kbsm.Family {
 			// kube-state-metric labels
 			clusterIP := s.Spec.ClusterIP
 			externalName := s.Spec.ExternalName
@@ -50,8 +49,8 @@ var serviceMetricsFamilies = []FamilyGenerator{
 }
 
 // wrapServiceFunc is a helper function for generating service-based metrics
-func wrapServiceFunc(f func(*v1.Service, []*marketplacev1alpha1.MeterDefinition) *kbsm.Family) func(obj interface{}, meterDefinitions []*marketplacev1alpha1.MeterDefinition) *kbsm.Family {
-	return func(obj interface{}, meterDefinitions []*marketplacev1alpha1.MeterDefinition) *kbsm.Family {
+func wrapServiceFunc(f func(*v1.Service, []*MeterDefinitionMatch) *kbsm.Family) func(obj interface{}, meterDefinitions []*MeterDefinitionMatch) *kbsm.Family {
+	return func(obj interface{}, meterDefinitions []*MeterDefinitionMatch) *kbsm.Family {
 		svc := obj.(*v1.Service)
 
 		metricFamily := f(svc, meterDefinitions)
@@ -20,7 +20,6 @@ import (
 	"io"
 	"reflect"
 
-	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
 	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/meter_definition"
 	"github.com/sasha-s/go-deadlock"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -32,7 +31,7 @@ type FamilyByteSlicer interface {
 }
 
 type MeterDefinitionFetcher interface {
-	GetMeterDefinitions(interface{}) ([]*marketplacev1alpha1.MeterDefinition, error)
+	GetMeterDefinitions(interface{}) ([]*MeterDefinitionMatch, error)
 }
 
 // MetricsStore implements the k8s.io/client-go/tools/cache.Store
@@ -53,7 +52,7 @@ type MetricsStore struct {
 
 	// generateMetricsFunc generates metrics based on a given Kubernetes object
 	// and returns them grouped by metric family.
-	generateMetricsFunc func(interface{}, []*marketplacev1alpha1.MeterDefinition) []FamilyByteSlicer
+	generateMetricsFunc func(interface{}, []*MeterDefinitionMatch) []FamilyByteSlicer
 
 	meterDefStore *meter_definition.MeterDefinitionStore
 
@@ -65,7 +64,7 @@ type MetricsStore struct {
 // NewMetricsStore returns a new MetricsStore
 func NewMetricsStore(
 	headers []string,
-	generateFunc func(interface{}, []*marketplacev1alpha1.MeterDefinition) []FamilyByteSlicer,
+	generateFunc func(interface{}, []*MeterDefinitionMatch) []FamilyByteSlicer,
 	meterDefStore *meter_definition.MeterDefinitionStore,
 	meterDefFetcher MeterDefinitionFetcher,
 	expectedType reflect.Type,
@@ -96,8 +96,73 @@ type MeterBaseSpec struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
 	// +optional
 	AdditionalScrapeConfigs *corev1.SecretKeySelector `json:"additionalScrapeConfigs,omitempty"`
+
+	// ReportInterval is the length of time each generated meter report covers.
+	// Either Daily or Hourly. Default is Daily.
+	// +kubebuilder:validation:Enum=Daily;Hourly
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +optional
+	ReportInterval ReportInterval `json:"reportInterval,omitempty"`
+
+	// ReportTimezone is the IANA time zone used to align report boundaries,
+	// for example America/New_York. Default is UTC.
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +optional
+	ReportTimezone string `json:"reportTimezone,omitempty"`
+
+	// ReportRetentionDays is the number of days meter reports are kept on the
+	// cluster before they are removed. Default is 30.
+	// +kubebuilder:validation:Minimum=1
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +optional
+	ReportRetentionDays *int32 `json:"reportRetentionDays,omitempty"`
+
+	// ReportSpool configures the volume meter reports are kept on until every
+	// upload target has them. Reports are not spooled when it is not set.
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +optional
+	ReportSpool *ReportSpoolSpec `json:"reportSpool,omitempty"`
+}
+
+// ReportSpoolSpec contains configuration for the meter report spool claim.
+type ReportSpoolSpec struct {
+	// Storage class for the spool claim. Default is "" i.e. default.
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +optional
+	Class *string `json:"class,omitempty"`
+
+	// Storage size for the spool claim. Default is 1Gi.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=quantity
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +optional
+	Size resource.Quantity `json:"size,omitempty"`
+
+	// AccessModes of the spool claim. Report jobs may run on any node, so the
+	// default is ReadWriteMany.
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +optional
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
 }
 
+// ReportInterval is the cadence meter reports are generated at.
+type ReportInterval string
+
+const (
+	ReportIntervalDaily  ReportInterval = "Daily"
+	ReportIntervalHourly ReportInterval = "Hourly"
+
+	// DefaultReportRetentionDays is the retention used when ReportRetentionDays is not set.
+	DefaultReportRetentionDays int32 = 30
+)
+
 // MeterBaseStatus defines the observed state of MeterBase.
 // +k8s:openapi-gen=true
 type MeterBaseStatus struct {
@@ -17,6 +17,7 @@ package v1alpha1
 import (
 	"encoding/json"
 	"strings"
+	"time"
 
 	"github.com/operator-framework/operator-sdk/pkg/status"
 	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/common"
@@ -30,6 +31,10 @@ const (
 	MeterDefConditionTypeHasResult           status.ConditionType   = "FoundMatches"
 	MeterDefConditionReasonNoResultsInStatus status.ConditionReason = "No results in status"
 	MeterDefConditionReasonResultsInStatus   status.ConditionReason = "Results in status"
+
+	MeterDefConditionTypeFiltersValid          status.ConditionType   = "FiltersValid"
+	MeterDefConditionReasonFiltersCompiled     status.ConditionReason = "Filters compiled"
+	MeterDefConditionReasonFilterCompileFailed status.ConditionReason = "Filter compile failed"
 )
 
 var (
@@ -45,8 +50,25 @@ var (
 		Reason:  MeterDefConditionReasonResultsInStatus,
 		Message: "Meter definition has results.",
 	}
+	MeterDefConditionFiltersValid = status.Condition{
+		Type:    MeterDefConditionTypeFiltersValid,
+		Status:  corev1.ConditionTrue,
+		Reason:  MeterDefConditionReasonFiltersCompiled,
+		Message: "Meter definition filters compiled.",
+	}
 )
 
+// MeterDefConditionFiltersInvalid is the condition of a meter definition
+// whose filters failed to compile.
+func MeterDefConditionFiltersInvalid(err error) status.Condition {
+	return status.Condition{
+		Type:    MeterDefConditionTypeFiltersValid,
+		Status:  corev1.ConditionFalse,
+		Reason:  MeterDefConditionReasonFilterCompileFailed,
+		Message: err.Error(),
+	}
+}
+
 // MeterDefinitionSpec defines the desired metering spec
 // +k8s:openapi-gen=true
 type MeterDefinitionSpec struct {
@@ -68,10 +90,11 @@ type MeterDefinitionSpec struct {
 	InstalledBy *common.NamespacedNameReference `json:"installedBy,omitempty"`
 
 	// WorkloadVertexType is the top most object of a workload. It allows
-	// you to identify the upper bounds of your workloads.
-	// +kubebuilder:validation:Enum=Namespace;OperatorGroup
+	// you to identify the upper bounds of your workloads. Cluster meters
+	// every namespace and cluster-scoped resources such as nodes.
+	// +kubebuilder:validation:Enum=Namespace;OperatorGroup;Cluster
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
-	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:select:Namespace,urn:alm:descriptor:com.tectonic.ui:select:OperatorGroup"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:select:Namespace,urn:alm:descriptor:com.tectonic.ui:select:OperatorGroup,urn:alm:descriptor:com.tectonic.ui:select:Cluster"
 	WorkloadVertexType WorkloadVertex `json:"workloadVertexType,omitempty"`
 
 	// VertexFilters are used when Namespace is selected. Can be omitted
@@ -108,16 +131,30 @@ type MeterDefinitionSpec struct {
 const (
 	WorkloadVertexOperatorGroup WorkloadVertex = "OperatorGroup"
 	WorkloadVertexNamespace                    = "Namespace"
+	WorkloadVertexCluster                      = "Cluster"
 )
 const (
 	WorkloadTypePod            WorkloadType = "Pod"
 	WorkloadTypeService                     = "Service"
 	WorkloadTypeServiceMonitor              = "ServiceMonitor"
 	WorkloadTypePVC                         = "PersistentVolumeClaim"
+	WorkloadTypeGeneric                     = "Generic"
+	WorkloadTypeNode                        = "Node"
 )
 
+const (
+	MetricTypeGauge     MetricType = "gauge"
+	MetricTypeCounter   MetricType = "counter"
+	MetricTypeHistogram MetricType = "histogram"
+	MetricTypeSummary   MetricType = "summary"
+)
+
+// DefaultOwnerMaxDepth is the owner depth used when OwnerMaxDepth is not set.
+const DefaultOwnerMaxDepth int32 = 10
+
 type WorkloadVertex string
 type WorkloadType string
+type MetricType string
 type CSVNamespacedName common.NamespacedNameReference
 
 // Workload helps identify what to target for metering.
@@ -128,12 +165,21 @@ type Workload struct {
 	Name string `json:"name"`
 
 	// WorkloadType identifies the type of workload to look for. This can be
-	// pod or service right now.
-	// +kubebuilder:validation:Enum=Pod;Service;PersistentVolumeClaim
+	// pod, service, persistentvolumeclaim, node or generic. Generic workloads
+	// match any resource of the GroupVersionKind, such as a Deployment or a
+	// custom resource. Node workloads require the Cluster vertex.
+	// +kubebuilder:validation:Enum=Pod;Service;PersistentVolumeClaim;Generic;Node
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
-	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:select:Pod,urn:alm:descriptor:com.tectonic.ui:select:Service,urn:alm:descriptor:com.tectonic.ui:select:PersistentVolumeClaim"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:select:Pod,urn:alm:descriptor:com.tectonic.ui:select:Service,urn:alm:descriptor:com.tectonic.ui:select:PersistentVolumeClaim,urn:alm:descriptor:com.tectonic.ui:select:Generic,urn:alm:descriptor:com.tectonic.ui:select:Node"
 	WorkloadType WorkloadType `json:"type"`
 
+	// GroupVersionKind of the resources a Generic workload meters. Required
+	// for and only used by Generic workloads.
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text"
+	GroupVersionKind *common.GroupVersionKind `json:"groupVersionKind,omitempty"`
+
 	// OwnerCRD is the name of the GVK to look for as the owner of all the
 	// meterable assets. If omitted, the labels and annotations are used instead.
 	// +optional
@@ -141,6 +187,13 @@ type Workload struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text"
 	OwnerCRD *common.GroupVersionKind `json:"ownerCRD,omitempty"`
 
+	// OwnerMaxDepth is how many levels of controller owners are followed
+	// looking for the OwnerCRD and the root owner. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=50
+	// +optional
+	OwnerMaxDepth *int32 `json:"ownerMaxDepth,omitempty"`
+
 	// LabelSelector are used to filter to the correct workload.
 	// +optional
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
@@ -153,18 +206,56 @@ type Workload struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
 	AnnotationSelector *metav1.LabelSelector `json:"annotationSelector,omitempty"`
 
+	// Expression is a JSONPath expression used to filter to the correct
+	// workload on its fields. The resource is wrapped in a list and matches
+	// if the expression returns a result, for example
+	// {$[?(@.spec.storageClassName=="gold")]} or
+	// {$[*].spec.containers[?(@.image=="quay.io/partner/app:1.0")]}.
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	Expression string `json:"expression,omitempty"`
+
 	// MetricLabels are the labels to collect
 	// +required
 	// +kubebuilder:validation:MinItems=1
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
 	MetricLabels []MeterLabelQuery `json:"metricLabels,omitempty"`
+
+	// ResourceLabels are the query result labels that identify the resource
+	// usage is attributed to, such as a tenant or instance label. Their values
+	// are joined with "/" to form the resource name. Defaults to the pod,
+	// service, persistentvolumeclaim or node label of the workload type, or
+	// the lowercased kind of a Generic workload.
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	ResourceLabels []string `json:"resourceLabels,omitempty"`
+
+	// AdditionalLabels are the query result labels added to each usage
+	// record. Defaults to pod, namespace, service and persistentvolumeclaim.
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	AdditionalLabels []string `json:"additionalLabels,omitempty"`
 }
 
 type WorkloadResource struct {
 	ReferencedWorkloadName string `json:"referencedWorkloadName"`
 
 	common.NamespacedNameReference `json:",inline"`
+
+	// RootOwner is the top-most controller owner of the resource, usage
+	// is attributed to it.
+	// +optional
+	RootOwner *common.NamespacedNameReference `json:"rootOwner,omitempty"`
+}
+
+// GetOwnerMaxDepth returns the owner depth of the workload.
+func (w Workload) GetOwnerMaxDepth() int {
+	if w.OwnerMaxDepth == nil {
+		return int(DefaultOwnerMaxDepth)
+	}
+
+	return int(*w.OwnerMaxDepth)
 }
 
 type ByAlphabetical []WorkloadResource
@@ -227,6 +318,52 @@ type MeterLabelQuery struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:select:sum,urn:alm:descriptor:com.tectonic.ui:select:min,urn:alm:descriptor:com.tectonic.ui:select:max,urn:alm:descriptor:com.tectonic.ui:select:avg"
 	Aggregation string `json:"aggregation,omitempty"`
+
+	// MetricType is the prometheus type of the metric. Histogram and summary
+	// metrics are read from their _bucket, _sum and _count series, and the
+	// query must then be the metric name with optional label matchers.
+	// +kubebuilder:validation:Enum:=gauge;counter;histogram;summary
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:select:gauge,urn:alm:descriptor:com.tectonic.ui:select:counter,urn:alm:descriptor:com.tectonic.ui:select:histogram,urn:alm:descriptor:com.tectonic.ui:select:summary"
+	// +optional
+	MetricType MetricType `json:"metricType,omitempty"`
+
+	// Quantiles to report for a histogram or summary metric, such as "0.95".
+	// Each quantile is reported as its own usage metric along with the
+	// _sum and _count of the metric.
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +optional
+	Quantiles []string `json:"quantiles,omitempty"`
+
+	// Interval is the granularity of the reported usage, such as 15m or 24h.
+	// The report window must be a multiple of the interval. Defaults to 1h.
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Instant evaluates the query once at the end of the report window and
+	// reports the result for the whole window instead of per interval. The
+	// query must cover the window itself, such as with an increase over
+	// the window. Instant metrics are not recorded by recording rules.
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +optional
+	Instant bool `json:"instant,omitempty"`
+}
+
+const DefaultMeterInterval = time.Hour
+
+// GetInterval returns the interval of the reported usage.
+func (q MeterLabelQuery) GetInterval() time.Duration {
+	if q.Interval == nil || q.Interval.Duration == 0 {
+		return DefaultMeterInterval
+	}
+	return q.Interval.Duration
+}
+
+// IsHistogram is true if the metric is read from histogram or summary series.
+func (q MeterLabelQuery) IsHistogram() bool {
+	return q.MetricType == MetricTypeHistogram || q.MetricType == MetricTypeSummary
 }
 
 // MeterDefinitionStatus defines the observed state of MeterDefinition
@@ -243,6 +380,12 @@ type MeterDefinitionStatus struct {
 	// this meter definition
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
 	WorkloadResources []WorkloadResource `json:"workloadResource,omitempty"`
+
+	// RecordingRulesTime is when the recording rules of the meter definition
+	// last changed. Reports starting after it query the recorded series.
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +optional
+	RecordingRulesTime *metav1.Time `json:"recordingRulesTime,omitempty"`
 }
 
 // MeterDefinition defines the meter workloads used to enable pay for
@@ -47,6 +47,19 @@ type MeterReportSpec struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="hidden"
 	// +optional
 	ExtraArgs []string `json:"extraJobArgs,omitempty"`
+
+	// DryRun runs the report without uploading it. A summary of the
+	// report is written to the status instead.
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// RerunGeneration requests a rerun of the report when it is changed.
+	// The report's job is deleted and run again, and the new report
+	// supersedes the earlier one.
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +optional
+	RerunGeneration int64 `json:"rerunGeneration,omitempty"`
 }
 
 // MeterReportStatus defines the observed state of MeterReport
@@ -71,11 +84,236 @@ type MeterReportStatus struct {
 	// +optional
 	UploadID *types.UID `json:"uploadUID,omitempty"`
 
+	// UploadTime is the time the report was uploaded to the primary target.
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +optional
+	UploadTime *metav1.Time `json:"uploadTime,omitempty"`
+
 	// QueryErrorList shows if there were any errors from queries
 	// for the report.
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
 	// +optional
 	QueryErrorList []string `json:"queryErrorList,omitempty"`
+
+	// UploadStatus is the result of the upload to each upload target.
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +optional
+	UploadStatus []UploadDetails `json:"uploadStatus,omitempty"`
+
+	// ReportFile is the path of the report tarball written by the reporter.
+	// Failed uploads are retried from this file.
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:hidden"
+	// +optional
+	ReportFile string `json:"reportFile,omitempty"`
+
+	// DryRunSummary is a bounded summary of a dry run report.
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +optional
+	DryRunSummary *DryRunSummary `json:"dryRunSummary,omitempty"`
+
+	// ReportID is the ID of the last report written by the reporter.
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +optional
+	ReportID string `json:"reportID,omitempty"`
+
+	// SupersedesReportID is the ID of the report a rerun replaces.
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +optional
+	SupersedesReportID string `json:"supersedesReportID,omitempty"`
+
+	// Coverage is how much of the report window Prometheus was scraping the
+	// targets the meter definitions depend on.
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +optional
+	Coverage *ReportCoverage `json:"coverage,omitempty"`
+
+	// ObservedRerunGeneration is the last RerunGeneration acted on.
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:hidden"
+	// +optional
+	ObservedRerunGeneration int64 `json:"observedRerunGeneration,omitempty"`
+
+	// SupersededJobUID is the UID of the job deleted for a rerun. The new
+	// job is not created until the deleted job is gone.
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:hidden"
+	// +optional
+	SupersededJobUID types.UID `json:"supersededJobUID,omitempty"`
+}
+
+// IsRerunRequested is true if the RerunGeneration changed since the
+// report last ran.
+func (r *MeterReport) IsRerunRequested() bool {
+	return r.Spec.RerunGeneration != r.Status.ObservedRerunGeneration
+}
+
+// ResetForRerun clears the results of the last run. The last report ID
+// is kept as the ID the rerun supersedes.
+func (r *MeterReport) ResetForRerun() {
+	supersedes := r.Status.SupersedesReportID
+
+	if r.Status.ReportID != "" {
+		supersedes = r.Status.ReportID
+	}
+
+	conditions := status.NewConditions(ReportConditionJobNotStarted)
+
+	r.Status = MeterReportStatus{
+		Conditions:              &conditions,
+		SupersedesReportID:      supersedes,
+		ObservedRerunGeneration: r.Spec.RerunGeneration,
+	}
+}
+
+// DryRunSummaryLimit is the most workload totals and metrics kept in a
+// dry run summary.
+const DryRunSummaryLimit = 10
+
+// DryRunSummary previews the metrics a report would upload.
+type DryRunSummary struct {
+	// MetricCount is the number of metric keys in the report.
+	MetricCount int `json:"metricCount"`
+
+	// RowCount is the number of metric values in the report.
+	RowCount int `json:"rowCount"`
+
+	// Workloads are the largest totals per domain, kind, workload and metric.
+	// +optional
+	Workloads []DryRunWorkloadTotal `json:"workloads,omitempty"`
+
+	// TopMetrics are the largest metric values of the report.
+	// +optional
+	TopMetrics []DryRunMetric `json:"topMetrics,omitempty"`
+
+	// Truncated is true if workloads or metrics were left out of the summary.
+	// +optional
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// DryRunWorkloadTotal is the total of a metric for a workload.
+type DryRunWorkloadTotal struct {
+	Domain   string `json:"domain"`
+	Kind     string `json:"kind"`
+	Workload string `json:"workload,omitempty"`
+	Metric   string `json:"metric"`
+	Count    int    `json:"count"`
+	Total    string `json:"total"`
+}
+
+// DryRunMetric is a single metric value of the report.
+type DryRunMetric struct {
+	MetricID      string `json:"metricId"`
+	IntervalStart string `json:"intervalStart"`
+	Namespace     string `json:"namespace,omitempty"`
+	ResourceName  string `json:"resourceName,omitempty"`
+	Metric        string `json:"metric"`
+	Value         string `json:"value"`
+}
+
+// CoverageLimit is the most gaps and meter definitions kept in the coverage status.
+const CoverageLimit = 10
+
+// ReportCoverage is the scrape health of a report window.
+type ReportCoverage struct {
+	// Percent of the report window every target was up and returned samples.
+	Percent string `json:"percent"`
+
+	// Gaps are the intervals a target was down or not scraped.
+	// +optional
+	Gaps []CoverageGap `json:"gaps,omitempty"`
+
+	// MeterDefinitions are the least covered meter definitions below 100 percent.
+	// +optional
+	MeterDefinitions []MeterDefinitionCoverage `json:"meterDefinitions,omitempty"`
+
+	// Truncated is true if gaps or meter definitions were left out.
+	// +optional
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Held is true if the upload was held because coverage was below the minimum.
+	// A held report is kept in the spool until it is released.
+	// +optional
+	Held bool `json:"held,omitempty"`
+}
+
+// CoverageGap is an interval at least one target was down or not scraped.
+type CoverageGap struct {
+	Start metav1.Time `json:"start"`
+	End   metav1.Time `json:"end"`
+}
+
+// MeterDefinitionCoverage is the coverage of a single meter definition.
+type MeterDefinitionCoverage struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Percent   string `json:"percent"`
+}
+
+const (
+	UploadStatusSuccess = "success"
+	UploadStatusFailure = "failure"
+	UploadStatusPending = "pending"
+)
+
+// UploadDetails is the result of uploading the report to a single target.
+type UploadDetails struct {
+	// Target is the name of the upload target.
+	Target string `json:"target"`
+
+	// Status is success, failure or pending if the report is spooled.
+	Status string `json:"status"`
+
+	// UploadID is the receipt returned by the target.
+	// +optional
+	UploadID string `json:"uploadID,omitempty"`
+
+	// Error is the last error returned by the target.
+	// +optional
+	Error string `json:"error,omitempty"`
+
+	// Attempts is the number of requests made on the last upload.
+	// +optional
+	Attempts int `json:"attempts,omitempty"`
+
+	// LastAttemptTime is the time of the last upload attempt.
+	// +optional
+	LastAttemptTime metav1.Time `json:"lastAttemptTime,omitempty"`
+}
+
+// SetUploadStatus records the upload result for a target, replacing any
+// earlier result for the same target.
+func (s *MeterReportStatus) SetUploadStatus(details UploadDetails) {
+	for i := range s.UploadStatus {
+		if s.UploadStatus[i].Target == details.Target {
+			s.UploadStatus[i] = details
+			return
+		}
+	}
+
+	s.UploadStatus = append(s.UploadStatus, details)
+}
+
+// FailedUploadTargets returns the targets whose last upload failed.
+// Pending uploads are left to the spool.
+func (s *MeterReportStatus) FailedUploadTargets() []string {
+	targets := []string{}
+	for _, details := range s.UploadStatus {
+		if details.Status == UploadStatusFailure {
+			targets = append(targets, details.Target)
+		}
+	}
+	return targets
+}
+
+// PendingUploadTargets returns the targets whose upload is waiting in the
+// spool.
+func (s *MeterReportStatus) PendingUploadTargets() []string {
+	targets := []string{}
+	for _, details := range s.UploadStatus {
+		if details.Status == UploadStatusPending {
+			targets = append(targets, details.Target)
+		}
+	}
+	return targets
 }
 
 const (
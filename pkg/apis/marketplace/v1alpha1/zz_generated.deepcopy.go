@@ -118,6 +118,82 @@ func (in *CSVNamespacedName) DeepCopy() *CSVNamespacedName {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CoverageGap) DeepCopyInto(out *CoverageGap) {
+	*out = *in
+	in.Start.DeepCopyInto(&out.Start)
+	in.End.DeepCopyInto(&out.End)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CoverageGap.
+func (in *CoverageGap) DeepCopy() *CoverageGap {
+	if in == nil {
+		return nil
+	}
+	out := new(CoverageGap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunMetric) DeepCopyInto(out *DryRunMetric) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunMetric.
+func (in *DryRunMetric) DeepCopy() *DryRunMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunSummary) DeepCopyInto(out *DryRunSummary) {
+	*out = *in
+	if in.Workloads != nil {
+		in, out := &in.Workloads, &out.Workloads
+		*out = make([]DryRunWorkloadTotal, len(*in))
+		copy(*out, *in)
+	}
+	if in.TopMetrics != nil {
+		in, out := &in.TopMetrics, &out.TopMetrics
+		*out = make([]DryRunMetric, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunSummary.
+func (in *DryRunSummary) DeepCopy() *DryRunSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunWorkloadTotal) DeepCopyInto(out *DryRunWorkloadTotal) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunWorkloadTotal.
+func (in *DryRunWorkloadTotal) DeepCopy() *DryRunWorkloadTotal {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunWorkloadTotal)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in Header) DeepCopyInto(out *Header) {
 	{
@@ -408,6 +484,16 @@ func (in *MeterBaseSpec) DeepCopyInto(out *MeterBaseSpec) {
 		*out = new(v1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ReportRetentionDays != nil {
+		in, out := &in.ReportRetentionDays, &out.ReportRetentionDays
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ReportSpool != nil {
+		in, out := &in.ReportSpool, &out.ReportSpool
+		*out = new(ReportSpoolSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -501,6 +587,22 @@ func (in *MeterDefinition) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MeterDefinitionCoverage) DeepCopyInto(out *MeterDefinitionCoverage) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MeterDefinitionCoverage.
+func (in *MeterDefinitionCoverage) DeepCopy() *MeterDefinitionCoverage {
+	if in == nil {
+		return nil
+	}
+	out := new(MeterDefinitionCoverage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MeterDefinitionList) DeepCopyInto(out *MeterDefinitionList) {
 	*out = *in
@@ -599,6 +701,10 @@ func (in *MeterDefinitionStatus) DeepCopyInto(out *MeterDefinitionStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.RecordingRulesTime != nil {
+		in, out := &in.RecordingRulesTime, &out.RecordingRulesTime
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -615,6 +721,16 @@ func (in *MeterDefinitionStatus) DeepCopy() *MeterDefinitionStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MeterLabelQuery) DeepCopyInto(out *MeterLabelQuery) {
 	*out = *in
+	if in.Quantiles != nil {
+		in, out := &in.Quantiles, &out.Quantiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	return
 }
 
@@ -748,11 +864,32 @@ func (in *MeterReportStatus) DeepCopyInto(out *MeterReportStatus) {
 		*out = new(types.UID)
 		**out = **in
 	}
+	if in.UploadTime != nil {
+		in, out := &in.UploadTime, &out.UploadTime
+		*out = (*in).DeepCopy()
+	}
 	if in.QueryErrorList != nil {
 		in, out := &in.QueryErrorList, &out.QueryErrorList
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.UploadStatus != nil {
+		in, out := &in.UploadStatus, &out.UploadStatus
+		*out = make([]UploadDetails, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DryRunSummary != nil {
+		in, out := &in.DryRunSummary, &out.DryRunSummary
+		*out = new(DryRunSummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Coverage != nil {
+		in, out := &in.Coverage, &out.Coverage
+		*out = new(ReportCoverage)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1171,6 +1308,61 @@ func (in *RemoteResourceS3Status) DeepCopy() *RemoteResourceS3Status {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReportCoverage) DeepCopyInto(out *ReportCoverage) {
+	*out = *in
+	if in.Gaps != nil {
+		in, out := &in.Gaps, &out.Gaps
+		*out = make([]CoverageGap, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MeterDefinitions != nil {
+		in, out := &in.MeterDefinitions, &out.MeterDefinitions
+		*out = make([]MeterDefinitionCoverage, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportCoverage.
+func (in *ReportCoverage) DeepCopy() *ReportCoverage {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportCoverage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReportSpoolSpec) DeepCopyInto(out *ReportSpoolSpec) {
+	*out = *in
+	if in.Class != nil {
+		in, out := &in.Class, &out.Class
+		*out = new(string)
+		**out = **in
+	}
+	out.Size = in.Size.DeepCopy()
+	if in.AccessModes != nil {
+		in, out := &in.AccessModes, &out.AccessModes
+		*out = make([]v1.PersistentVolumeAccessMode, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportSpoolSpec.
+func (in *ReportSpoolSpec) DeepCopy() *ReportSpoolSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportSpoolSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Request) DeepCopyInto(out *Request) {
 	*out = *in
@@ -1232,6 +1424,23 @@ func (in *StorageSpec) DeepCopy() *StorageSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UploadDetails) DeepCopyInto(out *UploadDetails) {
+	*out = *in
+	in.LastAttemptTime.DeepCopyInto(&out.LastAttemptTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UploadDetails.
+func (in *UploadDetails) DeepCopy() *UploadDetails {
+	if in == nil {
+		return nil
+	}
+	out := new(UploadDetails)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ValueFrom) DeepCopyInto(out *ValueFrom) {
 	*out = *in
@@ -1252,11 +1461,21 @@ func (in *ValueFrom) DeepCopy() *ValueFrom {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Workload) DeepCopyInto(out *Workload) {
 	*out = *in
+	if in.GroupVersionKind != nil {
+		in, out := &in.GroupVersionKind, &out.GroupVersionKind
+		*out = new(common.GroupVersionKind)
+		**out = **in
+	}
 	if in.OwnerCRD != nil {
 		in, out := &in.OwnerCRD, &out.OwnerCRD
 		*out = new(common.GroupVersionKind)
 		**out = **in
 	}
+	if in.OwnerMaxDepth != nil {
+		in, out := &in.OwnerMaxDepth, &out.OwnerMaxDepth
+		*out = new(int32)
+		**out = **in
+	}
 	if in.LabelSelector != nil {
 		in, out := &in.LabelSelector, &out.LabelSelector
 		*out = new(metav1.LabelSelector)
@@ -1270,6 +1489,18 @@ func (in *Workload) DeepCopyInto(out *Workload) {
 	if in.MetricLabels != nil {
 		in, out := &in.MetricLabels, &out.MetricLabels
 		*out = make([]MeterLabelQuery, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ResourceLabels != nil {
+		in, out := &in.ResourceLabels, &out.ResourceLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AdditionalLabels != nil {
+		in, out := &in.AdditionalLabels, &out.AdditionalLabels
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 	return
@@ -1289,6 +1520,11 @@ func (in *Workload) DeepCopy() *Workload {
 func (in *WorkloadResource) DeepCopyInto(out *WorkloadResource) {
 	*out = *in
 	in.NamespacedNameReference.DeepCopyInto(&out.NamespacedNameReference)
+	if in.RootOwner != nil {
+		in, out := &in.RootOwner, &out.RootOwner
+		*out = new(common.NamespacedNameReference)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
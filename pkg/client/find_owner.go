@@ -17,15 +17,29 @@ package client
 import (
 	"context"
 	"strings"
+	"time"
 
 	"emperror.dev/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/cache"
 )
 
+// ownerCacheTTL is how long the owner of a resource is cached. Owners
+// rarely change, the TTL bounds how stale a changed or deleted owner is.
+const ownerCacheTTL = 5 * time.Minute
+
 type FindOwnerHelper struct {
 	client *DynamicClient
+
+	// owners caches the controller owner of a resource by its UID
+	owners *cache.Expiring
+}
+
+// ownerLookup is a cached lookup, owner is nil for resources without one.
+type ownerLookup struct {
+	owner *metav1.OwnerReference
 }
 
 func NewFindOwnerHelper(
@@ -33,10 +47,78 @@ func NewFindOwnerHelper(
 ) *FindOwnerHelper {
 	return &FindOwnerHelper{
 		client: dynamicClient,
+		owners: cache.NewExpiring(),
 	}
 }
 
+// FindOwner returns the controller owner of the lookupOwner resource. The
+// lookup is cached by the resource's UID.
 func (f *FindOwnerHelper) FindOwner(name, namespace string, lookupOwner *metav1.OwnerReference) (owner *metav1.OwnerReference, err error) {
+	if lookupOwner.UID != "" {
+		if val, ok := f.owners.Get(lookupOwner.UID); ok {
+			return val.(ownerLookup).owner, nil
+		}
+	}
+
+	owner, err = f.findOwner(name, namespace, lookupOwner)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if lookupOwner.UID != "" {
+		f.owners.Set(lookupOwner.UID, ownerLookup{owner: owner}, ownerCacheTTL)
+	}
+
+	return owner, nil
+}
+
+// WalkOwners calls fn with each controller owner of the object, starting
+// with its own, until fn returns false, an owner has no owner or maxDepth
+// owners were visited. Without a helper only the object's own owner is
+// visited.
+func (f *FindOwnerHelper) WalkOwners(
+	obj metav1.Object,
+	maxDepth int,
+	fn func(owner *metav1.OwnerReference) bool,
+) error {
+	owner := metav1.GetControllerOf(obj)
+	namespace := obj.GetNamespace()
+
+	for depth := 1; owner != nil && depth <= maxDepth; depth++ {
+		if !fn(owner) || f == nil || depth == maxDepth {
+			return nil
+		}
+
+		var err error
+		owner, err = f.FindOwner(owner.Name, namespace, owner)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindRootOwner returns the top-most controller owner of the object within
+// maxDepth levels, or nil if it has no owner.
+func (f *FindOwnerHelper) FindRootOwner(obj metav1.Object, maxDepth int) (*metav1.OwnerReference, error) {
+	var root *metav1.OwnerReference
+
+	err := f.WalkOwners(obj, maxDepth, func(owner *metav1.OwnerReference) bool {
+		root = owner
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+func (f *FindOwnerHelper) findOwner(name, namespace string, lookupOwner *metav1.OwnerReference) (owner *metav1.OwnerReference, err error) {
 	apiVersionSplit := strings.Split(lookupOwner.APIVersion, "/")
 	var group, version string
 
@@ -39,7 +39,9 @@ import (
 	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils"
 	"github.com/spf13/pflag"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -392,33 +394,37 @@ func (r *ReconcileMeterBase) Reconcile(request reconcile.Request) (reconcile.Res
 		HandleResult(
 			ListAction(meterReportList, client.InNamespace(request.Namespace)),
 			OnContinue(Call(func() (ClientAction, error) {
-				loc := time.UTC
-				dateRangeInDays := -30
-
-				meterReportNames := r.sortMeterReports(meterReportList)
+				schedule, err := newReportSchedule(instance.Spec)
+				if err != nil {
+					reqLogger.Error(err, "invalid report schedule")
+					return nil, err
+				}
 
 				// prune old reports
-				meterReportNames, err := r.removeOldReports(meterReportNames, loc, dateRangeInDays, request)
+				meterReports, err := r.removeOldReports(meterReportList.Items, schedule, request)
 				if err != nil {
 					reqLogger.Error(err, err.Error())
 				}
 
 				// fill in gaps of missing reports
 				// we want the min date to be install date - 1 day
-				endDate := time.Now().In(loc)
-
-				minDate := instance.ObjectMeta.CreationTimestamp.Time.In(loc)
-				minDate = utils.TruncateTime(minDate, loc)
+				endDate := time.Now()
+				minDate := instance.ObjectMeta.CreationTimestamp.Time
 
-				expectedCreatedDates := r.generateExpectedDates(endDate, loc, dateRangeInDays, minDate)
-				foundCreatedDates, err := r.generateFoundCreatedDates(meterReportNames)
+				expectedPeriods := schedule.expectedPeriods(endDate, minDate)
 
-				if err != nil {
-					return nil, err
+				var foundPeriods []reportPeriod
+				for i := range meterReports {
+					period, ok := reportPeriodFor(&meterReports[i])
+					if !ok {
+						log.Info("meterreport name was irregular", "name", meterReports[i].Name)
+						continue
+					}
+					foundPeriods = append(foundPeriods, period)
 				}
 
-				log.Info("report dates", "expected", expectedCreatedDates, "found", foundCreatedDates, "min", minDate)
-				err = r.createReportIfNotFound(expectedCreatedDates, foundCreatedDates, request, instance)
+				log.Info("report periods", "expected", len(expectedPeriods), "found", len(foundPeriods), "min", minDate)
+				err = r.createReportIfNotFound(schedule, expectedPeriods, foundPeriods, request, instance)
 
 				if err != nil {
 					return nil, err
@@ -439,25 +445,108 @@ func (r *ReconcileMeterBase) Reconcile(request reconcile.Request) (reconcile.Res
 		return result.Return()
 	}
 
+	if instance.Spec.ReportSpool != nil {
+		if result, err := cc.Do(
+			context.TODO(),
+			Do(r.reconcileReportSpool(instance, factory, meterReportList.Items)...),
+		); result.Is(Error) || result.Is(Requeue) {
+			if err != nil {
+				return result.ReturnWithError(merrors.Wrap(err, "error reconciling report spool"))
+			}
+
+			return result.Return()
+		}
+	}
+
 	reqLogger.Info("finished reconciling")
 	return reconcile.Result{RequeueAfter: time.Hour * 1}, nil
 }
 
+// reconcileReportSpool creates the claim the reporter jobs spool reports to
+// and runs the upload-pending job while reports have uploads waiting in the
+// spool. The job drains the spool of every report in the namespace, so the
+// meterbase owns it. A finished job is deleted once it is an hour old and
+// the next reconcile starts another run.
+func (r *ReconcileMeterBase) reconcileReportSpool(
+	instance *marketplacev1alpha1.MeterBase,
+	factory *manifests.Factory,
+	meterReports []marketplacev1alpha1.MeterReport,
+) []ClientAction {
+	pvc := &corev1.PersistentVolumeClaim{}
+	job := &batchv1.Job{}
+
+	var pending *marketplacev1alpha1.MeterReport
+	for i := range meterReports {
+		if len(meterReports[i].Status.PendingUploadTargets()) > 0 {
+			pending = &meterReports[i]
+			break
+		}
+	}
+
+	return []ClientAction{
+		manifests.CreateIfNotExistsFactoryItem(
+			pvc,
+			func() (runtime.Object, error) {
+				return factory.ReporterSpoolPVC(instance.Spec.ReportSpool)
+			},
+			CreateWithAddOwner(instance),
+		),
+		HandleResult(
+			GetAction(types.NamespacedName{
+				Namespace: instance.Namespace,
+				Name:      utils.REPORTER_UPLOAD_PENDING_JOB_NAME,
+			}, job),
+			OnNotFound(Call(func() (ClientAction, error) {
+				if pending == nil {
+					return nil, nil
+				}
+
+				log.Info("uploads pending, starting upload pending job",
+					"report", pending.Name, "targets", pending.Status.PendingUploadTargets())
+				return manifests.CreateIfNotExistsFactoryItem(
+					job,
+					func() (runtime.Object, error) {
+						return factory.ReporterUploadPendingJob(pending.Spec.ExtraArgs)
+					},
+					CreateWithAddOwner(instance),
+				), nil
+			})),
+			OnContinue(Call(func() (ClientAction, error) {
+				jr := &common.JobReference{}
+				jr.SetFromJob(job)
+
+				if !jr.IsDone() || time.Since(job.CreationTimestamp.Time) < time.Hour {
+					return nil, nil
+				}
+
+				return DeleteAction(job, DeleteWithDeleteOptions(client.PropagationPolicy(metav1.DeletePropagationBackground))), nil
+			})),
+		),
+	}
+}
+
 const promServiceName = "rhm-prometheus-meterbase"
 
-func (r *ReconcileMeterBase) createReportIfNotFound(expectedCreatedDates []string, foundCreatedDates []string, request reconcile.Request, instance *marketplacev1alpha1.MeterBase) error {
+func (r *ReconcileMeterBase) createReportIfNotFound(
+	schedule *reportSchedule,
+	expectedPeriods []reportPeriod,
+	foundPeriods []reportPeriod,
+	request reconcile.Request,
+	instance *marketplacev1alpha1.MeterBase,
+) error {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 
-	// find the diff between the dates we expect and the dates found on the cluster and create any missing reports
-	missingReports := utils.FindDiff(expectedCreatedDates, foundCreatedDates)
-	for _, missingReportDateString := range missingReports {
-		missingReportName := r.newMeterReportNameFromString(missingReportDateString)
-		missingReportStartDate, _ := time.Parse(utils.DATE_FORMAT, missingReportDateString)
-		missingReportEndDate := missingReportStartDate.AddDate(0, 0, 1)
+	// find the time we expect reports for that no report on the cluster covers and create reports for it
+	for _, missing := range missingPeriods(expectedPeriods, foundPeriods) {
+		missingReportName := schedule.name(missing)
 
-		missingMeterReport := r.newMeterReport(request.Namespace, missingReportStartDate, missingReportEndDate, missingReportName, instance, promServiceName)
+		missingMeterReport := r.newMeterReport(request.Namespace, missing.start, missing.end, missingReportName, instance, promServiceName)
 		err := r.client.Create(context.TODO(), missingMeterReport)
 		if err != nil {
+			if k8serrors.IsAlreadyExists(err) {
+				reqLogger.Info("Missing report name already taken", "Resource", missingReportName)
+				continue
+			}
 			return err
 		}
 		reqLogger.Info("Created Missing Report", "Resource", missingReportName)
@@ -466,33 +555,32 @@ func (r *ReconcileMeterBase) createReportIfNotFound(expectedCreatedDates []strin
 	return nil
 }
 
-func (r *ReconcileMeterBase) removeOldReports(meterReportNames []string, loc *time.Location, dateRange int, request reconcile.Request) ([]string, error) {
+func (r *ReconcileMeterBase) removeOldReports(
+	meterReports []marketplacev1alpha1.MeterReport,
+	schedule *reportSchedule,
+	request reconcile.Request,
+) ([]marketplacev1alpha1.MeterReport, error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
-	limit := utils.TruncateTime(time.Now(), loc).AddDate(0, 0, dateRange)
-	for _, reportName := range meterReportNames {
-		dateCreated, err := r.retrieveCreatedDate(reportName)
+	limit := schedule.retentionLimit(time.Now())
 
-		if err != nil {
+	var kept []marketplacev1alpha1.MeterReport
+	for i := range meterReports {
+		report := &meterReports[i]
+		period, ok := reportPeriodFor(report)
+
+		if !ok || !period.start.Before(limit) {
+			kept = append(kept, *report)
 			continue
 		}
 
-		if dateCreated.Before(limit) {
-			reqLogger.Info("Deleting Report", "Resource", reportName)
-			meterReportNames = utils.RemoveKey(meterReportNames, reportName)
-			deleteReport := &marketplacev1alpha1.MeterReport{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      reportName,
-					Namespace: request.Namespace,
-				},
-			}
-			err := r.client.Delete(context.TODO(), deleteReport)
-			if err != nil {
-				return nil, err
-			}
+		reqLogger.Info("Deleting Report", "Resource", report.Name)
+		err := r.client.Delete(context.TODO(), report)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return nil, err
 		}
 	}
 
-	return meterReportNames, nil
+	return kept, nil
 }
 
 // configPath: /etc/config/prometheus.yml
@@ -506,74 +594,6 @@ type MeterbaseOpts struct {
 	corev1.PullPolicy
 }
 
-func (r *ReconcileMeterBase) sortMeterReports(meterReportList *marketplacev1alpha1.MeterReportList) []string {
-
-	var meterReportNames []string
-	for _, report := range meterReportList.Items {
-		meterReportNames = append(meterReportNames, report.Name)
-	}
-
-	sort.Strings(meterReportNames)
-	return meterReportNames
-}
-
-func (r *ReconcileMeterBase) retrieveCreatedDate(reportName string) (time.Time, error) {
-	splitStr := strings.SplitN(reportName, "-", 3)
-
-	if len(splitStr) != 3 {
-		return time.Now(), errors.New("failed to get date")
-	}
-
-	dateString := splitStr[2:]
-	return time.Parse(utils.DATE_FORMAT, strings.Join(dateString, ""))
-}
-
-func (r *ReconcileMeterBase) newMeterReportNameFromDate(date time.Time) string {
-	dateSuffix := strings.Join(strings.Fields(date.String())[:1], "")
-	return fmt.Sprintf("%s%s", utils.METER_REPORT_PREFIX, dateSuffix)
-}
-
-func (r *ReconcileMeterBase) newMeterReportNameFromString(dateString string) string {
-	dateSuffix := dateString
-	return fmt.Sprintf("%s%s", utils.METER_REPORT_PREFIX, dateSuffix)
-}
-
-func (r *ReconcileMeterBase) generateFoundCreatedDates(meterReportNames []string) ([]string, error) {
-	var foundCreatedDates []string
-	for _, reportName := range meterReportNames {
-		splitStr := strings.SplitN(reportName, "-", 3)
-
-		if len(splitStr) != 3 {
-			log.Info("meterreport name was irregular", "name", reportName)
-			continue
-		}
-
-		dateString := splitStr[2:]
-		foundCreatedDates = append(foundCreatedDates, strings.Join(dateString, ""))
-	}
-	return foundCreatedDates, nil
-}
-
-func (r *ReconcileMeterBase) generateExpectedDates(endTime time.Time, loc *time.Location, dateRange int, minDate time.Time) []string {
-	// set start date
-	startDate := utils.TruncateTime(endTime, loc).AddDate(0, 0, dateRange)
-
-	if minDate.After(startDate) {
-		startDate = utils.TruncateTime(minDate, loc)
-	}
-
-	// set end date
-	endDate := utils.TruncateTime(endTime, loc)
-
-	// loop through the range of dates we expect
-	var expectedCreatedDates []string
-	for d := startDate; d.After(endDate) == false; d = d.AddDate(0, 0, 1) {
-		expectedCreatedDates = append(expectedCreatedDates, d.Format(utils.DATE_FORMAT))
-	}
-
-	return expectedCreatedDates
-}
-
 func (r *ReconcileMeterBase) newMeterReport(namespace string, startTime time.Time, endTime time.Time, meterReportName string, instance *marketplacev1alpha1.MeterBase, prometheusServiceName string) *marketplacev1alpha1.MeterReport {
 	return &marketplacev1alpha1.MeterReport{
 		ObjectMeta: metav1.ObjectMeta{
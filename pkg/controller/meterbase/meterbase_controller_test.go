@@ -15,10 +15,26 @@
 package meterbase
 
 import (
+	"context"
 	"time"
 
+	"github.com/gotidy/ptr"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/manifests"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils/reconcileutils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 var _ = Describe("MeterbaseController", func() {
@@ -31,11 +47,13 @@ var _ = Describe("MeterbaseController", func() {
 
 	Describe("check date functions", func() {
 		var (
-			ctrl *ReconcileMeterBase
+			schedule *reportSchedule
 		)
 
 		BeforeEach(func() {
-			ctrl = &ReconcileMeterBase{}
+			var err error
+			schedule, err = newReportSchedule(marketplacev1alpha1.MeterBaseSpec{})
+			Expect(err).To(Succeed())
 		})
 
 		It("reports should calculate the correct dates to create", func() {
@@ -43,13 +61,256 @@ var _ = Describe("MeterbaseController", func() {
 			endDate = endDate.AddDate(0, 0, 0)
 			minDate := endDate.AddDate(0, 0, 0)
 
-			exp := ctrl.generateExpectedDates(endDate, time.UTC, -30, minDate)
+			exp := schedule.expectedPeriods(endDate, minDate)
 			Expect(exp).To(HaveLen(1))
 
 			minDate = endDate.AddDate(0, 0, -2)
 
-			exp = ctrl.generateExpectedDates(endDate, time.UTC, -30, minDate)
+			exp = schedule.expectedPeriods(endDate, minDate)
 			Expect(exp).To(HaveLen(3))
 		})
+
+		It("should default to daily UTC reports kept for 30 days", func() {
+			endDate := time.Date(2020, 10, 15, 13, 30, 0, 0, time.UTC)
+
+			exp := schedule.expectedPeriods(endDate, time.Time{})
+			Expect(exp).To(HaveLen(31))
+			Expect(exp[0].start).To(Equal(time.Date(2020, 9, 15, 0, 0, 0, 0, time.UTC)))
+			Expect(schedule.name(exp[30])).To(Equal("meter-report-2020-10-15"))
+		})
+
+		It("should honor interval, timezone and retention", func() {
+			retention := int32(90)
+			schedule, err := newReportSchedule(marketplacev1alpha1.MeterBaseSpec{
+				ReportInterval:      marketplacev1alpha1.ReportIntervalHourly,
+				ReportTimezone:      "America/New_York",
+				ReportRetentionDays: &retention,
+			})
+			Expect(err).To(Succeed())
+
+			endDate := time.Date(2020, 10, 15, 13, 30, 0, 0, time.UTC)
+			minDate := time.Date(2020, 10, 15, 9, 0, 0, 0, time.UTC)
+
+			exp := schedule.expectedPeriods(endDate, minDate)
+			// midnight in New York is 04:00 UTC
+			Expect(exp).To(HaveLen(10))
+			Expect(exp[0].start.UTC()).To(Equal(time.Date(2020, 10, 15, 4, 0, 0, 0, time.UTC)))
+			Expect(exp[9].end.Sub(exp[9].start)).To(Equal(time.Hour))
+			Expect(schedule.name(exp[9])).To(Equal("meter-report-2020-10-15-13"))
+
+			Expect(schedule.retentionLimit(endDate).UTC()).To(Equal(time.Date(2020, 7, 17, 4, 0, 0, 0, time.UTC)))
+		})
+
+		It("should start hours on the hour of a half hour timezone", func() {
+			schedule, err := newReportSchedule(marketplacev1alpha1.MeterBaseSpec{
+				ReportInterval: marketplacev1alpha1.ReportIntervalHourly,
+				ReportTimezone: "Asia/Kolkata",
+			})
+			Expect(err).To(Succeed())
+
+			endDate := time.Date(2020, 10, 15, 2, 45, 0, 0, time.UTC)
+			minDate := time.Date(2020, 10, 15, 0, 0, 0, 0, time.UTC)
+
+			exp := schedule.expectedPeriods(endDate, minDate)
+			// midnight in Kolkata is 18:30 UTC the day before
+			Expect(exp).To(HaveLen(9))
+			Expect(exp[0].start.UTC()).To(Equal(time.Date(2020, 10, 14, 18, 30, 0, 0, time.UTC)))
+
+			for _, period := range exp {
+				Expect(period.start.In(schedule.loc).Minute()).To(Equal(0))
+				Expect(period.end.Sub(period.start)).To(Equal(time.Hour))
+			}
+
+			Expect(exp[8].start.UTC()).To(Equal(time.Date(2020, 10, 15, 2, 30, 0, 0, time.UTC)))
+			Expect(schedule.truncate(endDate).UTC()).To(Equal(time.Date(2020, 10, 15, 2, 30, 0, 0, time.UTC)))
+		})
+
+		It("should reject an invalid schedule", func() {
+			_, err := newReportSchedule(marketplacev1alpha1.MeterBaseSpec{ReportTimezone: "Not/AZone"})
+			Expect(err).To(HaveOccurred())
+
+			_, err = newReportSchedule(marketplacev1alpha1.MeterBaseSpec{ReportInterval: "Weekly"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should not overlap legacy daily reports when switching to hourly", func() {
+			hourly, err := newReportSchedule(marketplacev1alpha1.MeterBaseSpec{
+				ReportInterval: marketplacev1alpha1.ReportIntervalHourly,
+			})
+			Expect(err).To(Succeed())
+
+			legacy, ok := reportPeriodFromName("meter-report-2020-10-14")
+			Expect(ok).To(BeTrue())
+
+			endDate := time.Date(2020, 10, 15, 2, 30, 0, 0, time.UTC)
+			minDate := time.Date(2020, 10, 14, 0, 0, 0, 0, time.UTC)
+
+			missing := missingPeriods(hourly.expectedPeriods(endDate, minDate), []reportPeriod{legacy})
+			Expect(missing).To(HaveLen(3))
+			Expect(hourly.name(missing[0])).To(Equal("meter-report-2020-10-15-00"))
+		})
+
+		It("should only fill gaps left by hourly reports when switching to daily", func() {
+			start := time.Date(2020, 10, 15, 0, 0, 0, 0, time.UTC)
+			covered := []reportPeriod{
+				{start: start, end: start.Add(time.Hour)},
+				{start: start.Add(time.Hour), end: start.Add(2 * time.Hour)},
+			}
+
+			missing := missingPeriods([]reportPeriod{{start: start, end: start.AddDate(0, 0, 1)}}, covered)
+			Expect(missing).To(HaveLen(1))
+			Expect(missing[0].start).To(Equal(start.Add(2 * time.Hour)))
+			Expect(missing[0].end).To(Equal(start.AddDate(0, 0, 1)))
+			Expect(schedule.name(missing[0])).To(Equal("meter-report-2020-10-15-02"))
+
+			missing = missingPeriods([]reportPeriod{{start: start.AddDate(0, 0, 1), end: start.AddDate(0, 0, 2)}}, covered)
+			Expect(missing).To(HaveLen(1))
+			Expect(schedule.name(missing[0])).To(Equal("meter-report-2020-10-16"))
+		})
+
+		It("should remove reports past retention", func() {
+			now := time.Now().UTC()
+			old := &marketplacev1alpha1.MeterReport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "meter-report-" + now.AddDate(0, 0, -45).Format(utils.DATE_FORMAT),
+					Namespace: namespace,
+				},
+			}
+			recent := &marketplacev1alpha1.MeterReport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "meter-report-" + now.AddDate(0, 0, -1).Format(utils.DATE_FORMAT),
+					Namespace: namespace,
+				},
+			}
+
+			s := scheme.Scheme
+			s.AddKnownTypes(marketplacev1alpha1.SchemeGroupVersion, &marketplacev1alpha1.MeterReport{}, &marketplacev1alpha1.MeterReportList{})
+			ctrl := &ReconcileMeterBase{client: fake.NewFakeClientWithScheme(s, old, recent), scheme: s}
+
+			kept, err := ctrl.removeOldReports(
+				[]marketplacev1alpha1.MeterReport{*old, *recent},
+				schedule,
+				reconcile.Request{NamespacedName: types.NamespacedName{Name: "meterbase", Namespace: namespace}},
+			)
+			Expect(err).To(Succeed())
+			Expect(kept).To(HaveLen(1))
+			Expect(kept[0].Name).To(Equal(recent.Name))
+
+			list := &marketplacev1alpha1.MeterReportList{}
+			Expect(ctrl.client.List(context.TODO(), list)).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+		})
+	})
+
+	Describe("report spool", func() {
+		var (
+			s         *runtime.Scheme
+			meterbase *marketplacev1alpha1.MeterBase
+			report    *marketplacev1alpha1.MeterReport
+		)
+
+		BeforeEach(func() {
+			s = scheme.Scheme
+			s.AddKnownTypes(marketplacev1alpha1.SchemeGroupVersion,
+				&marketplacev1alpha1.MeterBase{},
+				&marketplacev1alpha1.MeterReport{},
+				&marketplacev1alpha1.MeterReportList{},
+			)
+
+			meterbase = &marketplacev1alpha1.MeterBase{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.METERBASE_NAME,
+					Namespace: namespace,
+					UID:       "meterbase-uid",
+				},
+				Spec: marketplacev1alpha1.MeterBaseSpec{
+					Enabled: true,
+					ReportSpool: &marketplacev1alpha1.ReportSpoolSpec{
+						Class: ptr.String("shared"),
+					},
+				},
+			}
+
+			conditions := status.NewConditions(marketplacev1alpha1.ReportConditionJobFinished)
+			report = &marketplacev1alpha1.MeterReport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "meter-report-2020-10-01",
+					Namespace: namespace,
+				},
+				Spec: marketplacev1alpha1.MeterReportSpec{
+					ExtraArgs: []string{"--uploadTarget", "redhat-insights,s3"},
+				},
+				Status: marketplacev1alpha1.MeterReportStatus{
+					Conditions: &conditions,
+					UploadStatus: []marketplacev1alpha1.UploadDetails{
+						{Target: "redhat-insights", Status: marketplacev1alpha1.UploadStatusSuccess},
+						{Target: "s3", Status: marketplacev1alpha1.UploadStatusPending},
+					},
+				},
+			}
+		})
+
+		reconcileSpool := func(objs ...runtime.Object) client.Client {
+			c := fake.NewFakeClientWithScheme(s, append([]runtime.Object{meterbase}, objs...)...)
+			ctrl := &ReconcileMeterBase{client: c, scheme: s}
+			cc := (&reconcileutils.DefaultCommandRunnerProvider{}).NewCommandRunner(c, s, log)
+			factory := manifests.NewFactory(namespace, manifests.NewDefaultConfig())
+
+			result, err := cc.Do(context.TODO(),
+				reconcileutils.Do(ctrl.reconcileReportSpool(meterbase, factory, []marketplacev1alpha1.MeterReport{*report})...))
+			Expect(err).To(Succeed())
+			Expect(result.Is(reconcileutils.Continue)).To(BeTrue())
+			return c
+		}
+
+		It("should create the spool claim and the upload pending job owned by the meterbase", func() {
+			c := reconcileSpool()
+
+			pvc := &corev1.PersistentVolumeClaim{}
+			Expect(c.Get(context.TODO(), types.NamespacedName{Name: utils.REPORTER_SPOOL_PVC_NAME, Namespace: namespace}, pvc)).To(Succeed())
+			Expect(pvc.Spec.StorageClassName).To(Equal(ptr.String("shared")))
+			Expect(pvc.Spec.AccessModes).To(Equal([]corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}))
+			Expect(pvc.OwnerReferences).To(HaveLen(1))
+			Expect(pvc.OwnerReferences[0].UID).To(Equal(meterbase.UID))
+
+			job := &batchv1.Job{}
+			Expect(c.Get(context.TODO(), types.NamespacedName{Name: utils.REPORTER_UPLOAD_PENDING_JOB_NAME, Namespace: namespace}, job)).To(Succeed())
+			args := job.Spec.Template.Spec.Containers[0].Args
+			Expect(args[0]).To(Equal("upload-pending"))
+			Expect(args).To(ContainElements("--spoolDir", "--uploadTarget", "redhat-insights,s3"))
+			Expect(job.OwnerReferences).To(HaveLen(1))
+			Expect(job.OwnerReferences[0].UID).To(Equal(meterbase.UID))
+		})
+
+		It("should not start the upload pending job without pending uploads", func() {
+			report.Status.UploadStatus = nil
+			c := reconcileSpool()
+
+			list := &batchv1.JobList{}
+			Expect(c.List(context.TODO(), list)).To(Succeed())
+			Expect(list.Items).To(BeEmpty())
+		})
+
+		It("should delete a finished upload pending job an hour after it started", func() {
+			job := func(created time.Time) *batchv1.Job {
+				return &batchv1.Job{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              utils.REPORTER_UPLOAD_PENDING_JOB_NAME,
+						Namespace:         namespace,
+						CreationTimestamp: metav1.NewTime(created),
+					},
+					Status: batchv1.JobStatus{Succeeded: 1},
+				}
+			}
+
+			list := &batchv1.JobList{}
+			c := reconcileSpool(job(time.Now().Add(-10 * time.Minute)))
+			Expect(c.List(context.TODO(), list)).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+
+			c = reconcileSpool(job(time.Now().Add(-2 * time.Hour)))
+			Expect(c.List(context.TODO(), list)).To(Succeed())
+			Expect(list.Items).To(BeEmpty())
+		})
 	})
 })
@@ -0,0 +1,204 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package meterbase
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils"
+)
+
+// hourlyReportFormat is the name suffix of hourly reports. Hourly names are
+// always written in UTC so the repeated hour of a DST change can't collide.
+const hourlyReportFormat = "2006-01-02-15"
+
+// reportPeriod is the [start, end) window a meter report covers.
+type reportPeriod struct {
+	start time.Time
+	end   time.Time
+}
+
+// reportSchedule is the cadence, alignment and retention of the meter reports
+// generated for a MeterBase.
+type reportSchedule struct {
+	interval      marketplacev1alpha1.ReportInterval
+	loc           *time.Location
+	retentionDays int
+}
+
+func newReportSchedule(spec marketplacev1alpha1.MeterBaseSpec) (*reportSchedule, error) {
+	schedule := &reportSchedule{
+		interval:      marketplacev1alpha1.ReportIntervalDaily,
+		loc:           time.UTC,
+		retentionDays: int(marketplacev1alpha1.DefaultReportRetentionDays),
+	}
+
+	switch spec.ReportInterval {
+	case "":
+	case marketplacev1alpha1.ReportIntervalDaily, marketplacev1alpha1.ReportIntervalHourly:
+		schedule.interval = spec.ReportInterval
+	default:
+		return nil, errors.Errorf("unsupported report interval %q", spec.ReportInterval)
+	}
+
+	if spec.ReportTimezone != "" {
+		loc, err := time.LoadLocation(spec.ReportTimezone)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid report timezone %q", spec.ReportTimezone)
+		}
+		schedule.loc = loc
+	}
+
+	if spec.ReportRetentionDays != nil {
+		if *spec.ReportRetentionDays < 1 {
+			return nil, errors.Errorf("report retention days must be at least 1, got %d", *spec.ReportRetentionDays)
+		}
+		schedule.retentionDays = int(*spec.ReportRetentionDays)
+	}
+
+	return schedule, nil
+}
+
+// truncate returns the start of the period containing t. Hours start on the
+// hour of the report timezone, which is not the UTC hour in zones with a
+// half hour offset.
+func (s *reportSchedule) truncate(t time.Time) time.Time {
+	if s.interval == marketplacev1alpha1.ReportIntervalHourly {
+		t = t.In(s.loc)
+		return t.Add(-time.Duration(t.Minute())*time.Minute -
+			time.Duration(t.Second())*time.Second -
+			time.Duration(t.Nanosecond()))
+	}
+
+	t = t.In(s.loc)
+	return utils.TruncateTime(t, s.loc)
+}
+
+// next returns the start of the period following the one starting at start.
+func (s *reportSchedule) next(start time.Time) time.Time {
+	if s.interval == marketplacev1alpha1.ReportIntervalHourly {
+		return start.Add(time.Hour)
+	}
+
+	return start.AddDate(0, 0, 1)
+}
+
+// retentionLimit is the time before which reports are removed.
+func (s *reportSchedule) retentionLimit(now time.Time) time.Time {
+	return utils.TruncateTime(now.In(s.loc), s.loc).AddDate(0, 0, -s.retentionDays)
+}
+
+// name returns the meter report name for a period. Full daily periods keep the
+// historical meter-report-<date> name; hourly periods and the partial periods
+// left over after an interval or timezone change use the hourly name.
+func (s *reportSchedule) name(period reportPeriod) string {
+	if s.interval == marketplacev1alpha1.ReportIntervalDaily &&
+		period.start.Equal(s.truncate(period.start)) &&
+		period.end.Equal(s.next(period.start)) {
+		return utils.METER_REPORT_PREFIX + period.start.In(s.loc).Format(utils.DATE_FORMAT)
+	}
+
+	return utils.METER_REPORT_PREFIX + period.start.UTC().Format(hourlyReportFormat)
+}
+
+// expectedPeriods returns every period from the retention limit, or the day
+// minDate falls in if later, through the period containing endTime.
+func (s *reportSchedule) expectedPeriods(endTime time.Time, minDate time.Time) []reportPeriod {
+	startDate := s.truncate(s.retentionLimit(endTime))
+
+	minDate = utils.TruncateTime(minDate.In(s.loc), s.loc)
+	if minDate.After(startDate) {
+		startDate = minDate
+	}
+
+	endDate := s.truncate(endTime)
+
+	var periods []reportPeriod
+	for d := startDate; !d.After(endDate); d = s.next(d) {
+		periods = append(periods, reportPeriod{start: d, end: s.next(d)})
+	}
+
+	return periods
+}
+
+// reportPeriodFor returns the window covered by an existing report. Reports
+// created before the window was recorded on the spec fall back to their name,
+// which is either a UTC day or a UTC hour.
+func reportPeriodFor(report *marketplacev1alpha1.MeterReport) (reportPeriod, bool) {
+	if !report.Spec.StartTime.IsZero() && report.Spec.EndTime.After(report.Spec.StartTime.Time) {
+		return reportPeriod{start: report.Spec.StartTime.Time, end: report.Spec.EndTime.Time}, true
+	}
+
+	return reportPeriodFromName(report.Name)
+}
+
+func reportPeriodFromName(name string) (reportPeriod, bool) {
+	if !strings.HasPrefix(name, utils.METER_REPORT_PREFIX) {
+		return reportPeriod{}, false
+	}
+
+	suffix := strings.TrimPrefix(name, utils.METER_REPORT_PREFIX)
+
+	if start, err := time.Parse(hourlyReportFormat, suffix); err == nil {
+		return reportPeriod{start: start, end: start.Add(time.Hour)}, true
+	}
+
+	if start, err := time.Parse(utils.DATE_FORMAT, suffix); err == nil {
+		return reportPeriod{start: start, end: start.AddDate(0, 0, 1)}, true
+	}
+
+	return reportPeriod{}, false
+}
+
+// missingPeriods subtracts the covered windows from the expected periods so
+// a report is never created for time an existing report already meters.
+func missingPeriods(expected []reportPeriod, covered []reportPeriod) []reportPeriod {
+	sorted := make([]reportPeriod, len(covered))
+	copy(sorted, covered)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].start.Before(sorted[j].start)
+	})
+
+	var missing []reportPeriod
+	for _, period := range expected {
+		cursor := period.start
+
+		for _, c := range sorted {
+			if !c.end.After(cursor) || !c.start.Before(period.end) {
+				continue
+			}
+
+			if c.start.After(cursor) {
+				missing = append(missing, reportPeriod{start: cursor, end: c.start})
+			}
+
+			cursor = c.end
+
+			if !cursor.Before(period.end) {
+				break
+			}
+		}
+
+		if cursor.Before(period.end) {
+			missing = append(missing, reportPeriod{start: cursor, end: period.end})
+		}
+	}
+
+	return missing
+}
@@ -16,19 +16,29 @@ package meterdefinition
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"reflect"
+	"regexp"
 	"strings"
 	"time"
 
+	merrors "emperror.dev/errors"
 	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	v1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
 	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/meter_definition"
+	prom "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/prometheus"
 	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils"
 	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils/patch"
 	. "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils/reconcileutils"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -83,6 +93,15 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to the recording rules of the MeterDefinition
+	err = c.Watch(&source.Kind{Type: &monitoringv1.PrometheusRule{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &v1alpha1.MeterDefinition{},
+	})
+	if err != nil {
+		return err
+	}
+
 	return err
 }
 
@@ -138,13 +157,32 @@ func (r *ReconcileMeterDefinition) Reconcile(request reconcile.Request) (reconci
 		instance.Spec.PodMeterLabels = nil
 	}
 
+	if err := meter_definition.CompileWorkloadFilters(instance); err != nil {
+		reqLogger.Error(err, "Failed to compile workload filters.")
+		queue = instance.Status.Conditions.SetCondition(v1alpha1.MeterDefConditionFiltersInvalid(err)) || queue
+	} else {
+		queue = instance.Status.Conditions.SetCondition(v1alpha1.MeterDefConditionFiltersValid) || queue
+	}
+
+	rulesChanged, err := r.reconcileRecordingRules(instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to reconcile recording rules.")
+		return reconcile.Result{}, err
+	}
+
+	if rulesChanged {
+		now := metav1.Now()
+		instance.Status.RecordingRulesTime = &now
+		queue = true
+	}
+
 	switch {
 	case instance.Status.Conditions.IsUnknownFor(v1alpha1.MeterDefConditionTypeHasResult):
 		fallthrough
 	case len(instance.Status.WorkloadResources) == 0:
-		queue = instance.Status.Conditions.SetCondition(v1alpha1.MeterDefConditionNoResults)
+		queue = instance.Status.Conditions.SetCondition(v1alpha1.MeterDefConditionNoResults) || queue
 	case len(instance.Status.WorkloadResources) > 0:
-		queue = instance.Status.Conditions.SetCondition(v1alpha1.MeterDefConditionHasResults)
+		queue = instance.Status.Conditions.SetCondition(v1alpha1.MeterDefConditionHasResults) || queue
 	}
 
 	result, _ = cc.Do(
@@ -165,6 +203,122 @@ func (r *ReconcileMeterDefinition) Reconcile(request reconcile.Request) (reconci
 	return reconcile.Result{RequeueAfter: time.Minute * 1}, nil
 }
 
+// reconcileRecordingRules keeps a PrometheusRule per workload recording the
+// joined series the reporter queries, and removes the rules of workloads
+// that were removed. It returns true if any rule changed.
+func (r *ReconcileMeterDefinition) reconcileRecordingRules(instance *v1alpha1.MeterDefinition) (bool, error) {
+	changed := false
+	desired := map[string]bool{}
+	mdefName := types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}
+
+	for i, workload := range instance.Spec.Workloads {
+		group, err := prom.NewRecordingRuleGroup(mdefName, workload)
+		if err != nil {
+			log.Error(err, "skipping recording rules of workload", "workload", workload.Name)
+			continue
+		}
+
+		rule := &monitoringv1.PrometheusRule{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      recordingRuleName(instance.Namespace, instance.Name, workload.Name, i),
+				Namespace: instance.Namespace,
+				Labels:    labelsForPrometheusRule(instance.Name, instance.Namespace),
+			},
+			Spec: monitoringv1.PrometheusRuleSpec{
+				Groups: []monitoringv1.RuleGroup{group},
+			},
+		}
+
+		if err := controllerutil.SetControllerReference(instance, rule, r.scheme); err != nil {
+			return changed, merrors.Wrap(err, "failed to set owner of recording rule")
+		}
+
+		desired[rule.Name] = true
+
+		existing := &monitoringv1.PrometheusRule{}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: rule.Name, Namespace: rule.Namespace}, existing)
+
+		switch {
+		case k8serrors.IsNotFound(err):
+			if err := r.client.Create(context.TODO(), rule); err != nil {
+				return changed, merrors.Wrap(err, "failed to create recording rule")
+			}
+			changed = true
+		case err != nil:
+			return changed, merrors.Wrap(err, "failed to get recording rule")
+		case !reflect.DeepEqual(existing.Spec, rule.Spec) || !reflect.DeepEqual(existing.Labels, rule.Labels):
+			existing.Spec = rule.Spec
+			existing.Labels = rule.Labels
+			if err := r.client.Update(context.TODO(), existing); err != nil {
+				return changed, merrors.Wrap(err, "failed to update recording rule")
+			}
+			changed = true
+		}
+	}
+
+	rules := &monitoringv1.PrometheusRuleList{}
+	err := r.client.List(context.TODO(), rules,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels(labelsForPrometheusRule(instance.Name, instance.Namespace)))
+	if err != nil {
+		return changed, merrors.Wrap(err, "failed to list recording rules")
+	}
+
+	for _, rule := range rules.Items {
+		if desired[rule.Name] {
+			continue
+		}
+
+		if err := r.client.Delete(context.TODO(), rule); err != nil && !k8serrors.IsNotFound(err) {
+			return changed, merrors.Wrap(err, "failed to delete recording rule")
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// recordingRuleName is the name of the PrometheusRule of a workload. Unnamed
+// workloads use their index. Sanitizing can map different names to the
+// same string, so a hash of the meter definition and workload keeps the
+// name unique, and the readable part is cut to fit the name limit.
+func recordingRuleName(namespace, mdefName, workloadName string, index int) string {
+	if workloadName == "" {
+		workloadName = fmt.Sprintf("%d", index)
+	}
+
+	hash := shortHash(namespace + "/" + mdefName + "/" + workloadName)
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(mdefName+"-"+workloadName), "-")
+	name = truncate(strings.Trim(name, "-"), validation.DNS1123SubdomainMaxLength-len(hash)-1)
+
+	return strings.Trim(name, "-") + "-" + hash
+}
+
+// safeLabelValue cuts a value longer than a label value may be, keeping it
+// unique with a hash of the whole value.
+func safeLabelValue(value string) string {
+	if len(value) <= validation.LabelValueMaxLength {
+		return value
+	}
+
+	hash := shortHash(value)
+	return strings.TrimRight(value[:validation.LabelValueMaxLength-len(hash)-1], "-_.") + "-" + hash
+}
+
+func shortHash(value string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(value)))[:8]
+}
+
+func truncate(value string, length int) string {
+	if len(value) > length {
+		return value[:length]
+	}
+
+	return value
+}
+
 func (r *ReconcileMeterDefinition) finalizeMeterDefinition(req *v1alpha1.MeterDefinition) (reconcile.Result, error) {
 	var err error
 
@@ -201,6 +355,15 @@ func labelsForServiceMonitor(name, namespace string) map[string]string {
 	}
 }
 
+func labelsForPrometheusRule(name, namespace string) map[string]string {
+	return map[string]string{
+		"marketplace.redhat.com/metering":                  "true",
+		"marketplace.redhat.com/metered.kind":              "PrometheusRule",
+		"marketplace.redhat.com/meterDefinition.namespace": namespace,
+		"marketplace.redhat.com/meterDefinition.name":      safeLabelValue(name),
+	}
+}
+
 func labelsForKubeStateMonitor(name, namespace string) map[string]string {
 	return map[string]string{
 		"marketplace.redhat.com/metered":                   "true",
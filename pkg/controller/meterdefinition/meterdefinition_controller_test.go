@@ -15,14 +15,20 @@
 package meterdefinition
 
 import (
+	"strings"
+
 	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	. "github.com/onsi/ginkgo"
 	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
 	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils/reconcileutils"
 	. "github.com/redhat-marketplace/redhat-marketplace-operator/test/rectest"
+	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -32,6 +38,16 @@ var _ = Describe("Testing with Ginkgo", func() {
 
 		testNoServiceMonitors(GinkgoT())
 	})
+
+	It("should record the workload queries", func() {
+		testRecordingRules(GinkgoT())
+	})
+	It("should name recording rules uniquely", func() {
+		testRecordingRuleNames(GinkgoT())
+	})
+	It("should report filter compile errors", func() {
+		testInvalidExpression(GinkgoT())
+	})
 })
 
 var (
@@ -79,3 +95,127 @@ func testNoServiceMonitors(t GinkgoTInterface) {
 		),
 	)
 }
+
+func testRecordingRules(t GinkgoTInterface) {
+	t.Parallel()
+	mdef := meterdefinition.DeepCopy()
+	mdef.Spec.Workloads = []marketplacev1alpha1.Workload{
+		{
+			Name:         "app-pods",
+			WorkloadType: marketplacev1alpha1.WorkloadTypePod,
+			MetricLabels: []marketplacev1alpha1.MeterLabelQuery{
+				{Label: "rpc_durations", Query: "rpc_durations_seconds_count", Aggregation: "sum"},
+			},
+		},
+	}
+
+	reconcilerTest := NewReconcilerTest(setup, mdef)
+	reconcilerTest.TestAll(t,
+		ReconcileStep(
+			opts,
+			ReconcileWithExpectedResults(AnyResult),
+		),
+		ListStep(
+			opts,
+			ListWithObj(&monitoringv1.PrometheusRuleList{}),
+			ListWithFilter(
+				client.InNamespace(namespace),
+				client.MatchingLabels(labelsForPrometheusRule(name, namespace))),
+			ListWithCheckResult(func(r *ReconcilerTest, t ReconcileTester, i runtime.Object) {
+				list, ok := i.(*monitoringv1.PrometheusRuleList)
+
+				assert.Truef(t, ok, "expected prometheus rule list got type %T", i)
+				assert.Equal(t, 1, len(list.Items))
+
+				rule := list.Items[0]
+				assert.Equal(t, recordingRuleName(namespace, name, "app-pods", 0), rule.Name)
+				assert.True(t, strings.HasPrefix(rule.Name, "meterdefinition-app-pods-"))
+				assert.Equal(t, 1, len(rule.Spec.Groups))
+				assert.Equal(t, 1, len(rule.Spec.Groups[0].Rules))
+				assert.Equal(t, "meterdef_workload:rpc_durations", rule.Spec.Groups[0].Rules[0].Record)
+				assert.Equal(t, "app-pods", rule.Spec.Groups[0].Rules[0].Labels["meter_def_workload"])
+			}),
+		),
+		GetStep(
+			opts,
+			GetWithNamespacedName(name, namespace),
+			GetWithObj(&marketplacev1alpha1.MeterDefinition{}),
+			GetWithCheckResult(func(r *ReconcilerTest, t ReconcileTester, i runtime.Object) {
+				mdef, ok := i.(*marketplacev1alpha1.MeterDefinition)
+
+				assert.Truef(t, ok, "expected meter definition got type %T", i)
+				assert.NotNil(t, mdef.Status.RecordingRulesTime)
+			}),
+		),
+	)
+}
+
+func testRecordingRuleNames(t GinkgoTInterface) {
+	t.Parallel()
+
+	assert.NotEqual(t,
+		recordingRuleName(namespace, "foo-bar", "baz", 0),
+		recordingRuleName(namespace, "foo", "bar-baz", 0))
+	assert.NotEqual(t,
+		recordingRuleName(namespace, "a_b", "pods", 0),
+		recordingRuleName(namespace, "a-b", "pods", 0))
+	assert.NotEqual(t,
+		recordingRuleName(namespace, "a.b", "pods", 0),
+		recordingRuleName(namespace, "a-b", "pods", 0))
+	assert.NotEqual(t,
+		recordingRuleName(namespace, "foo", "pods", 0),
+		recordingRuleName("other", "foo", "pods", 0))
+
+	long := strings.Repeat("a", 250)
+	for _, ruleName := range []string{
+		recordingRuleName(namespace, long, "pods", 0),
+		recordingRuleName(namespace, long, "", 1),
+	} {
+		assert.LessOrEqual(t, len(ruleName), validation.DNS1123SubdomainMaxLength)
+		assert.Empty(t, validation.IsDNS1123Subdomain(ruleName))
+	}
+	assert.NotEqual(t,
+		recordingRuleName(namespace, long, "pods-a", 0),
+		recordingRuleName(namespace, long, "pods-b", 0))
+
+	for _, label := range labelsForPrometheusRule(long, namespace) {
+		assert.Empty(t, validation.IsValidLabelValue(label))
+	}
+	assert.NotEqual(t, safeLabelValue(long+"a"), safeLabelValue(long+"b"))
+}
+
+func testInvalidExpression(t GinkgoTInterface) {
+	t.Parallel()
+	mdef := meterdefinition.DeepCopy()
+	mdef.Spec.Workloads = []marketplacev1alpha1.Workload{
+		{
+			Name:         "app-pods",
+			WorkloadType: marketplacev1alpha1.WorkloadTypePod,
+			Expression:   `{$[?(@.spec.nodeName=="worker-0"`,
+		},
+	}
+
+	reconcilerTest := NewReconcilerTest(setup, mdef)
+	reconcilerTest.TestAll(t,
+		ReconcileStep(
+			opts,
+			ReconcileWithExpectedResults(AnyResult),
+		),
+		GetStep(
+			opts,
+			GetWithNamespacedName(name, namespace),
+			GetWithObj(&marketplacev1alpha1.MeterDefinition{}),
+			GetWithCheckResult(func(r *ReconcilerTest, t ReconcileTester, i runtime.Object) {
+				mdef, ok := i.(*marketplacev1alpha1.MeterDefinition)
+
+				assert.Truef(t, ok, "expected meter definition got type %T", i)
+
+				cond := mdef.Status.Conditions.GetCondition(marketplacev1alpha1.MeterDefConditionTypeFiltersValid)
+				assert.NotNil(t, cond)
+				assert.True(t, cond.IsFalse())
+				assert.Equal(t, marketplacev1alpha1.MeterDefConditionReasonFilterCompileFailed, cond.Reason)
+				assert.Contains(t, cond.Message, "app-pods")
+			}),
+		),
+	)
+}
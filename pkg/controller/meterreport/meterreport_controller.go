@@ -31,6 +31,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -149,6 +150,63 @@ func (r *ReconcileMeterReport) Reconcile(request reconcile.Request) (reconcile.R
 
 	job := &batchv1.Job{}
 
+	if instance.IsRerunRequested() {
+		reqLogger.Info("rerun requested",
+			"rerunGeneration", instance.Spec.RerunGeneration,
+			"supersedes", instance.Status.ReportID)
+
+		result, _ := cc.Do(context.TODO(),
+			HandleResult(
+				GetAction(request.NamespacedName, job),
+				OnContinue(DeleteAction(job, DeleteWithDeleteOptions(client.PropagationPolicy(metav1.DeletePropagationBackground)))),
+				OnNotFound(ContinueResponse()),
+			),
+		)
+
+		if result.Is(Error) {
+			reqLogger.Error(result.GetError(), "Failed to delete job for rerun.")
+			return result.Return()
+		}
+
+		// the cache can still return the deleted job, so its UID is kept
+		// to wait for it to be gone before the new job is created
+		instance.ResetForRerun()
+		instance.Status.SupersededJobUID = job.UID
+
+		result, _ = cc.Do(context.TODO(), UpdateAction(instance, UpdateStatusOnly(true)))
+
+		if result.Is(Error) {
+			reqLogger.Error(result.GetError(), "Failed to reset report for rerun.")
+			return result.Return()
+		}
+
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	if instance.Status.SupersededJobUID != "" {
+		result, _ := cc.Do(context.TODO(), GetAction(request.NamespacedName, job))
+
+		if result.Is(Error) {
+			reqLogger.Error(result.GetError(), "Failed to get superseded job.")
+			return result.Return()
+		}
+
+		if result.Is(Continue) && job.UID == instance.Status.SupersededJobUID {
+			reqLogger.Info("waiting for the superseded job to be deleted", "uid", job.UID)
+			return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+
+		instance.Status.SupersededJobUID = ""
+
+		if result, _ := cc.Do(context.TODO(), UpdateAction(instance, UpdateStatusOnly(true))); !result.Is(Continue) {
+			if result.Is(Error) {
+				reqLogger.Error(result.GetError(), "Failed to update status.")
+			}
+
+			return result.Return()
+		}
+	}
+
 	c := manifests.NewOperatorConfig(r.cfg)
 	factory := manifests.NewFactory(instance.Namespace, c)
 
@@ -181,13 +239,30 @@ func (r *ReconcileMeterReport) Reconcile(request reconcile.Request) (reconcile.R
 
 	}
 
+	// reports are only spooled when the meterbase configures a spool
+	meterbase := &marketplacev1alpha1.MeterBase{}
 	result, _ := cc.Do(
+		context.TODO(),
+		HandleResult(
+			GetAction(types.NamespacedName{Name: utils.METERBASE_NAME, Namespace: instance.Namespace}, meterbase),
+			OnNotFound(ContinueResponse()),
+		),
+	)
+
+	if result.Is(Error) {
+		reqLogger.Error(result.GetError(), "Failed to get meterbase.")
+		return result.Return()
+	}
+
+	spool := meterbase.Spec.ReportSpool != nil
+
+	result, _ = cc.Do(
 		context.TODO(),
 		HandleResult(
 			manifests.CreateIfNotExistsFactoryItem(
 				job,
 				func() (runtime.Object, error) {
-					return factory.ReporterJob(instance)
+					return factory.ReporterJob(instance, spool)
 				}, CreateWithAddOwner(instance),
 			),
 			OnRequeue(UpdateStatusCondition(instance, instance.Status.Conditions, marketplacev1alpha1.ReportConditionJobSubmitted)),
@@ -255,7 +330,6 @@ func (r *ReconcileMeterReport) Reconcile(request reconcile.Request) (reconcile.R
 		result, _ = cc.Do(context.TODO(),
 			UpdateStatusCondition(instance, instance.Status.Conditions, marketplacev1alpha1.ReportConditionJobFinished),
 		)
-
 	}
 
 	if result != nil && !result.Is(Continue) {
@@ -0,0 +1,271 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package meterreport
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils/reconcileutils"
+	. "github.com/redhat-marketplace/redhat-marketplace-operator/test/rectest"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("MeterReport controller", func() {
+	It("should rerun a report", func() {
+		testRerun(GinkgoT())
+	})
+
+	It("should wait for the superseded job to be deleted", func() {
+		testRerunWaitsForJob(GinkgoT())
+	})
+
+	It("should not spool reports without a spool", func() {
+		testReportJob(GinkgoT(), nil)
+	})
+
+	It("should spool reports when the meterbase has a spool", func() {
+		testReportJob(GinkgoT(), &marketplacev1alpha1.ReportSpoolSpec{})
+	})
+})
+
+var (
+	name      = "meter-report-2020-10-01"
+	namespace = "openshift-redhat-marketplace"
+	req       = reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	opts = []StepOption{
+		WithRequest(req),
+	}
+)
+
+func setup(r *ReconcilerTest) error {
+	s := scheme.Scheme
+	s.AddKnownTypes(marketplacev1alpha1.SchemeGroupVersion, &marketplacev1alpha1.MeterReport{}, &marketplacev1alpha1.MeterBase{})
+
+	r.Client = fake.NewFakeClient(r.GetGetObjects()...)
+	r.Reconciler = &ReconcileMeterReport{client: r.Client, scheme: s, ccprovider: &reconcileutils.DefaultCommandRunnerProvider{}}
+	return nil
+}
+
+func testRerun(t GinkgoTInterface) {
+	t.Parallel()
+
+	conditions := status.NewConditions(marketplacev1alpha1.ReportConditionJobFinished)
+	uploadCount := 10
+	meterreport := &marketplacev1alpha1.MeterReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: marketplacev1alpha1.MeterReportSpec{
+			RerunGeneration: 1,
+		},
+		Status: marketplacev1alpha1.MeterReportStatus{
+			Conditions:        &conditions,
+			MetricUploadCount: &uploadCount,
+			ReportID:          "c6d4b1a8-4c9e-4f0d-9e63-6f0b1f4c2a11",
+			ReportFile:        "/tmp/upload-report.tar.gz",
+		},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       "old-job-uid",
+		},
+	}
+
+	reconcilerTest := NewReconcilerTest(setup, meterreport, job)
+	reconcilerTest.TestAll(t,
+		ReconcileStep(opts,
+			ReconcileWithExpectedResults(RequeueResult),
+		),
+		GetStep(opts,
+			GetWithNamespacedName(name, namespace),
+			GetWithObj(&marketplacev1alpha1.MeterReport{}),
+			GetWithCheckResult(func(r *ReconcilerTest, t ReconcileTester, i runtime.Object) {
+				report, ok := i.(*marketplacev1alpha1.MeterReport)
+
+				assert.Truef(t, ok, "expected meter report got type %T", i)
+				assert.Equal(t, int64(1), report.Status.ObservedRerunGeneration)
+				assert.Equal(t, "c6d4b1a8-4c9e-4f0d-9e63-6f0b1f4c2a11", report.Status.SupersedesReportID)
+				assert.Empty(t, report.Status.ReportID)
+				assert.Empty(t, report.Status.ReportFile)
+				assert.Nil(t, report.Status.MetricUploadCount)
+				assert.True(t, report.Status.Conditions.IsFalseFor(marketplacev1alpha1.ReportConditionTypeJobRunning))
+				assert.Equal(t, marketplacev1alpha1.ReportConditionReasonJobNotStarted,
+					report.Status.Conditions.GetCondition(marketplacev1alpha1.ReportConditionTypeJobRunning).Reason)
+			}),
+		),
+		ListStep(opts,
+			ListWithObj(&batchv1.JobList{}),
+			ListWithCheckResult(func(r *ReconcilerTest, t ReconcileTester, i runtime.Object) {
+				list, ok := i.(*batchv1.JobList)
+
+				assert.Truef(t, ok, "expected job list got type %T", i)
+				assert.Empty(t, list.Items, "the old job should be deleted")
+			}),
+		),
+		ReconcileStep(opts,
+			ReconcileWithExpectedResults(RequeueResult, RequeueResult),
+		),
+		GetStep(opts,
+			GetWithNamespacedName(name, namespace),
+			GetWithObj(&batchv1.Job{}),
+			GetWithCheckResult(func(r *ReconcilerTest, t ReconcileTester, i runtime.Object) {
+				job, ok := i.(*batchv1.Job)
+
+				assert.Truef(t, ok, "expected job got type %T", i)
+				assert.NotEqual(t, types.UID("old-job-uid"), job.UID, "a new job should be created")
+			}),
+		),
+		GetStep(opts,
+			GetWithNamespacedName(name, namespace),
+			GetWithObj(&marketplacev1alpha1.MeterReport{}),
+			GetWithCheckResult(func(r *ReconcilerTest, t ReconcileTester, i runtime.Object) {
+				report, ok := i.(*marketplacev1alpha1.MeterReport)
+
+				assert.Truef(t, ok, "expected meter report got type %T", i)
+				assert.Empty(t, report.Status.SupersededJobUID)
+			}),
+		),
+	)
+}
+
+func testRerunWaitsForJob(t GinkgoTInterface) {
+	t.Parallel()
+
+	conditions := status.NewConditions(marketplacev1alpha1.ReportConditionJobNotStarted)
+	meterreport := &marketplacev1alpha1.MeterReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Status: marketplacev1alpha1.MeterReportStatus{
+			Conditions:       &conditions,
+			SupersededJobUID: "old-job-uid",
+		},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       "old-job-uid",
+		},
+	}
+
+	reconcilerTest := NewReconcilerTest(setup, meterreport, job)
+	reconcilerTest.TestAll(t,
+		ReconcileStep(opts,
+			ReconcileWithExpectedResults(RequeueAfterResult(5*time.Second)),
+		),
+		GetStep(opts,
+			GetWithNamespacedName(name, namespace),
+			GetWithObj(&marketplacev1alpha1.MeterReport{}),
+			GetWithCheckResult(func(r *ReconcilerTest, t ReconcileTester, i runtime.Object) {
+				report, ok := i.(*marketplacev1alpha1.MeterReport)
+
+				assert.Truef(t, ok, "expected meter report got type %T", i)
+				assert.Equal(t, types.UID("old-job-uid"), report.Status.SupersededJobUID)
+				assert.Equal(t, marketplacev1alpha1.ReportConditionReasonJobNotStarted,
+					report.Status.Conditions.GetCondition(marketplacev1alpha1.ReportConditionTypeJobRunning).Reason)
+			}),
+		),
+	)
+}
+
+func testReportJob(t GinkgoTInterface, spool *marketplacev1alpha1.ReportSpoolSpec) {
+	t.Parallel()
+
+	meterreport := &marketplacev1alpha1.MeterReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: marketplacev1alpha1.MeterReportSpec{
+			StartTime: metav1.NewTime(time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)),
+			EndTime:   metav1.NewTime(time.Date(2020, 10, 2, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+	meterbase := &marketplacev1alpha1.MeterBase{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      utils.METERBASE_NAME,
+			Namespace: namespace,
+		},
+		Spec: marketplacev1alpha1.MeterBaseSpec{
+			Enabled:     true,
+			ReportSpool: spool,
+		},
+	}
+
+	reconcilerTest := NewReconcilerTest(setup, meterreport, meterbase)
+	reconcilerTest.TestAll(t,
+		ReconcileStep(opts,
+			ReconcileWithExpectedResults(RequeueResult),
+		),
+		GetStep(opts,
+			GetWithNamespacedName(name, namespace),
+			GetWithObj(&batchv1.Job{}),
+			GetWithCheckResult(func(r *ReconcilerTest, t ReconcileTester, i runtime.Object) {
+				job, ok := i.(*batchv1.Job)
+
+				assert.Truef(t, ok, "expected job got type %T", i)
+				podSpec := job.Spec.Template.Spec
+				claims := []string{}
+
+				for _, volume := range podSpec.Volumes {
+					if volume.PersistentVolumeClaim != nil {
+						claims = append(claims, volume.PersistentVolumeClaim.ClaimName)
+					}
+				}
+
+				if spool == nil {
+					assert.NotContains(t, podSpec.Containers[0].Args, "--spoolDir")
+					assert.Empty(t, claims)
+					return
+				}
+
+				assert.Contains(t, podSpec.Containers[0].Args, "--spoolDir")
+				assert.Equal(t, []string{utils.REPORTER_SPOOL_PVC_NAME}, claims)
+			}),
+		),
+		ListStep(opts,
+			ListWithObj(&corev1.PersistentVolumeClaimList{}),
+			ListWithCheckResult(func(r *ReconcilerTest, t ReconcileTester, i runtime.Object) {
+				list, ok := i.(*corev1.PersistentVolumeClaimList)
+
+				assert.Truef(t, ok, "expected claim list got type %T", i)
+				assert.Empty(t, list.Items, "the meterbase owns the spool claim")
+			}),
+		),
+	)
+}
@@ -333,7 +333,7 @@ func assetsPrometheusPrometheusRulesYaml() (*asset, error) {
 	return a, nil
 }
 
-var _assetsPrometheusPrometheusYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd4\x58\x4b\x6f\xe3\x36\x10\xbe\xfb\x57\x10\xbe\x18\x28\x96\x96\x9d\x76\xbb\xbb\x02\x7c\x48\x1d\x77\x13\x34\x0f\x63\x1d\xf4\x71\xa9\xc1\x50\x63\x99\x30\x45\xaa\xc3\x91\x37\x46\xb0\xff\xbd\xa0\x5e\x96\x6c\x25\x4e\x50\x14\x68\xf7\xb4\xe1\xcc\x7c\x9a\xe7\x47\x8e\x45\xaa\x7e\x05\x74\xca\x9a\x90\x25\xd6\x28\xb2\xa8\x4c\x3c\x94\x16\xc1\xba\xa1\xb4\x49\xb0\x1d\xf7\x36\xca\x44\x21\x9b\xa3\x4d\x80\xd6\x90\xb9\x5e\x02\x24\x22\x41\x22\xec\x31\x66\x44\x02\x21\x4b\x6b\x21\x4f\x80\x00\x1f\x84\x83\x1e\x63\x5a\x3c\x80\x76\x5e\x8d\x35\x54\x42\x86\x10\xad\x05\xf1\x44\xe0\x06\x28\xd5\x42\x42\xcf\xa5\x20\xbd\xa2\x58\xad\x94\x51\xb4\x2b\x8d\x6c\x74\x6e\x48\x9d\xb7\x0e\x3d\x16\xac\x00\x11\xa2\x8b\xcc\xfb\xbb\x90\x6b\x88\x32\xad\x4c\x7c\x15\x1b\x5b\x1f\xcf\x1e\x41\x66\xe4\x63\x2b\xcd\x18\xe3\x39\x62\x89\x76\x0f\x98\xec\x45\xfe\x5f\xee\xef\x02\x34\x48\xb2\xd8\x16\x31\x96\x08\x92\xeb\xd9\x63\x8a\xe0\x7c\xc2\xdc\xa1\xdc\xa3\x6f\x60\xd7\x4c\xc6\x91\x06\x63\x36\x05\x14\x1e\x9d\x5d\x99\x0e\xf1\x56\xe8\x0c\x3a\xa0\x4b\xf8\x8f\x6d\x48\x9f\x7c\x97\x0a\x79\x6c\xc1\xbb\x72\xdc\x54\x20\x9b\x5a\x6d\xe3\xdd\x2f\xde\xe3\x4d\xf6\x00\x68\x80\xc0\x0d\x95\x0d\xd6\xd6\x91\x47\x6e\xe8\x7f\x05\x15\xaf\x29\x64\xe3\xd1\xa8\xc7\x98\xb4\x86\x84\x32\x80\xe5\x67\x39\x53\x89\x88\xa1\xab\xae\x5c\x64\xb4\x96\x6b\x90\x9b\x50\x0b\x02\x47\xbd\xbd\xe7\x21\xab\x85\xe5\x29\x82\xb3\x19\xb6\xc2\x41\xf8\x2b\x03\x47\xad\x00\x65\x9a\x79\x57\x92\xc6\x51\x02\x89\xc5\x5d\xc8\xce\x46\x37\xaa\x3c\x26\xc0\x44\x19\xe1\x1b\xe0\x06\x9c\x13\x31\xcc\xad\x56\x72\x17\xb2\x9f\x85\xd6\x0f\x42\x6e\xee\xed\xb5\x8d\xdd\x9d\x99\x21\x5a\x2c\x23\x11\x18\xbb\x66\xbf\xf0\x14\xed\x56\x45\x80\x13\x9b\x82\x71\x6b\xb5\xa2\xa6\x74\x4d\x94\x3a\x2e\xa2\xc8\x77\xc5\x24\xfc\x34\xfa\x34\x3e\x14\xd7\xd2\xa6\x00\x12\xa1\x34\x8f\x6c\x22\x94\x99\x7c\xd7\x94\x64\xa9\x23\x04\x91\x4c\xbc\x6d\x18\x04\xda\x4a\xa1\x7d\x49\x3c\xf8\xa8\x0d\x9e\x0a\xe7\xbe\x46\x7c\xa5\x34\x4c\x02\x20\x19\xa4\x68\x1f\x77\x41\x25\x08\x7c\x7e\x9b\x16\x75\x08\xdc\x01\x6e\x95\x2f\x8f\x94\x36\x33\x34\xe9\xa8\x5c\x47\x1b\x73\x36\x68\x62\x08\x9c\x3c\xf5\xab\x9a\xf5\x43\xd6\xdf\xf7\x63\xff\x1d\xeb\x6f\x01\x1f\xfc\x69\x0c\xd4\xff\x36\x78\x06\x24\x02\x0d\xb1\x20\xe0\x19\x6a\x37\x79\xea\x07\xfd\x90\xbd\x1a\xb4\x85\xca\x49\x3b\x2e\x01\xa9\x48\x05\x69\x17\xa4\xa8\xb6\x82\xc0\xff\x7f\x28\x91\x0e\x95\x37\xb0\xeb\xd6\xdd\xc0\xae\xa9\x2b\xb5\x02\xe3\x73\x26\x11\xa8\xcc\xf6\x56\x60\x80\x99\x09\x8a\x43\x17\xb4\x47\xa8\x4c\x6f\x99\xdd\x80\xec\x06\x4c\x0b\xd1\xda\x8d\x82\x36\xe2\xbe\x7e\x15\xa6\x2b\x98\x66\x59\xfc\xdd\x5d\x48\x29\x4a\xcb\x8d\xca\xc3\xf0\x09\x18\xa6\x90\x3c\xaf\xfd\x26\xcf\xa5\x38\x4c\x9c\xdb\xa8\x34\x9f\x6a\x8e\x10\xc3\xe3\xe4\xcf\x20\x01\x42\x25\xab\x2e\x01\xb3\x6d\xce\x4f\x31\xe8\x97\xf7\xf7\xf3\xe5\xfc\xcb\xdd\xef\x7f\x34\x66\x36\xe7\xba\x90\x0d\x06\x9d\xea\x8b\x37\xe8\xdf\xde\x9d\x54\xae\x19\x2a\x56\x8e\x70\x37\x2c\x1a\xde\x47\x5c\x67\xe7\x87\xc0\x3a\xe0\x36\x8f\x2d\x2f\x44\x9b\xb2\x72\x84\x79\xa6\x75\x45\x23\x57\xab\x5b\x4b\x73\x04\x07\xa6\x4d\x6b\x8d\xdb\x30\xc7\xa9\x6e\x2d\x8b\xd4\xe2\x96\x9a\x47\xe7\x16\x29\x64\x2d\xf2\xa8\xb0\x72\x82\xf9\xaf\xd0\x23\x63\x5b\xab\xb3\x04\x6e\x7c\x6f\xb4\x42\x49\xfc\xc9\x5c\xd0\x3a\x64\x87\x13\x75\x14\x52\xd9\xf5\xb8\x4e\x78\xd7\xbb\xc1\x8f\xe6\x0b\xc8\xad\x11\x79\x33\x76\xb3\x1e\xcf\xa3\x57\x04\xfa\x66\xf8\x96\x61\xc7\x65\xc2\xb5\x8d\xc9\x3a\x8a\x00\xb1\x75\xee\x40\x66\x08\x5c\x2b\x47\x60\x5a\xf7\xc9\x59\x4b\x2f\xe7\x38\x95\xae\x01\xb9\xcb\x14\x81\x9b\xdc\x5f\x2f\x96\xb3\xe9\xc5\xe5\x6c\xf9\x65\x71\xbe\xfc\xed\xea\xfe\x72\x79\x3e\x5b\x2c\xc7\x67\x1f\x97\x9f\xa7\x37\xcb\xc5\xe5\xf9\xd9\xfb\x1f\xdf\xed\xb5\x66\xd3\x8b\x13\x7a\x47\x38\xd3\x9f\xa6\xaf\xc2\xe9\xd4\x7b\x01\xad\x15\x59\x3e\x76\x39\x51\x72\x91\x45\x0a\x8c\x04\x37\x79\x2e\xd1\xc3\x3d\xa5\x1d\xdf\x5c\x43\xb7\x95\x2d\xe8\xc3\x2b\x75\x7c\xf6\x61\x38\x1a\x8e\x86\xe3\xfc\x4a\x0d\x8e\x13\x0c\xd8\x24\xe5\x13\x37\x49\x6e\x52\xca\xfd\x95\xf2\x82\xe5\xc1\xbd\x52\x3b\xc6\xa5\x68\x58\x49\x6b\x56\x2a\x4e\x44\xea\x0a\x36\x36\x71\xee\x91\xf3\x5a\x0f\x99\x89\x34\x54\x2c\xcd\x5b\xf4\xfc\x6a\x8a\xf3\x8c\xcf\xf1\x41\xc8\x7f\x46\x73\x07\x30\x7c\xfc\x7a\x9e\x3b\x3b\x9a\x2c\x8f\xf3\x6f\xd0\x5c\x3e\x58\x8a\x76\x53\x6b\x08\x1e\x29\x64\x4f\xdf\xfe\x57\x04\xe8\x63\x17\xd1\x9d\xd1\xbb\x90\xad\x84\x76\xf0\xc2\x37\x4f\x37\xce\x91\x2b\xb5\x09\x3f\x6d\x71\xec\x08\x42\xaa\x95\x14\x2e\x64\x67\xbd\xa3\xba\x1d\xd6\x2c\xaf\xd7\x87\xba\x5e\x55\xad\xc6\x9f\x7d\xa9\xca\x86\x3e\x2f\x9e\x1d\xb7\x45\x4b\x9c\x78\x93\xa6\xa8\x6c\x5e\x5a\x2d\x9c\x2b\x4c\xdc\xce\x11\x24\x5c\xea\xcc\x11\x20\x97\xa8\x48\x49\xa1\xfd\x2a\x9a\x33\xeb\xb5\x7f\x4c\x87\x8c\x30\x2b\xdc\x27\x30\xf9\x82\xc8\xbe\x1f\x79\xce\x7e\xf5\x04\x35\x96\xd9\x7a\x78\x4e\x0d\x8e\xb1\x11\xb4\x77\xcb\xf6\xcb\xcb\xba\x90\x69\x65\xb2\xc7\xde\x73\x5d\x5b\x26\xe9\xa6\x58\xd4\xdb\x58\xf9\x76\x7a\xdd\xd8\xb7\xfd\xd1\x9e\x12\xcb\x48\xfc\x46\x9f\xf7\x99\x32\x71\xc8\x06\x3e\x0f\x83\x23\xe0\xdb\xea\xc1\xdd\xf1\x85\x8e\xfd\x97\xb3\xa7\x62\xef\x1d\xd4\x49\xf2\xe1\x54\x35\xd8\xff\xac\x30\x78\xd7\xd8\x7e\x2f\x2c\xb8\x5b\x4b\xb3\x47\xe5\x88\xf9\xe0\x44\x14\x29\x5f\x0b\xa1\x17\x12\x45\x0a\xd3\xbc\x33\xcb\xaf\x94\x1c\xb1\x4e\x1a\x53\xb2\x37\xe0\x2e\xb7\xe0\x45\x33\x17\xb3\x93\x7b\x94\x2b\x47\xb0\x1a\xee\x44\xa2\x8b\xb4\xfa\x57\x43\xb5\xb5\x1e\x10\x58\x79\x7a\x72\x2c\x5f\x50\x79\x15\x4e\xe3\x91\x50\x78\x7c\x23\xd2\xda\xa7\x97\xc6\xb0\xf0\x58\x03\xed\x87\xb5\x21\x77\x64\xd1\xb7\x6f\x6f\x4f\x53\x53\x2d\x54\x72\x0f\x49\xea\x9b\xb4\xaa\x57\xf5\x4b\x4b\xf9\x57\x61\xd5\x98\xa1\x81\x23\x61\x22\x81\xd1\xa0\x41\xbf\x47\x8c\xdc\xcd\xc9\x7b\x2f\xd8\xfb\xcf\xaa\xf7\x77\x00\x00\x00\xff\xff\x07\x91\xf3\xd2\x5f\x12\x00\x00")
+var _assetsPrometheusPrometheusYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd5\x58\xdb\x6e\xe3\x36\x10\x7d\xf7\x57\x10\x79\x31\x50\x84\x96\xed\x76\xbb\xbb\x02\xfc\x90\x26\xee\x26\x68\x2e\xc6\xda\xe8\xe5\xa5\x06\x43\x8d\x65\xc2\x94\xa8\x92\x94\xd7\x46\xb0\xff\xde\xa1\xee\xb2\x95\x38\x41\x5b\xb4\x4d\x80\xc8\xe6\xcc\x1c\xcd\xf5\x90\x0c\x4b\xc4\xcf\xa0\x8d\x50\xb1\x4f\x22\x15\x0b\xab\xb4\x88\xc3\x01\x57\x1a\x94\xc1\x47\xe4\x6d\x47\xbd\x8d\x88\x03\x9f\xcc\xb4\x8a\xc0\xae\x21\x35\x3d\x7c\xb2\x80\x59\xe6\xf7\x08\x89\x59\x04\x3e\x49\x2a\x21\xc5\x27\xe8\x47\x66\x00\x85\x92\x3d\x82\x34\x4e\x8d\x34\x54\x7c\xa2\x21\x58\x33\x4b\x23\xa6\x37\x60\x13\xc9\x38\xf4\x4c\x02\xdc\x29\xb2\xd5\x4a\xa0\x1f\xfb\xc2\x48\x05\x17\xb1\x15\x17\xad\x45\x87\x05\x2b\xd0\x88\x72\x95\x3a\x7f\xe7\x7c\x0d\x41\x2a\xf1\xd3\x4d\x18\xab\x6a\x79\xba\x03\x9e\x5a\x17\x5b\x61\x46\x08\xcd\x10\x0b\xb4\x05\xe8\xa8\x16\xb9\x9f\xcc\xdf\x39\x48\xe0\x98\x88\xb6\x88\x90\x88\x59\xbe\x9e\xee\xf0\xdd\xc6\x25\xcc\x1c\xca\x1d\xfa\x06\xf6\xcd\x64\x1c\x69\x10\xa2\x12\xd0\xcc\xa1\x93\x9b\xb8\x43\xbc\x65\x32\x85\x0e\xe8\x02\xfe\x43\x1b\xd2\x25\xdf\x24\x98\xbe\x23\x0b\xda\x95\xe3\xa6\x82\x55\x89\x92\x2a\xdc\xff\xe4\x3c\xde\xa4\x8f\xa0\x63\x2c\x9c\x19\x08\xe5\xad\x95\xb1\x0e\xb9\xa1\xff\x05\x44\xb8\xb6\x3e\x19\x0d\x87\xb8\xca\x55\x6c\x99\x88\xb1\x71\xf2\xd7\x52\x22\x22\x16\x42\x57\x5d\x29\x4b\xed\x1a\xcb\xc3\x37\xbe\x64\x88\x6f\x7b\xb5\xe7\x3e\xa9\x84\xc5\x2a\xa6\x56\xa5\xba\x15\x8e\x86\x3f\x30\x21\xb6\x15\x20\x4f\x52\xe7\x4a\xd4\x58\x8a\x20\x52\x1a\x23\x19\x0f\xef\x44\xb1\x8c\x6d\x18\x89\x98\xb9\x06\xb8\xc3\x92\xa1\x83\x33\x25\x05\x47\xa5\x1f\x99\x94\x8f\x8c\x6f\x16\xea\x56\x85\xe6\x21\x9e\x6a\xad\x74\x11\x09\xd3\xa1\x69\xf6\x0b\xc5\x6a\x6e\x45\x00\x7a\x82\x95\x8b\xcd\x5a\xac\x6c\x53\xba\xb6\x36\x31\x94\x05\x81\xeb\x8a\x89\xff\x71\xf8\x71\x74\x28\xae\xa4\x4d\x01\x44\x4c\x48\x1a\x28\x7c\xc4\x93\x6f\x9a\x92\x34\x31\x56\x03\x8b\x26\xce\xd6\xf7\x3c\xa9\x38\x93\xae\x24\x0e\x7c\xd8\x06\x4f\x98\x31\x5f\x02\xba\x12\x12\x26\x1e\x58\xee\xa1\xb3\xbb\xbd\x57\x0a\x3c\x97\xdf\xa6\x45\x15\x02\x35\xa0\xb7\xc2\x95\x87\x73\x95\xc6\x76\xd2\x51\xb9\x8e\x36\xa6\xa4\xdf\xc4\x60\x7a\xf2\x74\x56\xd6\xec\xcc\x27\x67\x75\x3f\x9e\x9d\x93\xb3\x2d\xf2\x80\x5b\x0d\xc1\x9e\x7d\xed\x3f\x03\x12\xe0\xb4\x85\xd8\x19\x34\xd5\xd2\x20\x9c\x87\x16\xaf\x06\x6d\xa1\x52\x2b\x0d\xe5\xa0\x6d\x9e\x0a\xfc\x86\xe9\x10\x5b\xc4\x76\x9f\x07\x5c\xdb\x43\x65\x9c\xd6\x6e\x5d\x14\x34\x75\xb9\x14\x10\xbb\x9c\x71\x0d\xb6\xc8\xf6\x96\x69\x4f\xa7\xb1\x97\x2f\x1a\xaf\x3d\x42\x45\x7a\x8b\xec\x7a\x56\x6d\x20\x6e\x21\x2a\xb5\x11\xd0\x46\xac\xeb\x57\x62\x9a\x9c\x69\x96\xf9\xf7\xee\x42\x72\x56\x58\x6e\x44\x16\x86\x4b\xc0\x20\x81\xe8\x79\xed\x37\x79\xce\xd9\x61\xe2\xcc\x46\x24\xd9\x54\x53\x0d\x21\xec\x26\xbf\x7b\xd8\x25\x5a\xf0\xb2\x4b\x20\xde\x36\xe7\x27\x1f\xf4\xeb\xc5\x62\xb6\x9c\x7d\x7e\xf8\xf5\xb7\xde\x01\xd7\xf9\xa4\xdf\xef\x54\x9f\xbf\x41\xff\xfe\xe1\xa4\x72\xc5\x50\xa1\xc0\xf9\xda\x0f\xf2\x86\x77\x11\x57\xd9\xf9\xce\x53\x06\xa8\xca\x62\xcb\x0a\xd1\xa6\xac\x0c\x61\x96\x4a\x59\xd2\xc8\xcd\xea\x5e\xd9\x19\xb6\x2a\x36\x47\x8b\xd6\x1a\xbb\x61\x86\x53\xee\x5a\x4a\xdb\x16\xb7\x54\x3c\x3a\x43\x89\x4f\x5a\xe4\x51\x62\x65\x04\xf3\x5f\xa1\x47\x4c\xab\x92\x69\x04\x77\xae\x37\x5a\xa1\x44\x6e\x65\xc6\xec\xda\x27\x87\x13\x75\x14\x52\xd1\xf5\x7a\x1d\xd1\xae\x73\x83\x1b\xcd\x17\x90\x5b\x23\xf2\x66\xec\x66\x3d\x9e\x47\x2f\x09\xf4\xcd\xf0\x2d\xc3\x8e\xcd\x84\xe2\x96\x6b\x91\xcb\x71\x3f\xd1\xad\x75\x04\x4e\x35\x50\x89\xcd\x09\x71\x6b\x3f\x19\xb7\xf4\x32\x8e\x13\xc9\x1a\x34\x35\xa9\xc0\xee\x9c\x2c\x6e\xe7\xcb\xe9\xe5\xd5\xf5\x74\xf9\x79\x7e\xb1\xfc\xe5\x66\x71\xbd\xbc\x98\xce\x97\xa3\xf1\x87\xe5\xa7\xcb\xbb\xe5\xfc\xfa\x62\xfc\xee\xfb\xf3\x5a\x0b\xff\x9e\xd0\x3b\xc2\xb9\xfc\xe1\xf2\x55\x38\x9d\x7a\x2f\xa0\xb5\x22\xcb\xc6\x2e\x23\x4a\xfc\x18\x20\xe1\x62\x9f\x4f\x9e\x4b\xf4\xa0\xa6\xb4\xe3\x9d\x6b\x60\xb6\xbc\x05\x7d\xb8\xa5\x8e\xc6\xef\x07\x43\xfc\x1d\x65\x5b\xaa\x77\x9c\x60\xe4\xd0\x06\x29\x9f\xd8\x49\x32\x93\x42\xee\xb6\x94\x17\x2c\x0f\xf6\x95\xca\x31\xe4\xe5\x86\x15\xd2\xc2\x4a\x84\x11\x4b\x4c\xce\xc6\x71\x98\x79\x64\x9c\xd6\x63\x1a\x07\x12\x4a\x96\xa6\x2d\x7a\x7e\x35\xc5\x39\xc6\xa7\x98\x47\xfe\xd7\x68\xee\x00\x86\x8e\x5e\xcf\x73\xe3\xa3\xc9\x72\x38\xff\x04\xcd\x65\x83\x85\x87\xfd\x4b\xf4\x01\x76\xf8\xf6\xa7\xaf\xff\x2b\x02\x74\xb1\xb3\xe0\x21\x96\xe8\xd7\x8a\x49\x03\x2f\xbc\xf3\x74\xe3\x1c\xb9\x52\x99\xd0\xd3\x16\xc7\x8e\x68\x48\x30\x63\x0c\x6f\x74\xe3\xde\x51\xdd\x0e\x6b\x96\xd5\xeb\x7d\x55\xaf\xb2\x56\xa3\x4f\xae\x54\x45\x43\x5f\xe4\xc7\x8e\xfb\xbc\x25\x4e\x9c\x49\x31\xbd\x2a\x2b\xad\x44\xce\xcd\x4d\xcc\x1e\xf9\x13\xc7\x49\xa6\xf8\xd4\x94\xa3\x18\xfd\x93\xee\x2a\x9a\x31\xeb\xad\x3b\x4c\xfb\xc4\xea\x34\x77\x1f\x97\xb2\x0b\x22\xf9\x76\xe8\x38\xfb\xd5\x13\xd4\xb8\xcc\x56\xc3\x73\x6a\x70\x62\x15\x40\xfb\x6e\xd9\x3e\x79\x29\x4c\x23\xde\x61\xd3\x5d\xef\xb9\xae\x2d\x92\x74\x97\x5f\xd4\xdb\x58\xd9\xed\xf4\xb6\x71\xdf\x76\x4b\x35\x25\x16\x91\xb8\x1b\x7d\xd6\x67\x58\x69\x3c\x1c\xb9\x3c\xf4\x8f\x80\xef\xcb\x03\x77\xc7\x1b\x3a\xee\xbf\x94\x3c\xe5\xf7\xde\x7e\x95\x24\x17\x4e\x59\x83\xfa\xdf\x0a\xfd\xf3\xc6\xed\xf7\x4a\x81\xc1\xf4\x4c\x77\x98\x69\xe2\x82\xd3\xa9\x84\xbf\x31\x24\x07\xf7\xef\x04\x82\x9b\xb7\x70\x4d\xc5\xe4\x9c\x6b\x96\xc0\x65\x36\x62\xc5\x5b\x0a\xb2\xc3\xc9\xaf\xc7\xbd\x36\xa0\x26\xb3\xa0\xf9\x54\xe6\x24\x90\x79\x94\x29\x07\xb0\x1a\xec\x59\x24\xf3\xfe\x70\xc7\x9f\xf2\xfa\x7d\xc0\xc4\xc5\xea\x49\x7e\x79\x41\xe5\x55\x38\x8d\xd3\x4e\xee\xf1\x1d\x52\x4f\xe9\xd3\x4b\x7c\x92\x7b\x2c\xc1\xd6\xac\xd3\x90\x1b\xcc\xab\x9b\xc3\x5e\xcd\xb7\x38\xe2\x22\x5a\x40\x94\xb8\x69\x2b\xeb\x55\xfe\xcb\xa8\xf8\x96\x5b\x35\xc8\xa0\x6f\x2c\x8b\x03\xa6\x83\x7e\x63\x1f\x39\xda\x5a\xba\x37\x97\xda\x0b\xf2\x0e\x09\xea\x4f\x45\xfd\xb5\xbf\x28\x13\x00\x00")
 
 func assetsPrometheusPrometheusYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -348,7 +348,7 @@ func assetsPrometheusPrometheusYaml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "assets/prometheus/prometheus.yaml", size: 4703, mode: os.FileMode(420), modTime: time.Unix(1603328530, 0)}
+	info := bindataFileInfo{name: "assets/prometheus/prometheus.yaml", size: 4904, mode: os.FileMode(420), modTime: time.Unix(1603328530, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -33,6 +33,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -55,6 +56,9 @@ const (
 
 	ReporterJob = "assets/reporter/job.yaml"
 
+	// ReporterSpoolDirectory is where the reporter spool volume is mounted.
+	ReporterSpoolDirectory = "/var/lib/rhm-reporter/spool"
+
 	MetricStateDeployment     = "assets/metric-state/deployment.yaml"
 	MetricStateServiceMonitor = "assets/metric-state/service-monitor.yaml"
 	MetricStateService        = "assets/metric-state/service.yaml"
@@ -403,7 +407,9 @@ func (f *Factory) PrometheusServingCertsCABundle() (*v1.ConfigMap, error) {
 	return c, nil
 }
 
-func (f *Factory) ReporterJob(report *marketplacev1alpha1.MeterReport) (*batchv1.Job, error) {
+// ReporterJob runs the reporter for the report. When spool is set the
+// report is spooled to the reporter spool claim.
+func (f *Factory) ReporterJob(report *marketplacev1alpha1.MeterReport, spool bool) (*batchv1.Job, error) {
 	j, err := f.NewJob(MustAssetReader(ReporterJob))
 
 	if err != nil {
@@ -421,15 +427,111 @@ func (f *Factory) ReporterJob(report *marketplacev1alpha1.MeterReport) (*batchv1
 		report.Namespace,
 	)
 
+	if spool {
+		container.Args = append(container.Args, "--spoolDir", ReporterSpoolDirectory)
+	}
+
+	if report.Spec.DryRun {
+		container.Args = append(container.Args, "--dryRun")
+	}
+
 	if len(report.Spec.ExtraArgs) > 0 {
 		container.Args = append(container.Args, report.Spec.ExtraArgs...)
 	}
 
 	j.Spec.Template.Spec.Containers[0] = container
 
+	if spool {
+		addReporterSpoolVolume(j)
+	}
+
 	return j, nil
 }
 
+// ReporterUploadPendingJob drains the reporter spool of the namespace. The
+// extra args are passed on for the upload target flags.
+func (f *Factory) ReporterUploadPendingJob(extraArgs []string) (*batchv1.Job, error) {
+	j, err := f.NewJob(MustAssetReader(ReporterJob))
+
+	if err != nil {
+		return nil, err
+	}
+
+	container := j.Spec.Template.Spec.Containers[0]
+	container.Image = f.config.RelatedImages.Reporter
+
+	// the controller retries the drain, so a failed run is not restarted
+	j.Name = utils.REPORTER_UPLOAD_PENDING_JOB_NAME
+	j.Namespace = f.namespace
+	j.Spec.BackoffLimit = ptr.Int32(0)
+	container.Args = []string{
+		"upload-pending",
+		"--namespace",
+		f.namespace,
+		"--spoolDir",
+		ReporterSpoolDirectory,
+	}
+
+	if len(extraArgs) > 0 {
+		container.Args = append(container.Args, extraArgs...)
+	}
+
+	j.Spec.Template.Spec.Containers[0] = container
+	addReporterSpoolVolume(j)
+
+	return j, nil
+}
+
+// ReporterSpoolPVC is the claim the reporter jobs spool reports to until
+// every upload target has them. Reporter jobs run on any node, so the
+// claim is ReadWriteMany unless the spec sets other access modes.
+func (f *Factory) ReporterSpoolPVC(spec *marketplacev1alpha1.ReportSpoolSpec) (*corev1.PersistentVolumeClaim, error) {
+	size := resource.MustParse("1Gi")
+	accessModes := []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}
+
+	if !spec.Size.IsZero() {
+		size = spec.Size
+	}
+
+	if len(spec.AccessModes) > 0 {
+		accessModes = spec.AccessModes
+	}
+
+	pvc, err := utils.NewPersistentVolumeClaim(utils.PersistentVolume{
+		ObjectMeta: &metav1.ObjectMeta{
+			Name:      utils.REPORTER_SPOOL_PVC_NAME,
+			Namespace: f.namespace,
+		},
+		StorageSize: &size,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// a nil class uses the cluster's default storage class
+	pvc.Spec.StorageClassName = spec.Class
+	pvc.Spec.AccessModes = accessModes
+
+	return &pvc, nil
+}
+
+func addReporterSpoolVolume(j *batchv1.Job) {
+	podSpec := &j.Spec.Template.Spec
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "spool",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: utils.REPORTER_SPOOL_PVC_NAME,
+			},
+		},
+	})
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		Name:      "spool",
+		MountPath: ReporterSpoolDirectory,
+	})
+}
+
 func (f *Factory) MetricStateDeployment() (*appsv1.Deployment, error) {
 	d, err := f.NewDeployment(MustAssetReader(MetricStateDeployment))
 	if err != nil {
@@ -18,12 +18,17 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 
 	"emperror.dev/errors"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/common"
 	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
 	rhmclient "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/client"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -108,6 +113,26 @@ func (f *WorkloadTypeFilter) Filter(obj interface{}) (bool, error) {
 	return false, nil
 }
 
+// WorkloadGVKFilter matches the resources of a Generic workload, which the
+// store receives as unstructured objects.
+type WorkloadGVKFilter struct {
+	gvk common.GroupVersionKind
+}
+
+func (f *WorkloadGVKFilter) String() string {
+	return fmt.Sprintf("WorkloadGVKFilter{gvk: %v}", f.gvk)
+}
+
+func (f *WorkloadGVKFilter) Filter(obj interface{}) (bool, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+
+	if !ok {
+		return false, nil
+	}
+
+	return u.GetAPIVersion() == f.gvk.APIVersion && u.GetKind() == f.gvk.Kind, nil
+}
+
 type WorkloadFilterForOwner struct {
 	workload  v1alpha1.Workload
 	findOwner *rhmclient.FindOwnerHelper
@@ -117,6 +142,8 @@ func (f *WorkloadFilterForOwner) String() string {
 	return fmt.Sprintf("WorkloadFilterForOwner{workload=%v}", f.workload)
 }
 
+// Filter walks the controller owners of the object, up to the workload's
+// owner depth, looking for the OwnerCRD.
 func (f *WorkloadFilterForOwner) Filter(obj interface{}) (bool, error) {
 	meta, ok := obj.(metav1.Object)
 
@@ -124,36 +151,17 @@ func (f *WorkloadFilterForOwner) Filter(obj interface{}) (bool, error) {
 		return false, errors.New("type was not a metav1.Object")
 	}
 
-	owner := metav1.GetControllerOf(meta)
-
-	if owner == nil {
-		return false, nil
-	}
+	matched := false
+	err := f.findOwner.WalkOwners(meta, f.workload.GetOwnerMaxDepth(), func(owner *metav1.OwnerReference) bool {
+		matched = owner.APIVersion == f.workload.OwnerCRD.APIVersion && owner.Kind == f.workload.OwnerCRD.Kind
+		return !matched
+	})
 
-	if owner.APIVersion == f.workload.OwnerCRD.APIVersion && owner.Kind == f.workload.OwnerCRD.Kind {
-		return true, nil
+	if err != nil {
+		return false, err
 	}
 
-	namespace := meta.GetNamespace()
-	var err error
-
-	for i := 0; i < 5; i++ {
-		owner, err = f.findOwner.FindOwner(owner.Name, namespace, owner)
-
-		if err != nil {
-			return false, err
-		}
-
-		if owner == nil {
-			return false, nil
-		}
-
-		if owner.APIVersion == f.workload.OwnerCRD.APIVersion && owner.Kind == f.workload.OwnerCRD.Kind {
-			return true, nil
-		}
-	}
-
-	return false, nil
+	return matched, nil
 }
 
 type WorkloadLabelFilter struct {
@@ -183,3 +191,65 @@ func (f *WorkloadAnnotationFilter) Filter(obj interface{}) (bool, error) {
 
 	return f.annotationSelector.Matches(labels.Set(meta.GetAnnotations())), nil
 }
+
+// WorkloadExpressionFilter matches resources a JSONPath expression returns
+// a result for. The resource is wrapped in a list so filter expressions can
+// compare its own fields.
+type WorkloadExpressionFilter struct {
+	expression string
+
+	// jsonpath keeps evaluation state, so evaluations are serialized
+	mu   sync.Mutex
+	path *jsonpath.JSONPath
+}
+
+// NewWorkloadExpressionFilter compiles the expression of a workload.
+func NewWorkloadExpressionFilter(expression string) (*WorkloadExpressionFilter, error) {
+	path := jsonpath.New("expression").AllowMissingKeys(true)
+
+	if err := path.Parse(expression); err != nil {
+		return nil, errors.WrapWithDetails(err, "failed to compile expression", "expression", expression)
+	}
+
+	return &WorkloadExpressionFilter{
+		expression: expression,
+		path:       path,
+	}, nil
+}
+
+func (f *WorkloadExpressionFilter) String() string {
+	return fmt.Sprintf("WorkloadExpressionFilter{expression: %s}", f.expression)
+}
+
+func (f *WorkloadExpressionFilter) Filter(obj interface{}) (bool, error) {
+	var content map[string]interface{}
+
+	switch o := obj.(type) {
+	case *unstructured.Unstructured:
+		content = o.UnstructuredContent()
+	default:
+		var err error
+		content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+
+		if err != nil {
+			return false, errors.Wrap(err, "failed to convert to unstructured")
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	results, err := f.path.FindResults([]interface{}{content})
+
+	if err != nil {
+		return false, errors.WrapWithDetails(err, "failed to evaluate expression", "expression", f.expression)
+	}
+
+	for _, result := range results {
+		if len(result) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
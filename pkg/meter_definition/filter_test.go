@@ -0,0 +1,84 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package meter_definition
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("WorkloadExpressionFilter", func() {
+	var (
+		gold   = "gold"
+		silver = "silver"
+		pvc    *corev1.PersistentVolumeClaim
+		pod    *corev1.Pod
+	)
+
+	BeforeEach(func() {
+		pvc = &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "apps"},
+			Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &gold},
+		}
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "apps"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "sidecar", Image: "quay.io/partner/proxy:1.0"},
+					{Name: "app", Image: "quay.io/partner/app:1.0"},
+				},
+			},
+		}
+	})
+
+	It("should match on the resource's own fields", func() {
+		sut, err := NewWorkloadExpressionFilter(`{$[?(@.spec.storageClassName=="gold")]}`)
+		Expect(err).To(Succeed())
+		Expect(sut.Filter(pvc)).To(BeTrue())
+
+		pvc.Spec.StorageClassName = &silver
+		Expect(sut.Filter(pvc)).To(BeFalse())
+	})
+
+	It("should match on list fields", func() {
+		sut, err := NewWorkloadExpressionFilter(`{$[*].spec.containers[?(@.image=="quay.io/partner/app:1.0")]}`)
+		Expect(err).To(Succeed())
+		Expect(sut.Filter(pod)).To(BeTrue())
+
+		pod.Spec.Containers = pod.Spec.Containers[:1]
+		Expect(sut.Filter(pod)).To(BeFalse())
+	})
+
+	It("should match unstructured resources and missing fields", func() {
+		sut, err := NewWorkloadExpressionFilter(`{$[*].spec.replicas}`)
+		Expect(err).To(Succeed())
+
+		deployment := &unstructured.Unstructured{}
+		deployment.SetAPIVersion("apps/v1")
+		deployment.SetKind("Deployment")
+		Expect(sut.Filter(deployment)).To(BeFalse())
+
+		Expect(unstructured.SetNestedField(deployment.Object, int64(3), "spec", "replicas")).To(Succeed())
+		Expect(sut.Filter(deployment)).To(BeTrue())
+	})
+
+	It("should fail to compile an invalid expression", func() {
+		_, err := NewWorkloadExpressionFilter(`{$[?(@.spec.storageClassName=="gold"`)
+		Expect(err).To(HaveOccurred())
+	})
+})
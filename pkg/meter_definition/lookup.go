@@ -152,6 +152,10 @@ func (s *MeterDefinitionLookupFilter) findNamespaces(
 	reqLogger := s.log.WithValues("func", "findNamespaces", "meterdef", instance.Name+"/"+instance.Namespace)
 
 	switch instance.Spec.WorkloadVertexType {
+	case v1alpha1.WorkloadVertexCluster:
+		reqLogger.Info("cluster vertex")
+		namespaces = []string{corev1.NamespaceAll}
+		return
 	case v1alpha1.WorkloadVertexOperatorGroup:
 		reqLogger.Info("operatorGroup vertex")
 		csv := &olmv1alpha1.ClusterServiceVersion{}
@@ -248,21 +252,34 @@ func (s *MeterDefinitionLookupFilter) createFilters(
 		runtimeFilters := []FilterRuntimeObject{&WorkloadNamespaceFilter{namespaces: namespaces}}
 
 		var err error
-		typeFilter := &WorkloadTypeFilter{}
+		var typeFilter FilterRuntimeObject
 		switch workload.WorkloadType {
 		case v1alpha1.WorkloadTypePod:
 			gvk := reflect.TypeOf(&corev1.Pod{})
-			typeFilter.gvks = []reflect.Type{gvk}
+			typeFilter = &WorkloadTypeFilter{gvks: []reflect.Type{gvk}}
 		case v1alpha1.WorkloadTypePVC:
 			gvk := reflect.TypeOf(&corev1.PersistentVolumeClaim{})
-			typeFilter.gvks = []reflect.Type{gvk}
+			typeFilter = &WorkloadTypeFilter{gvks: []reflect.Type{gvk}}
 		case v1alpha1.WorkloadTypeService:
 			gvk1 := reflect.TypeOf(&corev1.Service{})
-			typeFilter.gvks = []reflect.Type{gvk1}
+			typeFilter = &WorkloadTypeFilter{gvks: []reflect.Type{gvk1}}
 		case v1alpha1.WorkloadTypeServiceMonitor:
 			gvk1 := reflect.TypeOf(&corev1.Service{})
 			gvk2 := reflect.TypeOf(&monitoringv1.ServiceMonitor{})
-			typeFilter.gvks = []reflect.Type{gvk1, gvk2}
+			typeFilter = &WorkloadTypeFilter{gvks: []reflect.Type{gvk1, gvk2}}
+		case v1alpha1.WorkloadTypeNode:
+			if instance.Spec.WorkloadVertexType != v1alpha1.WorkloadVertexCluster {
+				err = errors.NewWithDetails("node workload requires the Cluster vertex", "workload", workload.Name)
+				break
+			}
+			gvk := reflect.TypeOf(&corev1.Node{})
+			typeFilter = &WorkloadTypeFilter{gvks: []reflect.Type{gvk}}
+		case v1alpha1.WorkloadTypeGeneric:
+			if workload.GroupVersionKind == nil {
+				err = errors.NewWithDetails("generic workload requires a groupVersionKind", "workload", workload.Name)
+				break
+			}
+			typeFilter = &WorkloadGVKFilter{gvk: *workload.GroupVersionKind}
 		default:
 			err = errors.NewWithDetails("unknown type filter", "type", workload.WorkloadType)
 		}
@@ -273,8 +290,13 @@ func (s *MeterDefinitionLookupFilter) createFilters(
 
 		runtimeFilters = append(runtimeFilters, typeFilter)
 
-		if workload.LabelSelector == nil && workload.AnnotationSelector == nil && workload.OwnerCRD == nil {
-			return nil, errors.New("workload isn't specific enough. 1 of owner, annotationSelector or labelSelector is required.")
+		// Nodes and the kind of a Generic workload are specific enough on
+		// their own.
+		if workload.WorkloadType != v1alpha1.WorkloadTypeGeneric &&
+			workload.WorkloadType != v1alpha1.WorkloadTypeNode &&
+			workload.LabelSelector == nil && workload.AnnotationSelector == nil &&
+			workload.OwnerCRD == nil && workload.Expression == "" {
+			return nil, errors.New("workload isn't specific enough. 1 of owner, annotationSelector, labelSelector or expression is required.")
 		}
 
 		if workload.LabelSelector != nil {
@@ -301,6 +323,16 @@ func (s *MeterDefinitionLookupFilter) createFilters(
 			})
 		}
 
+		if workload.Expression != "" {
+			expressionFilter, err := NewWorkloadExpressionFilter(workload.Expression)
+
+			if err != nil {
+				return nil, errors.WithDetails(err, "workload", workload.Name)
+			}
+
+			runtimeFilters = append(runtimeFilters, expressionFilter)
+		}
+
 		if workload.OwnerCRD != nil {
 			runtimeFilters = append(runtimeFilters, &WorkloadFilterForOwner{
 				workload:  workload,
@@ -312,3 +344,19 @@ func (s *MeterDefinitionLookupFilter) createFilters(
 	}
 	return filters, nil
 }
+
+// CompileWorkloadFilters compiles the filter expressions of the meter
+// definition's workloads, returning the first error.
+func CompileWorkloadFilters(meterdef *v1alpha1.MeterDefinition) error {
+	for _, workload := range meterdef.Spec.Workloads {
+		if workload.Expression == "" {
+			continue
+		}
+
+		if _, err := NewWorkloadExpressionFilter(workload.Expression); err != nil {
+			return errors.Wrapf(err, "workload %s", workload.Name)
+		}
+	}
+
+	return nil
+}
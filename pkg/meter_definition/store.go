@@ -23,6 +23,7 @@ import (
 	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	monitoringv1client "github.com/coreos/prometheus-operator/pkg/client/versioned/typed/monitoring/v1"
 	"github.com/go-logr/logr"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/common"
 	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
 	rhmclient "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/client"
 	marketplacev1alpha1client "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/generated/clientset/versioned/typed/marketplace/v1alpha1"
@@ -30,7 +31,9 @@ import (
 	"github.com/sasha-s/go-deadlock"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
@@ -39,14 +42,69 @@ import (
 
 type MeterDefinitionStores = map[string]*MeterDefinitionStore
 
+// Indexes of the matched object resources.
+const (
+	// ObjectUIDIndex indexes matches by the UID of the matched object.
+	ObjectUIDIndex = "objectUID"
+	// MeterDefUIDIndex indexes matches by the UID of the MeterDefinition.
+	MeterDefUIDIndex = "meterDefUID"
+	// MeterDefinitionIndex indexes matches by the namespace/name of the MeterDefinition.
+	MeterDefinitionIndex = "meterDefinition"
+	// NamespaceIndex indexes matches by the namespace of the matched object.
+	NamespaceIndex = "namespace"
+	// WorkloadNameIndex indexes matches by the name of the matched workload.
+	WorkloadNameIndex = "workloadName"
+)
+
+var objectResourceIndexers = cache.Indexers{
+	ObjectUIDIndex: func(obj interface{}) ([]string, error) {
+		return []string{string(obj.(*ObjectResourceValue).key.ObjectUID)}, nil
+	},
+	MeterDefUIDIndex: func(obj interface{}) ([]string, error) {
+		return []string{string(obj.(*ObjectResourceValue).key.MeterDefUID)}, nil
+	},
+	MeterDefinitionIndex: func(obj interface{}) ([]string, error) {
+		return []string{obj.(*ObjectResourceValue).MeterDef.String()}, nil
+	},
+	NamespaceIndex: func(obj interface{}) ([]string, error) {
+		val := obj.(*ObjectResourceValue)
+		if val.WorkloadResource == nil {
+			return []string{}, nil
+		}
+		return []string{val.WorkloadResource.Namespace}, nil
+	},
+	WorkloadNameIndex: func(obj interface{}) ([]string, error) {
+		val := obj.(*ObjectResourceValue)
+		if val.WorkloadResource == nil {
+			return []string{}, nil
+		}
+		return []string{val.ReferencedWorkloadName}, nil
+	},
+}
+
+// objectUIDKeyFunc keys seen objects by UID. Objects of different kinds may
+// share a namespace and name in the same store.
+func objectUIDKeyFunc(obj interface{}) (string, error) {
+	if key, ok := obj.(cache.ExplicitKey); ok {
+		return string(key), nil
+	}
+
+	o, err := meta.Accessor(obj)
+	if err != nil {
+		return "", err
+	}
+
+	return string(o.GetUID()), nil
+}
+
 // MeterDefinitionStore keeps the MeterDefinitions in place
 // and tracks the dependents using the rules based on the
 // rules. MeterDefinition controller uses this to effectively
 // find the child assets of a meter definition rules.
 type MeterDefinitionStore struct {
 	meterDefinitionFilters map[MeterDefUID]*MeterDefinitionLookupFilter
-	objectResourceSet      map[ObjectResourceKey]*ObjectResourceValue
-	objectsSeen            map[ObjectUID]interface{}
+	objectResourceSet      cache.ThreadSafeStore
+	objectsSeen            cache.Store
 
 	mutex deadlock.Mutex
 
@@ -65,6 +123,12 @@ type MeterDefinitionStore struct {
 	findOwner         *rhmclient.FindOwnerHelper
 	monitoringClient  *monitoringv1client.MonitoringV1Client
 	marketplaceClient *marketplacev1alpha1client.MarketplaceV1alpha1Client
+	dynamicClient     *rhmclient.DynamicClient
+
+	// watchGenericWorkloads starts watches for the kinds of the
+	// Generic workloads of the meter definitions added to the store.
+	watchGenericWorkloads bool
+	watchedKinds          map[schema.GroupVersionKind]bool
 
 	// listeners are used for downstream
 	listenerMutex deadlock.Mutex
@@ -90,6 +154,7 @@ type MeterDefinitionStoreBuilder struct {
 	findOwner         *rhmclient.FindOwnerHelper
 	monitoringClient  *monitoringv1client.MonitoringV1Client
 	marketplaceClient *marketplacev1alpha1client.MarketplaceV1alpha1Client
+	dynamicClient     *rhmclient.DynamicClient
 }
 
 func NewMeterDefinitionStoreBuilder(
@@ -98,6 +163,7 @@ func NewMeterDefinitionStoreBuilder(
 	cc ClientCommandRunner,
 	kubeClient clientset.Interface,
 	findOwner *rhmclient.FindOwnerHelper,
+	dynamicClient *rhmclient.DynamicClient,
 	monitoringClient *monitoringv1client.MonitoringV1Client,
 	marketplaceclient *marketplacev1alpha1client.MarketplaceV1alpha1Client,
 	scheme *runtime.Scheme,
@@ -110,6 +176,7 @@ func NewMeterDefinitionStoreBuilder(
 		monitoringClient:  monitoringClient,
 		marketplaceClient: marketplaceclient,
 		findOwner:         findOwner,
+		dynamicClient:     dynamicClient,
 		scheme:            scheme,
 	}
 }
@@ -124,14 +191,16 @@ func (s *MeterDefinitionStoreBuilder) NewInstance() *MeterDefinitionStore {
 		monitoringClient:       s.monitoringClient,
 		marketplaceClient:      s.marketplaceClient,
 		findOwner:              s.findOwner,
+		dynamicClient:          s.dynamicClient,
 		namespaces:             s.namespaces,
 		mutex:                  deadlock.Mutex{},
 		listenerMutex:          deadlock.Mutex{},
 		resyncObjChan:          make(chan interface{}),
-		objectsSeen:            make(map[ObjectUID]interface{}),
+		objectsSeen:            cache.NewStore(objectUIDKeyFunc),
 		listeners:              []chan *ObjectResourceMessage{},
 		meterDefinitionFilters: make(map[MeterDefUID]*MeterDefinitionLookupFilter),
-		objectResourceSet:      make(map[ObjectResourceKey]*ObjectResourceValue),
+		objectResourceSet:      cache.NewThreadSafeStore(objectResourceIndexers, cache.Indices{}),
+		watchedKinds:           make(map[schema.GroupVersionKind]bool),
 	}
 }
 
@@ -148,20 +217,13 @@ func (s *MeterDefinitionStore) addMeterDefinition(meterdef *v1alpha1.MeterDefini
 
 func (s *MeterDefinitionStore) removeMeterDefinition(meterdef *v1alpha1.MeterDefinition) {
 	delete(s.meterDefinitionFilters, MeterDefUID(meterdef.UID))
-	toDelete := []ObjectResourceKey{}
-
-	for key, val := range s.objectResourceSet {
-		if key.MeterDefUID == MeterDefUID(meterdef.GetUID()) {
-			toDelete = append(toDelete, key)
-			s.broadcast(&ObjectResourceMessage{
-				Action: DeleteMessageAction,
-				Object: val.Object,
-			})
-		}
-	}
 
-	for _, key := range toDelete {
-		delete(s.objectResourceSet, key)
+	for _, val := range s.byIndex(MeterDefUIDIndex, string(meterdef.GetUID())) {
+		s.objectResourceSet.Delete(val.key.Key())
+		s.broadcast(&ObjectResourceMessage{
+			Action: DeleteMessageAction,
+			Object: val.Object,
+		})
 	}
 
 	s.broadcast(&ObjectResourceMessage{
@@ -180,29 +242,67 @@ func (s *MeterDefinitionStore) broadcast(msg *ObjectResourceMessage) {
 }
 
 func (s *MeterDefinitionStore) GetMeterDefinitionRefs(uid types.UID) []*ObjectResourceValue {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+	return s.matched(s.byIndex(ObjectUIDIndex, string(uid)))
+}
 
-	vals := []*ObjectResourceValue{}
-	for key, val := range s.objectResourceSet {
-		if key.ObjectUID == ObjectUID(uid) && val.Matched {
-			vals = append(vals, val)
-		}
+func (s *MeterDefinitionStore) GetMeterDefObjects(meterDefUID types.UID) []*ObjectResourceValue {
+	return s.matched(s.byIndex(MeterDefUIDIndex, string(meterDefUID)))
+}
+
+// GetObjectResource returns the match of an object and a meter definition.
+func (s *MeterDefinitionStore) GetObjectResource(key ObjectResourceKey) (*ObjectResourceValue, bool) {
+	item, exists := s.objectResourceSet.Get(key.Key())
+	if !exists {
+		return nil, false
 	}
-	return vals
+
+	return item.(*ObjectResourceValue), true
 }
 
-func (s *MeterDefinitionStore) GetMeterDefObjects(meterDefUID types.UID) []*ObjectResourceValue {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+// ListObjectResources returns every match of an object and a meter definition.
+func (s *MeterDefinitionStore) ListObjectResources() []*ObjectResourceValue {
+	return toObjectResourceValues(s.objectResourceSet.List())
+}
+
+// ByIndex returns the matches whose indexed value is indexedValue. See
+// ObjectUIDIndex, MeterDefUIDIndex, MeterDefinitionIndex, NamespaceIndex and
+// WorkloadNameIndex for the indexes.
+func (s *MeterDefinitionStore) ByIndex(indexName, indexedValue string) ([]*ObjectResourceValue, error) {
+	items, err := s.objectResourceSet.ByIndex(indexName, indexedValue)
+	if err != nil {
+		return nil, err
+	}
+
+	return toObjectResourceValues(items), nil
+}
+
+// byIndex is ByIndex for the indexes the store defines, which can't fail.
+func (s *MeterDefinitionStore) byIndex(indexName, indexedValue string) []*ObjectResourceValue {
+	vals, err := s.ByIndex(indexName, indexedValue)
+	if err != nil {
+		s.log.Error(err, "failed to query index", "index", indexName)
+	}
+
+	return vals
+}
 
-	vals := []*ObjectResourceValue{}
-	for key, val := range s.objectResourceSet {
-		if key.MeterDefUID == MeterDefUID(meterDefUID) && val.Matched {
-			vals = append(vals, val)
+func (s *MeterDefinitionStore) matched(vals []*ObjectResourceValue) []*ObjectResourceValue {
+	matched := []*ObjectResourceValue{}
+	for _, val := range vals {
+		if val.Matched {
+			matched = append(matched, val)
 		}
 	}
 
+	return matched
+}
+
+func toObjectResourceValues(items []interface{}) []*ObjectResourceValue {
+	vals := make([]*ObjectResourceValue, 0, len(items))
+	for _, item := range items {
+		vals = append(vals, item.(*ObjectResourceValue))
+	}
+
 	return vals
 }
 
@@ -271,10 +371,8 @@ func (s *MeterDefinitionStore) Add(obj interface{}) error {
 		return nil
 	}
 
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
-	logger.Info("return matched results", len(matchedResults))
+	// owners are resolved before locking, they may need a lookup
+	values := make([]*ObjectResourceValue, 0, len(matchedResults))
 
 	for _, result := range matchedResults {
 		resource, err := v1alpha1.NewWorkloadResource(*result.workload, obj, s.scheme)
@@ -283,13 +381,29 @@ func (s *MeterDefinitionStore) Add(obj interface{}) error {
 			return err
 		}
 
+		resource.RootOwner, err = s.findRootOwner(obj, result.workload)
+		if err != nil {
+			// the match stands without an owner to attribute it to
+			logger.Error(err, "failed to find the root owner")
+		}
+
 		value, err := NewObjectResourceValue(result.lookup, resource, obj, result.ok)
 		if err != nil {
 			logger.Error(err, "failed to init a new workload resource value")
 			return err
 		}
 
-		s.objectResourceSet[result.key] = value
+		value.key = result.key
+		values = append(values, value)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	logger.Info("return matched results", "count", len(values))
+
+	for _, value := range values {
+		s.objectResourceSet.Update(value.key.Key(), value)
 
 		msg := &ObjectResourceMessage{
 			Action:              AddMessageAction,
@@ -307,6 +421,33 @@ func (s *MeterDefinitionStore) Add(obj interface{}) error {
 	return nil
 }
 
+// findRootOwner returns the top-most controller owner of the object, the
+// usage of its workload resource is attributed to it.
+func (s *MeterDefinitionStore) findRootOwner(
+	obj interface{},
+	workload *v1alpha1.Workload,
+) (*common.NamespacedNameReference, error) {
+	o, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.findOwner.FindRootOwner(o, workload.GetOwnerMaxDepth())
+	if err != nil || owner == nil {
+		return nil, err
+	}
+
+	return &common.NamespacedNameReference{
+		UID:       owner.UID,
+		Namespace: o.GetNamespace(),
+		Name:      owner.Name,
+		GroupVersionKind: &common.GroupVersionKind{
+			APIVersion: owner.APIVersion,
+			Kind:       owner.Kind,
+		},
+	}, nil
+}
+
 func (s *MeterDefinitionStore) handleMeterDefinition(meterdef *v1alpha1.MeterDefinition) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -323,6 +464,10 @@ func (s *MeterDefinitionStore) handleMeterDefinition(meterdef *v1alpha1.MeterDef
 	s.log.Info("found lookup", "lookup", lookup)
 	s.meterDefinitionFilters[MeterDefUID(meterdef.UID)] = lookup
 
+	if s.watchGenericWorkloads {
+		s.watchGenericKinds(meterdef)
+	}
+
 	msg := &ObjectResourceMessage{
 		Action: AddMessageAction,
 		Object: interface{}(meterdef),
@@ -334,18 +479,46 @@ func (s *MeterDefinitionStore) handleMeterDefinition(meterdef *v1alpha1.MeterDef
 	return nil
 }
 
+// watchGenericKinds starts reflectors for the kinds of the meter definition's
+// Generic workloads that aren't watched yet. A kind that can't be watched,
+// for example because its CRD isn't installed yet, is retried the next time
+// the meter definition is added. Watches last for the life of the store.
+func (s *MeterDefinitionStore) watchGenericKinds(meterdef *v1alpha1.MeterDefinition) {
+	for _, workload := range meterdef.Spec.Workloads {
+		if workload.WorkloadType != v1alpha1.WorkloadTypeGeneric || workload.GroupVersionKind == nil {
+			continue
+		}
+
+		gvk := schema.FromAPIVersionAndKind(workload.GroupVersionKind.APIVersion, workload.GroupVersionKind.Kind)
+
+		if s.watchedKinds[gvk] {
+			continue
+		}
+
+		resourceClient, err := s.dynamicClient.ClientForKind(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			s.log.Error(err, "failed to watch generic workload kind", "gvk", gvk.String())
+			continue
+		}
+
+		for _, ns := range s.namespaces {
+			expectedType := &unstructured.Unstructured{}
+			expectedType.SetGroupVersionKind(gvk)
+
+			reflector := cache.NewReflector(CreateDynamicListWatch(resourceClient, ns), expectedType, s, 5*60*time.Second)
+			go reflector.Run(s.ctx.Done())
+		}
+
+		s.log.Info("watching generic workload kind", "gvk", gvk.String())
+		s.watchedKinds[gvk] = true
+	}
+}
+
 func (s *MeterDefinitionStore) addSeenObject(obj interface{}) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	o, err := meta.Accessor(obj)
-	if err != nil {
-		return err
-	}
-
-	uid := ObjectUID(o.GetUID())
-	s.objectsSeen[uid] = obj
-	return nil
+	return s.objectsSeen.Update(obj)
 }
 
 func (s *MeterDefinitionStore) findObjectMatches(obj interface{}, results *[]result) error {
@@ -393,17 +566,17 @@ func (s *MeterDefinitionStore) Delete(obj interface{}) error {
 		return err
 	}
 
-	delete(s.objectsSeen, ObjectUID(o.GetUID()))
+	if err := s.objectsSeen.Delete(obj); err != nil {
+		return err
+	}
 
 	if meterdef, ok := obj.(*v1alpha1.MeterDefinition); ok {
 		s.removeMeterDefinition(meterdef)
 		return nil
 	}
 
-	for key, _ := range s.objectResourceSet {
-		if key.ObjectUID == ObjectUID(o.GetUID()) {
-			delete(s.objectResourceSet, key)
-		}
+	for _, val := range s.byIndex(ObjectUIDIndex, string(o.GetUID())) {
+		s.objectResourceSet.Delete(val.key.Key())
 	}
 
 	s.broadcast(&ObjectResourceMessage{
@@ -414,24 +587,26 @@ func (s *MeterDefinitionStore) Delete(obj interface{}) error {
 	return nil
 }
 
-// List implements the List method of the store interface.
+// List implements the List method of the store interface. It returns
+// every object seen, matched or not.
 func (s *MeterDefinitionStore) List() []interface{} {
-	return nil
+	return s.objectsSeen.List()
 }
 
-// ListKeys implements the ListKeys method of the store interface.
+// ListKeys implements the ListKeys method of the store interface. Objects
+// are keyed by UID.
 func (s *MeterDefinitionStore) ListKeys() []string {
-	return nil
+	return s.objectsSeen.ListKeys()
 }
 
 // Get implements the Get method of the store interface.
 func (s *MeterDefinitionStore) Get(obj interface{}) (item interface{}, exists bool, err error) {
-	return nil, false, nil
+	return s.objectsSeen.Get(obj)
 }
 
 // GetByKey implements the GetByKey method of the store interface.
 func (s *MeterDefinitionStore) GetByKey(key string) (item interface{}, exists bool, err error) {
-	return nil, false, nil
+	return s.objectsSeen.GetByKey(key)
 }
 
 // Replace will delete the contents of the store, using instead the
@@ -452,14 +627,7 @@ func (s *MeterDefinitionStore) Replace(list []interface{}, _ string) error {
 
 // Resync implements the Resync method of the store interface.
 func (s *MeterDefinitionStore) Resync() error {
-	s.mutex.Lock()
-	objs := []interface{}{}
-	for _, obj := range s.objectsSeen {
-		objs = append(objs, obj)
-	}
-	s.mutex.Unlock()
-
-	for _, obj := range objs {
+	for _, obj := range s.objectsSeen.List() {
 		s.Add(obj)
 	}
 
@@ -501,10 +669,17 @@ func (s *MeterDefinitionStoreBuilder) CreateStores() MeterDefinitionStores {
 
 	for _, storeConfig := range storeConfigs {
 		store := s.NewInstance()
+		store.watchGenericWorkloads = storeConfig.watchGenericWorkloads
 
 		for _, createLister := range storeConfig.createListers {
-			for _, ns := range s.namespaces {
+			for i, ns := range s.namespaces {
 				lister := createLister(s, ns)
+
+				// cluster-scoped resources are listed once
+				if lister.clusterScoped && i > 0 {
+					continue
+				}
+
 				reflector := cache.NewReflector(lister.lister, lister.expectedType, store, 5*60*time.Second)
 				go reflector.Run(s.ctx.Done())
 			}
@@ -524,11 +699,16 @@ func (s *MeterDefinitionStoreBuilder) SetNamespaces(ns []string) {
 type storeConfig struct {
 	name          string
 	createListers []createLister
+
+	// watchGenericWorkloads watches the kinds of Generic workloads as
+	// meter definitions are added.
+	watchGenericWorkloads bool
 }
 
 type reflectorConfig struct {
-	expectedType runtime.Object
-	lister       cache.ListerWatcher
+	expectedType  runtime.Object
+	lister        cache.ListerWatcher
+	clusterScoped bool
 }
 
 type createLister = func(*MeterDefinitionStoreBuilder, string) reflectorConfig
@@ -537,10 +717,12 @@ const (
 	ServiceStore          string = "serviceStore"
 	PodStore                     = "podStore"
 	PersistentVolumeStore        = "pvcStore"
+	GenericStore                 = "genericStore"
+	NodeStore                    = "nodeStore"
 )
 
 var (
-	storeConfigs []storeConfig = []storeConfig{pvcStore, podStore, serviceStore}
+	storeConfigs []storeConfig = []storeConfig{pvcStore, podStore, serviceStore, genericStore, nodeStore}
 	pvcStore     storeConfig   = storeConfig{
 		name: PersistentVolumeStore,
 		createListers: []createLister{
@@ -559,6 +741,19 @@ var (
 			serviceLister, serviceMonitorLister, meterDefLister,
 		},
 	}
+	genericStore = storeConfig{
+		name: GenericStore,
+		createListers: []createLister{
+			meterDefLister,
+		},
+		watchGenericWorkloads: true,
+	}
+	nodeStore = storeConfig{
+		name: NodeStore,
+		createListers: []createLister{
+			nodeLister, meterDefLister,
+		},
+	}
 )
 
 func pvcLister(s *MeterDefinitionStoreBuilder, ns string) reflectorConfig {
@@ -575,6 +770,14 @@ func podLister(s *MeterDefinitionStoreBuilder, ns string) reflectorConfig {
 	}
 }
 
+func nodeLister(s *MeterDefinitionStoreBuilder, _ string) reflectorConfig {
+	return reflectorConfig{
+		expectedType:  &corev1.Node{},
+		lister:        CreateNodeListWatch(s.kubeClient),
+		clusterScoped: true,
+	}
+}
+
 func serviceLister(s *MeterDefinitionStoreBuilder, ns string) reflectorConfig {
 	return reflectorConfig{
 		expectedType: &corev1.Service{},
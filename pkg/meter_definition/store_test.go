@@ -0,0 +1,286 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package meter_definition
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/common"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+	rhmclient "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/client"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("MeterDefinitionStore", func() {
+	var (
+		sut      *MeterDefinitionStore
+		meterdef *v1alpha1.MeterDefinition
+		pod      *corev1.Pod
+		other    *corev1.Pod
+	)
+
+	newPod := func(name, uid string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "apps",
+				UID:       types.UID(uid),
+				Labels:    labels,
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		sut = NewMeterDefinitionStoreBuilder(
+			context.TODO(), logf.Log.WithName("store"), nil, nil, nil, nil, nil, nil, scheme.Scheme,
+		).NewInstance()
+
+		meterdef = &v1alpha1.MeterDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-meter", Namespace: "apps", UID: "mdef-uid"},
+			Spec: v1alpha1.MeterDefinitionSpec{
+				Group:              "apps.partner.metering.com",
+				Kind:               "App",
+				WorkloadVertexType: v1alpha1.WorkloadVertexOperatorGroup,
+				Workloads: []v1alpha1.Workload{
+					{
+						Name:          "app-pods",
+						WorkloadType:  v1alpha1.WorkloadTypePod,
+						LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "metered"}},
+					},
+				},
+			},
+		}
+
+		pod = newPod("metered", "pod-uid", map[string]string{"app": "metered"})
+		other = newPod("unmetered", "other-uid", map[string]string{"app": "other"})
+
+		Expect(sut.Add(meterdef)).To(Succeed())
+		Expect(sut.Add(pod)).To(Succeed())
+		Expect(sut.Add(other)).To(Succeed())
+	})
+
+	It("should get and list seen objects", func() {
+		Expect(sut.ListKeys()).To(ConsistOf("pod-uid", "other-uid"))
+		Expect(sut.List()).To(HaveLen(2))
+
+		item, exists, err := sut.Get(pod)
+		Expect(err).To(Succeed())
+		Expect(exists).To(BeTrue())
+		Expect(item).To(Equal(pod))
+
+		item, exists, err = sut.GetByKey("other-uid")
+		Expect(err).To(Succeed())
+		Expect(exists).To(BeTrue())
+		Expect(item).To(Equal(other))
+	})
+
+	It("should index matches", func() {
+		Expect(sut.ListObjectResources()).To(HaveLen(1))
+
+		val, exists := sut.GetObjectResource(ObjectResourceKey{ObjectUID: "pod-uid", MeterDefUID: "mdef-uid"})
+		Expect(exists).To(BeTrue())
+		Expect(val.Object).To(Equal(pod))
+		Expect(val.MeterDef).To(Equal(types.NamespacedName{Name: "app-meter", Namespace: "apps"}))
+
+		for index, value := range map[string]string{
+			ObjectUIDIndex:       "pod-uid",
+			MeterDefUIDIndex:     "mdef-uid",
+			MeterDefinitionIndex: "apps/app-meter",
+			NamespaceIndex:       "apps",
+			WorkloadNameIndex:    "app-pods",
+		} {
+			vals, err := sut.ByIndex(index, value)
+			Expect(err).To(Succeed())
+			Expect(vals).To(ConsistOf(val), index)
+		}
+
+		Expect(sut.GetMeterDefinitionRefs("pod-uid")).To(ConsistOf(val))
+		Expect(sut.GetMeterDefinitionRefs("other-uid")).To(BeEmpty())
+		Expect(sut.GetMeterDefObjects("mdef-uid")).To(ConsistOf(val))
+
+		_, err := sut.ByIndex("unknown", "value")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should match resources of generic workloads", func() {
+		meterdef.Spec.Workloads = append(meterdef.Spec.Workloads, v1alpha1.Workload{
+			Name:             "app-deployments",
+			WorkloadType:     v1alpha1.WorkloadTypeGeneric,
+			GroupVersionKind: &common.GroupVersionKind{APIVersion: "apps/v1", Kind: "Deployment"},
+		})
+		Expect(sut.Add(meterdef)).To(Succeed())
+
+		deployment := &unstructured.Unstructured{}
+		deployment.SetAPIVersion("apps/v1")
+		deployment.SetKind("Deployment")
+		deployment.SetName("metered")
+		deployment.SetNamespace("apps")
+		deployment.SetUID("deployment-uid")
+
+		statefulSet := deployment.DeepCopy()
+		statefulSet.SetKind("StatefulSet")
+		statefulSet.SetUID("statefulset-uid")
+
+		Expect(sut.Add(deployment)).To(Succeed())
+		Expect(sut.Add(statefulSet)).To(Succeed())
+
+		vals, err := sut.ByIndex(WorkloadNameIndex, "app-deployments")
+		Expect(err).To(Succeed())
+		Expect(vals).To(HaveLen(1))
+		Expect(vals[0].Object).To(Equal(deployment))
+		Expect(vals[0].WorkloadResource.GroupVersionKind.Kind).To(Equal("Deployment"))
+		Expect(sut.GetMeterDefinitionRefs("statefulset-uid")).To(BeEmpty())
+	})
+
+	It("should match nodes of a cluster meter definition", func() {
+		clusterMeterdef := &v1alpha1.MeterDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-meter", Namespace: "apps", UID: "node-mdef-uid"},
+			Spec: v1alpha1.MeterDefinitionSpec{
+				Group:              "apps.partner.metering.com",
+				Kind:               "App",
+				WorkloadVertexType: v1alpha1.WorkloadVertexCluster,
+				Workloads: []v1alpha1.Workload{
+					{
+						Name:         "cluster-nodes",
+						WorkloadType: v1alpha1.WorkloadTypeNode,
+					},
+				},
+			},
+		}
+		Expect(sut.Add(clusterMeterdef)).To(Succeed())
+
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-0", UID: "node-uid"},
+		}
+		Expect(sut.Add(node)).To(Succeed())
+
+		vals, err := sut.ByIndex(WorkloadNameIndex, "cluster-nodes")
+		Expect(err).To(Succeed())
+		Expect(vals).To(HaveLen(1))
+		Expect(vals[0].Object).To(Equal(node))
+		Expect(sut.GetMeterDefinitionRefs("pod-uid")).To(HaveLen(1))
+
+		By("requiring the cluster vertex")
+		clusterMeterdef.Spec.WorkloadVertexType = v1alpha1.WorkloadVertexNamespace
+		_, err = NewMeterDefinitionLookupFilter(nil, clusterMeterdef, nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should match owners several levels up and record the root owner", func() {
+		isController := true
+		ownerRef := func(apiVersion, kind, name, uid string) []metav1.OwnerReference {
+			return []metav1.OwnerReference{{
+				APIVersion: apiVersion,
+				Kind:       kind,
+				Name:       name,
+				UID:        types.UID(uid),
+				Controller: &isController,
+			}}
+		}
+		newOwner := func(apiVersion, kind, name, uid string, owners []metav1.OwnerReference) *unstructured.Unstructured {
+			u := &unstructured.Unstructured{}
+			u.SetAPIVersion(apiVersion)
+			u.SetKind(kind)
+			u.SetName(name)
+			u.SetNamespace("apps")
+			u.SetUID(types.UID(uid))
+			u.SetOwnerReferences(owners)
+			return u
+		}
+
+		mapper := meta.NewDefaultRESTMapper(nil)
+		for _, gvk := range []schema.GroupVersionKind{
+			{Group: "apps", Version: "v1", Kind: "ReplicaSet"},
+			{Group: "apps", Version: "v1", Kind: "Deployment"},
+			{Group: "apps.partner.metering.com", Version: "v1", Kind: "Operand"},
+			{Group: "apps.partner.metering.com", Version: "v1", Kind: "Instance"},
+		} {
+			mapper.Add(gvk, meta.RESTScopeNamespace)
+		}
+
+		dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+			newOwner("apps/v1", "ReplicaSet", "app-rs", "rs-uid",
+				ownerRef("apps/v1", "Deployment", "app", "deployment-uid")),
+			newOwner("apps/v1", "Deployment", "app", "deployment-uid",
+				ownerRef("apps.partner.metering.com/v1", "Operand", "operand", "operand-uid")),
+			newOwner("apps.partner.metering.com/v1", "Operand", "operand", "operand-uid",
+				ownerRef("apps.partner.metering.com/v1", "Instance", "instance", "instance-uid")),
+			newOwner("apps.partner.metering.com/v1", "Instance", "instance", "instance-uid", nil),
+		)
+		findOwner := rhmclient.NewFindOwnerHelper(rhmclient.NewDynamicClient(dynamicClient, mapper))
+
+		sut = NewMeterDefinitionStoreBuilder(
+			context.TODO(), logf.Log.WithName("store"), nil, nil, findOwner, nil, nil, nil, scheme.Scheme,
+		).NewInstance()
+
+		meterdef.Spec.Workloads[0].LabelSelector = nil
+		meterdef.Spec.Workloads[0].OwnerCRD = &common.GroupVersionKind{
+			APIVersion: "apps.partner.metering.com/v1",
+			Kind:       "Instance",
+		}
+		Expect(sut.Add(meterdef)).To(Succeed())
+
+		pod.OwnerReferences = ownerRef("apps/v1", "ReplicaSet", "app-rs", "rs-uid")
+		Expect(sut.Add(pod)).To(Succeed())
+
+		vals := sut.GetMeterDefinitionRefs("pod-uid")
+		Expect(vals).To(HaveLen(1))
+		Expect(vals[0].RootOwner).To(Equal(&common.NamespacedNameReference{
+			UID:              "instance-uid",
+			Namespace:        "apps",
+			Name:             "instance",
+			GroupVersionKind: &common.GroupVersionKind{APIVersion: "apps.partner.metering.com/v1", Kind: "Instance"},
+		}))
+
+		By("using cached owners")
+		Expect(dynamicClient.Resource(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}).
+			Namespace("apps").Delete(context.TODO(), "app", metav1.DeleteOptions{})).To(Succeed())
+		root, err := findOwner.FindRootOwner(pod, 10)
+		Expect(err).To(Succeed())
+		Expect(root.Name).To(Equal("instance"))
+
+		By("stopping at the max depth")
+		depth := int32(2)
+		meterdef.Spec.Workloads[0].OwnerMaxDepth = &depth
+		Expect(sut.Add(meterdef)).To(Succeed())
+		Expect(sut.Delete(pod)).To(Succeed())
+		Expect(sut.Add(pod)).To(Succeed())
+		Expect(sut.GetMeterDefinitionRefs("pod-uid")).To(BeEmpty())
+	})
+
+	It("should remove deleted objects and meter definitions", func() {
+		Expect(sut.Delete(pod)).To(Succeed())
+		Expect(sut.ListKeys()).To(ConsistOf("other-uid"))
+		Expect(sut.ListObjectResources()).To(BeEmpty())
+
+		Expect(sut.Add(pod)).To(Succeed())
+		Expect(sut.ListObjectResources()).To(HaveLen(1))
+
+		Expect(sut.Delete(meterdef)).To(Succeed())
+		Expect(sut.ListObjectResources()).To(BeEmpty())
+		Expect(sut.GetMeterDefObjects("mdef-uid")).To(BeEmpty())
+	})
+})
@@ -54,6 +54,11 @@ func (o *ObjectResourceKey) String() string {
 	return string(jsonOut)
 }
 
+// Key is the store key of the object and meter definition pair.
+func (o ObjectResourceKey) Key() string {
+	return string(o.ObjectUID) + "/" + string(o.MeterDefUID)
+}
+
 func NewObjectResourceKey(object metav1.Object, meterdefUID MeterDefUID) ObjectResourceKey {
 	return ObjectResourceKey{
 		ObjectUID:   ObjectUID(object.GetUID()),
@@ -68,6 +73,8 @@ type ObjectResourceValue struct {
 	Matched      bool
 	Object       interface{}
 	*v1alpha1.WorkloadResource
+
+	key ObjectResourceKey
 }
 
 func NewObjectResourceValue(
@@ -22,6 +22,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 )
@@ -48,6 +49,17 @@ func CreatePodListWatch(kubeClient clientset.Interface, ns string) cache.ListerW
 	}
 }
 
+func CreateNodeListWatch(kubeClient clientset.Interface) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			return kubeClient.CoreV1().Nodes().List(context.TODO(), opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return kubeClient.CoreV1().Nodes().Watch(context.TODO(), opts)
+		},
+	}
+}
+
 func CreateServiceMonitorListWatch(c *monitoringv1client.MonitoringV1Client, ns string) cache.ListerWatcher {
 	return &cache.ListWatch{
 		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
@@ -80,3 +92,14 @@ func CreateMeterDefinitionWatch(c *marketplacev1alpha1client.MarketplaceV1alpha1
 		},
 	}
 }
+
+func CreateDynamicListWatch(c dynamic.NamespaceableResourceInterface, ns string) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			return c.Namespace(ns).List(context.TODO(), opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return c.Namespace(ns).Watch(context.TODO(), opts)
+		},
+	}
+}
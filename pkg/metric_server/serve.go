@@ -246,7 +246,7 @@ func serveMetrics(ctx context.Context, storeBuilder *metrics.Builder, opts *opti
 }
 
 type metricHandler struct {
-	stores             []*metrics.MetricsStore
+	stores             []metrics.MetricStore
 	enableGZIPEncoding bool
 }
 
@@ -75,7 +75,7 @@ func NewServer(opts *Options) (*Service, error) {
 	if err != nil {
 		return nil, err
 	}
-	meterDefinitionStoreBuilder := meter_definition.NewMeterDefinitionStoreBuilder(context, logger, clientCommandRunner, clientset, findOwnerHelper, monitoringV1Client, marketplaceV1alpha1Client, scheme)
+	meterDefinitionStoreBuilder := meter_definition.NewMeterDefinitionStoreBuilder(context, logger, clientCommandRunner, clientset, findOwnerHelper, dynamicClient, monitoringV1Client, marketplaceV1alpha1Client, scheme)
 	statusProcessor := meter_definition.NewStatusProcessor(logger, clientCommandRunner)
 	serviceProcessor := meter_definition.NewServiceProcessor(logger, clientCommandRunner)
 	cacheIsIndexed, err := addIndex(context, cache)
@@ -0,0 +1,365 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/prometheus/common/model"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type PromQuery struct {
+	Type v1alpha1.WorkloadType
+	// Kind is the kind of the resources of a Generic workload.
+	Kind          string
+	MeterDef      types.NamespacedName
+	Workload      string
+	Metric        string
+	Query         string
+	Start, End    time.Time
+	Step          time.Duration
+	Time          string
+	AggregateFunc string
+	AggregateBy   []string
+
+	// MetricType, Series and Quantile select a series of a histogram or
+	// summary metric. Query is then the metric name with optional matchers.
+	MetricType v1alpha1.MetricType
+	Series     HistogramSeries
+	Quantile   string
+
+	// Recorded queries the series recorded by the meter definition's
+	// recording rule instead of joining at query time.
+	Recorded bool
+
+	// Instant evaluates the query once at End for the whole window.
+	Instant bool
+}
+
+type HistogramSeries string
+
+const (
+	HistogramSeriesQuantile HistogramSeries = "quantile"
+	HistogramSeriesSum      HistogramSeries = "sum"
+	HistogramSeriesCount    HistogramSeries = "count"
+)
+
+// NewPromQueries builds the queries for a metric label. Gauges and counters
+// use a single query. Histograms and summaries use a query per quantile plus
+// the increase of the _sum and _count series, each reported as its own metric.
+func NewPromQueries(
+	mdef types.NamespacedName,
+	workload v1alpha1.Workload,
+	metric v1alpha1.MeterLabelQuery,
+	start, end time.Time,
+) ([]*PromQuery, error) {
+	interval := metric.GetInterval()
+
+	// an instant query has a single interval, the report window
+	if metric.Instant {
+		interval = end.Sub(start)
+	}
+
+	if err := validateInterval(interval, start, end); err != nil {
+		return nil, errors.Wrapf(err, "invalid interval for %s", metric.Label)
+	}
+
+	queries, err := buildPromQueries(mdef, workload, metric)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, query := range queries {
+		query.Start = start
+		query.End = end
+		query.Step = interval
+		query.Time = model.Duration(interval).String()
+	}
+
+	return queries, nil
+}
+
+func buildPromQueries(
+	mdef types.NamespacedName,
+	workload v1alpha1.Workload,
+	metric v1alpha1.MeterLabelQuery,
+) ([]*PromQuery, error) {
+	interval := metric.GetInterval()
+
+	if workload.WorkloadType == v1alpha1.WorkloadTypeGeneric &&
+		(workload.GroupVersionKind == nil || workload.GroupVersionKind.Kind == "") {
+		return nil, errors.Errorf("generic workload %s requires a groupVersionKind", workload.Name)
+	}
+
+	base := PromQuery{
+		Metric:        metric.Label,
+		Type:          workload.WorkloadType,
+		MeterDef:      mdef,
+		Workload:      workload.Name,
+		Query:         metric.Query,
+		Time:          model.Duration(interval).String(),
+		Step:          interval,
+		AggregateFunc: metric.Aggregation,
+		AggregateBy:   append(append([]string{}, workload.ResourceLabels...), workload.AdditionalLabels...),
+		MetricType:    metric.MetricType,
+		Instant:       metric.Instant,
+	}
+
+	if workload.GroupVersionKind != nil {
+		base.Kind = workload.GroupVersionKind.Kind
+	}
+
+	if !metric.IsHistogram() {
+		return []*PromQuery{&base}, nil
+	}
+
+	if base.Query == "" {
+		base.Query = metric.Label
+	}
+
+	queries := []*PromQuery{}
+
+	for _, quantile := range metric.Quantiles {
+		value, err := strconv.ParseFloat(quantile, 64)
+
+		if err != nil || value < 0 || value > 1 {
+			return nil, errors.Errorf("quantile %q of %s must be a number between 0 and 1", quantile, metric.Label)
+		}
+
+		query := base
+		query.Metric = fmt.Sprintf("%s_p%s", metric.Label,
+			strings.Replace(strconv.FormatFloat(value*100, 'f', -1, 64), ".", "_", 1))
+		query.Series = HistogramSeriesQuantile
+		query.Quantile = quantile
+		queries = append(queries, &query)
+	}
+
+	for _, series := range []HistogramSeries{HistogramSeriesSum, HistogramSeriesCount} {
+		query := base
+		query.Metric = fmt.Sprintf("%s_%s", metric.Label, series)
+		query.Series = series
+		queries = append(queries, &query)
+	}
+
+	return queries, nil
+}
+
+const minInterval = time.Minute
+
+// validateInterval checks the interval splits the report window evenly.
+func validateInterval(interval time.Duration, start, end time.Time) error {
+	window := end.Sub(start)
+
+	switch {
+	case interval < minInterval:
+		return errors.Errorf("interval %s is less than %s", interval, minInterval)
+	case window <= 0:
+		return errors.Errorf("report window %s to %s is empty", start, end)
+	case interval > window:
+		return errors.Errorf("interval %s is longer than the report window %s", interval, window)
+	case window%interval != 0:
+		return errors.Errorf("report window %s is not a multiple of the interval %s", window, interval)
+	}
+
+	return nil
+}
+
+func (q *PromQuery) makeLeftSide() string {
+	switch q.Type {
+	case v1alpha1.WorkloadTypePVC:
+		return fmt.Sprintf(`avg(meterdef_persistentvolumeclaim_info{meter_def_name="%v",meter_def_namespace="%v",phase="Bound"}) without (instance, container, endpoint, job, service)`, q.MeterDef.Name, q.MeterDef.Namespace)
+	case v1alpha1.WorkloadTypePod:
+		return fmt.Sprintf(`avg(meterdef_pod_info{meter_def_name="%v",meter_def_namespace="%v"}) without (pod_uid, instance, container, endpoint, job, service)`, q.MeterDef.Name, q.MeterDef.Namespace)
+	case v1alpha1.WorkloadTypeService:
+		// Service and service monitor are handled the same
+		fallthrough
+	case v1alpha1.WorkloadTypeServiceMonitor:
+		return fmt.Sprintf(`avg(meterdef_service_info{meter_def_name="%v",meter_def_namespace="%v"}) without (pod_uid, instance, container, endpoint, job, pod)`, q.MeterDef.Name, q.MeterDef.Namespace)
+	case v1alpha1.WorkloadTypeGeneric:
+		return fmt.Sprintf(`avg(%v{meter_def_name="%v",meter_def_namespace="%v"}) without (%v_uid, instance, container, endpoint, job, service, pod)`, GenericInfoMetric(q.Kind), q.MeterDef.Name, q.MeterDef.Namespace, GenericKindLabel(q.Kind))
+	case v1alpha1.WorkloadTypeNode:
+		// nodes aren't namespaced, the namespace is the exporter's
+		return fmt.Sprintf(`avg(meterdef_node_info{meter_def_name="%v",meter_def_namespace="%v"}) without (node_uid, instance, container, endpoint, job, service, pod, namespace)`, q.MeterDef.Name, q.MeterDef.Namespace)
+	default:
+		return "NOTSUPPORTED"
+	}
+}
+
+// JoinLabels are the labels a workload's info series is joined on.
+func (q *PromQuery) JoinLabels() string {
+	switch q.Type {
+	case v1alpha1.WorkloadTypePVC:
+		return "persistentvolumeclaim,namespace"
+	case v1alpha1.WorkloadTypePod:
+		return "pod,namespace"
+	case v1alpha1.WorkloadTypeService:
+		fallthrough
+	case v1alpha1.WorkloadTypeServiceMonitor:
+		return "service,namespace"
+	case v1alpha1.WorkloadTypeGeneric:
+		return GenericKindLabel(q.Kind) + ",namespace"
+	case v1alpha1.WorkloadTypeNode:
+		return "node"
+	default:
+		return "NOTSUPPORTED"
+	}
+}
+
+var invalidLabelNameChars = regexp.MustCompile(`[^a-z0-9_]`)
+
+// GenericKindLabel is the label holding the name of a Generic workload's
+// resource. It is the lowercased kind, the label kube-state-metrics uses for
+// the resources it exports, such as deployment or statefulset.
+func GenericKindLabel(kind string) string {
+	return invalidLabelNameChars.ReplaceAllString(strings.ToLower(kind), "_")
+}
+
+// GenericInfoMetric is the info series of the resources of a Generic workload.
+func GenericInfoMetric(kind string) string {
+	return "meterdef_" + GenericKindLabel(kind) + "_info"
+}
+
+// GenericReplicasMetric is the desired replicas of the scalable resources
+// of a Generic workload.
+func GenericReplicasMetric(kind string) string {
+	return "meterdef_" + GenericKindLabel(kind) + "_replicas"
+}
+
+// RootOwnerLabels are the labels of the info series naming the top-most
+// controller owner of the resource. They are only on the info series, so
+// the join copies them to the result when the workload aggregates by them.
+var RootOwnerLabels = []string{"root_owner_kind", "root_owner_name"}
+
+func (q *PromQuery) makeJoin() string {
+	include := []string{}
+
+	for _, label := range RootOwnerLabels {
+		for _, by := range q.AggregateBy {
+			if by == label {
+				include = append(include, label)
+				break
+			}
+		}
+	}
+
+	if len(include) == 0 {
+		return fmt.Sprintf("* on(%v) group_right", q.JoinLabels())
+	}
+
+	return fmt.Sprintf("* on(%v) group_right(%v)", q.JoinLabels(), strings.Join(include, ","))
+}
+
+// aggregateLabels are the join labels and any labels the workload
+// attributes usage by.
+func (q *PromQuery) aggregateLabels() string {
+	by := q.JoinLabels()
+	seen := map[string]bool{}
+
+	for _, label := range strings.Split(by, ",") {
+		seen[label] = true
+	}
+
+	for _, label := range q.AggregateBy {
+		if !seen[label] {
+			seen[label] = true
+			by = by + "," + label
+		}
+	}
+
+	return by
+}
+
+func (q *PromQuery) makeAggregateBy() string {
+	return fmt.Sprintf(`%v by (%v)`, q.AggregateFunc, q.aggregateLabels())
+}
+
+// makeHistogramQuery selects the series of a histogram or summary. The
+// result keeps the aggregate labels so it can be joined to the workload
+// and attributed by the workload's labels.
+func (q *PromQuery) makeHistogramQuery() string {
+	name, matchers := splitSelector(q.Query)
+	by := q.aggregateLabels()
+
+	switch {
+	case q.Series == HistogramSeriesQuantile && q.MetricType == v1alpha1.MetricTypeSummary:
+		return fmt.Sprintf(`max by (%v) (%v{%v})`,
+			by, name, joinMatchers(matchers, fmt.Sprintf(`quantile="%v"`, q.Quantile)))
+	case q.Series == HistogramSeriesQuantile:
+		return fmt.Sprintf(`histogram_quantile(%v, sum by (le,%v) (rate(%v_bucket{%v}[%v])))`,
+			q.Quantile, by, name, matchers, q.Time)
+	default:
+		return fmt.Sprintf(`sum by (%v) (increase(%v_%v{%v}[%v]))`,
+			by, name, q.Series, matchers, q.Time)
+	}
+}
+
+// splitSelector splits a series selector into the metric name and its
+// label matchers.
+func splitSelector(selector string) (string, string) {
+	selector = strings.TrimSpace(selector)
+	i := strings.Index(selector, "{")
+
+	if i < 0 {
+		return selector, ""
+	}
+
+	return selector[:i], strings.TrimSuffix(strings.TrimSpace(selector[i+1:]), "}")
+}
+
+func joinMatchers(matchers ...string) string {
+	nonEmpty := []string{}
+	for _, m := range matchers {
+		if m = strings.Trim(strings.TrimSpace(m), ","); m != "" {
+			nonEmpty = append(nonEmpty, m)
+		}
+	}
+	return strings.Join(nonEmpty, ",")
+}
+
+func (q *PromQuery) String() string {
+	if q.Recorded {
+		return q.RecordedSelector()
+	}
+
+	return q.Expr()
+}
+
+// Expr is the query joining the metric to the workload's info series.
+func (q *PromQuery) Expr() string {
+	aggregate := q.makeAggregateBy()
+	leftSide := q.makeLeftSide()
+	join := q.makeJoin()
+
+	var query string
+	if q.Series != "" {
+		query = q.makeHistogramQuery()
+	} else if q.Query != "" {
+		query = q.Query
+	} else {
+		query = fmt.Sprintf("%s{}", q.Metric)
+	}
+
+	return fmt.Sprintf(
+		`%v (%v %v %v)`, aggregate, leftSide, join, query,
+	)
+}
@@ -0,0 +1,378 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"time"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/common"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("Query", func() {
+
+	var (
+		start, _ = time.Parse(time.RFC3339, "2020-04-19T13:00:00Z")
+		end, _   = time.Parse(time.RFC3339, "2020-04-19T16:00:00Z")
+
+		rpcDurationSecondsQuery *PromQuery
+	)
+
+	BeforeEach(func() {
+		rpcDurationSecondsQuery = &PromQuery{
+			Metric: "rpc_durations_seconds_count",
+			Query:  `foo{bar="true"}`,
+			Start:  start,
+			End:    end,
+			Step:   time.Minute * 60,
+		}
+	})
+
+	It("should build a query", func() {
+		q1 := &PromQuery{
+			Metric: "foo",
+			Query:  "kube_persistentvolumeclaim_resource_requests_storage_bytes",
+			MeterDef: types.NamespacedName{
+				Name:      "foo",
+				Namespace: "foons",
+			},
+			AggregateFunc: "sum",
+			Type:          v1alpha1.WorkloadTypePVC,
+		}
+
+		expected := "sum by (persistentvolumeclaim,namespace) (avg(meterdef_persistentvolumeclaim_info{meter_def_name=\"foo\",meter_def_namespace=\"foons\",phase=\"Bound\"}) without (instance, container, endpoint, job, service) * on(persistentvolumeclaim,namespace) group_right kube_persistentvolumeclaim_resource_requests_storage_bytes)"
+		Expect(q1.String()).To(Equal(expected), "failed to create query for pvc")
+	})
+
+	It("should build histogram queries", func() {
+		queries, err := NewPromQueries(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{WorkloadType: v1alpha1.WorkloadTypePod},
+			v1alpha1.MeterLabelQuery{
+				Label:       "request_latency",
+				Query:       `http_request_duration_seconds{handler="/api"}`,
+				Aggregation: "max",
+				MetricType:  v1alpha1.MetricTypeHistogram,
+				Quantiles:   []string{"0.95", "0.999"},
+			},
+			start, end,
+		)
+		Expect(err).To(Succeed())
+		Expect(queries).To(HaveLen(4))
+
+		names := []string{}
+		for _, q := range queries {
+			names = append(names, q.Metric)
+		}
+		Expect(names).To(Equal([]string{"request_latency_p95", "request_latency_p99_9", "request_latency_sum", "request_latency_count"}))
+
+		Expect(queries[0].String()).To(HaveSuffix(
+			`* on(pod,namespace) group_right histogram_quantile(0.95, sum by (le,pod,namespace) (rate(http_request_duration_seconds_bucket{handler="/api"}[1h]))))`))
+		Expect(queries[2].String()).To(HaveSuffix(
+			`* on(pod,namespace) group_right sum by (pod,namespace) (increase(http_request_duration_seconds_sum{handler="/api"}[1h])))`))
+		Expect(queries[3].String()).To(HavePrefix("max by (pod,namespace) "))
+	})
+
+	It("should build summary queries", func() {
+		queries, err := NewPromQueries(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{WorkloadType: v1alpha1.WorkloadTypeService},
+			v1alpha1.MeterLabelQuery{
+				Label:       "rpc_durations_seconds",
+				Aggregation: "max",
+				MetricType:  v1alpha1.MetricTypeSummary,
+				Quantiles:   []string{"0.5"},
+			},
+			start, end,
+		)
+		Expect(err).To(Succeed())
+		Expect(queries).To(HaveLen(3))
+		Expect(queries[0].Metric).To(Equal("rpc_durations_seconds_p50"))
+		Expect(queries[0].String()).To(HaveSuffix(
+			`* on(service,namespace) group_right max by (service,namespace) (rpc_durations_seconds{quantile="0.5"}))`))
+		Expect(queries[1].String()).To(ContainSubstring(`increase(rpc_durations_seconds_sum{}[1h])`))
+	})
+
+	It("should keep the workload labels in the aggregation", func() {
+		queries, err := NewPromQueries(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{
+				WorkloadType:     v1alpha1.WorkloadTypePod,
+				ResourceLabels:   []string{"tenant"},
+				AdditionalLabels: []string{"namespace", "node"},
+			},
+			v1alpha1.MeterLabelQuery{Label: "requests", Aggregation: "sum"},
+			start, end,
+		)
+		Expect(err).To(Succeed())
+		Expect(queries[0].String()).To(HavePrefix("sum by (pod,namespace,tenant,node) ("))
+	})
+
+	It("should keep the workload labels in histogram series", func() {
+		queries, err := NewPromQueries(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{
+				WorkloadType:     v1alpha1.WorkloadTypePod,
+				ResourceLabels:   []string{"tenant"},
+				AdditionalLabels: []string{"namespace"},
+			},
+			v1alpha1.MeterLabelQuery{
+				Label:       "request_latency",
+				Query:       `http_request_duration_seconds{handler="/api"}`,
+				Aggregation: "max",
+				MetricType:  v1alpha1.MetricTypeHistogram,
+				Quantiles:   []string{"0.95"},
+			},
+			start, end,
+		)
+		Expect(err).To(Succeed())
+		Expect(queries[0].String()).To(HaveSuffix(
+			`histogram_quantile(0.95, sum by (le,pod,namespace,tenant) (rate(http_request_duration_seconds_bucket{handler="/api"}[1h]))))`))
+		Expect(queries[1].String()).To(HaveSuffix(
+			`sum by (pod,namespace,tenant) (increase(http_request_duration_seconds_sum{handler="/api"}[1h])))`))
+
+		queries, err = NewPromQueries(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{
+				WorkloadType:   v1alpha1.WorkloadTypeService,
+				ResourceLabels: []string{"tenant"},
+			},
+			v1alpha1.MeterLabelQuery{
+				Label:       "rpc_durations_seconds",
+				Aggregation: "max",
+				MetricType:  v1alpha1.MetricTypeSummary,
+				Quantiles:   []string{"0.5"},
+			},
+			start, end,
+		)
+		Expect(err).To(Succeed())
+		Expect(queries[0].String()).To(HaveSuffix(
+			`max by (service,namespace,tenant) (rpc_durations_seconds{quantile="0.5"}))`))
+	})
+
+	It("should copy the root owner labels across the join", func() {
+		queries, err := NewPromQueries(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{
+				WorkloadType:   v1alpha1.WorkloadTypePod,
+				ResourceLabels: []string{"root_owner_kind", "root_owner_name"},
+			},
+			v1alpha1.MeterLabelQuery{
+				Label:       "rpc_durations_seconds_count",
+				Aggregation: "sum",
+			},
+			start, end,
+		)
+		Expect(err).To(Succeed())
+		Expect(queries[0].String()).To(Equal(
+			`sum by (pod,namespace,root_owner_kind,root_owner_name) (avg(meterdef_pod_info{meter_def_name="foo",meter_def_namespace="foons"}) without (pod_uid, instance, container, endpoint, job, service) * on(pod,namespace) group_right(root_owner_kind,root_owner_name) rpc_durations_seconds_count{})`))
+	})
+
+	It("should use the metric interval", func() {
+		queries, err := NewPromQueries(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{WorkloadType: v1alpha1.WorkloadTypePod},
+			v1alpha1.MeterLabelQuery{
+				Label:    "requests",
+				Interval: &metav1.Duration{Duration: 15 * time.Minute},
+			},
+			start, end,
+		)
+		Expect(err).To(Succeed())
+		Expect(queries[0].Step).To(Equal(15 * time.Minute))
+		Expect(queries[0].Time).To(Equal("15m"))
+
+		queries, err = NewPromQueries(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{WorkloadType: v1alpha1.WorkloadTypePod},
+			v1alpha1.MeterLabelQuery{Label: "requests"},
+			start, end,
+		)
+		Expect(err).To(Succeed())
+		Expect(queries[0].Step).To(Equal(time.Hour))
+	})
+
+	It("should query an instant metric over the report window", func() {
+		queries, err := NewPromQueries(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{WorkloadType: v1alpha1.WorkloadTypePod},
+			v1alpha1.MeterLabelQuery{
+				Label:      "requests",
+				MetricType: v1alpha1.MetricTypeHistogram,
+				Interval:   &metav1.Duration{Duration: 15 * time.Minute},
+				Instant:    true,
+			},
+			start, end,
+		)
+		Expect(err).To(Succeed())
+		Expect(queries).To(HaveLen(2))
+		Expect(queries[0].Instant).To(BeTrue())
+		Expect(queries[0].Step).To(Equal(3 * time.Hour))
+		Expect(queries[0].String()).To(ContainSubstring(`increase(requests_sum{}[3h])`))
+
+		group, err := NewRecordingRuleGroup(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{
+				WorkloadType: v1alpha1.WorkloadTypePod,
+				MetricLabels: []v1alpha1.MeterLabelQuery{{Label: "requests", Instant: true}},
+			},
+		)
+		Expect(err).To(Succeed())
+		Expect(group.Rules).To(BeEmpty())
+	})
+
+	It("should validate the interval against the report window", func() {
+		Expect(validateInterval(24*time.Hour, start, start.Add(24*time.Hour))).To(Succeed())
+		Expect(validateInterval(15*time.Minute, start, end)).To(Succeed())
+		Expect(validateInterval(24*time.Hour, start, end)).ToNot(Succeed())
+		Expect(validateInterval(50*time.Minute, start, end)).ToNot(Succeed())
+		Expect(validateInterval(time.Second, start, end)).ToNot(Succeed())
+		Expect(validateInterval(time.Hour, end, start)).ToNot(Succeed())
+	})
+
+	It("should join a generic workload on its kind", func() {
+		queries, err := NewPromQueries(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{
+				Name:             "databases",
+				WorkloadType:     v1alpha1.WorkloadTypeGeneric,
+				GroupVersionKind: &common.GroupVersionKind{APIVersion: "db.example.com/v1", Kind: "Database"},
+			},
+			v1alpha1.MeterLabelQuery{Label: "database_count", Query: "meterdef_database_info", Aggregation: "max"},
+			start, end,
+		)
+		Expect(err).To(Succeed())
+		Expect(queries[0].String()).To(Equal(
+			`max by (database,namespace) (avg(meterdef_database_info{meter_def_name="foo",meter_def_namespace="foons"}) without (database_uid, instance, container, endpoint, job, service, pod) * on(database,namespace) group_right meterdef_database_info)`))
+
+		_, err = NewPromQueries(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{Name: "databases", WorkloadType: v1alpha1.WorkloadTypeGeneric},
+			v1alpha1.MeterLabelQuery{Label: "database_count"},
+			start, end,
+		)
+		Expect(err).ToNot(Succeed())
+	})
+
+	It("should join a node workload on the node", func() {
+		queries, err := NewPromQueries(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{Name: "nodes", WorkloadType: v1alpha1.WorkloadTypeNode},
+			v1alpha1.MeterLabelQuery{Label: "cores", Query: `kube_node_status_capacity{resource="cpu"}`, Aggregation: "max"},
+			start, end,
+		)
+		Expect(err).To(Succeed())
+		Expect(queries[0].String()).To(Equal(
+			`max by (node) (avg(meterdef_node_info{meter_def_name="foo",meter_def_namespace="foons"}) without (node_uid, instance, container, endpoint, job, service, pod, namespace) * on(node) group_right kube_node_status_capacity{resource="cpu"})`))
+	})
+
+	It("should reject an invalid quantile", func() {
+		_, err := NewPromQueries(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{WorkloadType: v1alpha1.WorkloadTypePod},
+			v1alpha1.MeterLabelQuery{
+				Label:      "request_latency",
+				MetricType: v1alpha1.MetricTypeHistogram,
+				Quantiles:  []string{"95"},
+			},
+			start, end,
+		)
+		Expect(err).ToNot(Succeed())
+	})
+
+	PIt("should build a query", func() {
+		By("building a query with no args")
+		q1 := &PromQuery{
+			Metric: "foo",
+		}
+
+		Expect(q1.String()).To(Equal("foo"), "failed to create query with no args")
+
+		By("building a complicated query")
+
+		expectedFields := []string{`rpc_durations_seconds_count`, `meter_kind="App"`, `meter_domain="apps.partner.metering.com"`}
+
+		for _, field := range expectedFields {
+			Expect(rpcDurationSecondsQuery).To(ContainSubstring(field))
+		}
+	})
+
+	It("should record the joined query", func() {
+		group, err := NewRecordingRuleGroup(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{
+				Name:         "app-pods",
+				WorkloadType: v1alpha1.WorkloadTypePod,
+				MetricLabels: []v1alpha1.MeterLabelQuery{
+					{Label: "requests", Query: "http_requests_total", Aggregation: "sum"},
+					{Label: "request-latency", MetricType: v1alpha1.MetricTypeHistogram, Quantiles: []string{"0.5"}, Aggregation: "max"},
+				},
+			},
+		)
+		Expect(err).To(Succeed())
+		Expect(group.Name).To(Equal("foons/foo/app-pods"))
+		Expect(group.Rules).To(HaveLen(4))
+
+		rule := group.Rules[0]
+		Expect(rule.Record).To(Equal("meterdef_workload:requests"))
+		Expect(rule.Labels).To(Equal(map[string]string{
+			"meter_def_name":      "foo",
+			"meter_def_namespace": "foons",
+			"meter_def_workload":  "app-pods",
+		}))
+		Expect(rule.Expr.String()).To(Equal(
+			`sum by (pod,namespace) (avg(meterdef_pod_info{meter_def_name="foo",meter_def_namespace="foons"}) without (pod_uid, instance, container, endpoint, job, service) * on(pod,namespace) group_right http_requests_total)`))
+		Expect(group.Rules[1].Record).To(Equal("meterdef_workload:request_latency_p50"))
+
+		By("querying the recorded series")
+		queries, err := NewPromQueries(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{Name: "app-pods", WorkloadType: v1alpha1.WorkloadTypePod},
+			v1alpha1.MeterLabelQuery{Label: "requests", Query: "http_requests_total", Aggregation: "sum"},
+			start, end,
+		)
+		Expect(err).To(Succeed())
+
+		queries[0].Recorded = true
+		Expect(queries[0].String()).To(Equal(
+			`meterdef_workload:requests{meter_def_name="foo",meter_def_namespace="foons",meter_def_workload="app-pods"}`))
+		Expect(queries[0].Expr()).To(Equal(rule.Expr.String()))
+	})
+
+	It("should not record a workload with an invalid metric", func() {
+		_, err := NewRecordingRuleGroup(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{
+				WorkloadType: v1alpha1.WorkloadTypePod,
+				MetricLabels: []v1alpha1.MeterLabelQuery{
+					{Label: "latency", MetricType: v1alpha1.MetricTypeHistogram, Quantiles: []string{"95"}},
+				},
+			},
+		)
+		Expect(err).To(HaveOccurred())
+
+		group, err := NewRecordingRuleGroup(
+			types.NamespacedName{Name: "foo", Namespace: "foons"},
+			v1alpha1.Workload{WorkloadType: v1alpha1.WorkloadTypePod},
+		)
+		Expect(err).To(Succeed())
+		Expect(group.Rules).To(Equal([]monitoringv1.Rule{}))
+	})
+})
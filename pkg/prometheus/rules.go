@@ -0,0 +1,98 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"fmt"
+	"regexp"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// recordedMetricPrefix prefixes the series recorded for meter definitions.
+	recordedMetricPrefix = "meterdef_workload:"
+
+	MeterDefNameLabel      = "meter_def_name"
+	MeterDefNamespaceLabel = "meter_def_namespace"
+	MeterDefWorkloadLabel  = "meter_def_workload"
+)
+
+var invalidMetricNameChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+
+// RecordName is the name of the series recorded for the query.
+func (q *PromQuery) RecordName() string {
+	return recordedMetricPrefix + invalidMetricNameChars.ReplaceAllString(q.Metric, "_")
+}
+
+// RecordedSelector selects the series recorded for the query.
+func (q *PromQuery) RecordedSelector() string {
+	return fmt.Sprintf(`%s{%s="%v",%s="%v",%s="%v"}`,
+		q.RecordName(),
+		MeterDefNameLabel, q.MeterDef.Name,
+		MeterDefNamespaceLabel, q.MeterDef.Namespace,
+		MeterDefWorkloadLabel, q.Workload,
+	)
+}
+
+// RecordingRule records the joined series of the query. The meter
+// definition labels are added so the series of different definitions and
+// workloads recording the same metric can be told apart.
+func (q *PromQuery) RecordingRule() monitoringv1.Rule {
+	return monitoringv1.Rule{
+		Record: q.RecordName(),
+		Expr:   intstr.FromString(q.Expr()),
+		Labels: map[string]string{
+			MeterDefNameLabel:      q.MeterDef.Name,
+			MeterDefNamespaceLabel: q.MeterDef.Namespace,
+			MeterDefWorkloadLabel:  q.Workload,
+		},
+	}
+}
+
+// NewRecordingRuleGroup returns the rule group recording every metric of a
+// meter definition workload.
+func NewRecordingRuleGroup(
+	mdef types.NamespacedName,
+	workload v1alpha1.Workload,
+) (monitoringv1.RuleGroup, error) {
+	group := monitoringv1.RuleGroup{
+		Name:  fmt.Sprintf("%s/%s/%s", mdef.Namespace, mdef.Name, workload.Name),
+		Rules: []monitoringv1.Rule{},
+	}
+
+	for _, metric := range workload.MetricLabels {
+		// a rule is evaluated continuously, it can't record a result that
+		// covers a whole report window
+		if metric.Instant {
+			continue
+		}
+
+		queries, err := buildPromQueries(mdef, workload, metric)
+
+		if err != nil {
+			return group, err
+		}
+
+		for _, query := range queries {
+			group.Rules = append(group.Rules, query.RecordingRule())
+		}
+	}
+
+	return group, nil
+}
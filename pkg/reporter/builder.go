@@ -48,10 +48,18 @@ func (m ReportEnvironment) String() string {
 }
 
 type ReportMetadata struct {
+	SchemaVersion  string                               `json:"schema_version"`
 	ReportID       uuid.UUID                            `json:"report_id"`
 	Source         uuid.UUID                            `json:"source"`
 	SourceMetadata ReportSourceMetadata                 `json:"source_metadata"`
 	ReportSlices   map[ReportSliceKey]ReportSlicesValue `json:"report_slices"`
+
+	// SupersedesReportID is the ID of an earlier report for the same
+	// window that this report replaces.
+	SupersedesReportID string `json:"supersedes_report_id,omitempty"`
+
+	// Coverage is the scrape health of the report window, if checked.
+	Coverage *ReportCoverage `json:"coverage,omitempty"`
 }
 
 type ReportSourceMetadata struct {
@@ -90,6 +98,9 @@ func (sliceKey ReportSliceKey) String() string {
 
 type ReportSlicesValue struct {
 	NumberMetrics int `json:"number_metrics"`
+
+	// SHA256 is the hex encoded digest of the slice file.
+	SHA256 string `json:"sha256,omitempty"`
 }
 
 type MetricsReport struct {
@@ -144,6 +155,10 @@ func kvToMap(keysAndValues []interface{}) (map[string]interface{}, error) {
 		return nil, errors.New("keyAndValues must be a length of 2")
 	}
 
+	if len(keysAndValues) == 0 {
+		return metrics, nil
+	}
+
 	chunks := utils.ChunkBy(keysAndValues, 2)
 
 	for _, chunk := range chunks {
@@ -221,6 +236,14 @@ func (r *ReportMetadata) AddMetricsReport(report *MetricsReport) {
 	}
 }
 
+// AddMetricsReportFile records a written slice with the digest of its file.
+func (r *ReportMetadata) AddMetricsReportFile(report *MetricsReport, data []byte) {
+	r.ReportSlices[report.ReportSliceID] = ReportSlicesValue{
+		NumberMetrics: len(report.Metrics),
+		SHA256:        sha256Hex(data),
+	}
+}
+
 func (r *ReportMetadata) UpdateMetricsReport(report *MetricsReport) {
 	r.ReportSlices[report.ReportSliceID] = ReportSlicesValue{
 		NumberMetrics: len(report.Metrics),
@@ -238,6 +261,7 @@ func NewReportMetadata(
 	metadata ReportSourceMetadata,
 ) *ReportMetadata {
 	return &ReportMetadata{
+		SchemaVersion:  ReportSchemaVersion,
 		ReportID:       uuid.New(),
 		Source:         source,
 		SourceMetadata: metadata,
@@ -76,6 +76,10 @@ func generateCACertPool(files ...string) (*tls.Config, error) {
 	}
 
 	for _, file := range files {
+		if file == "" {
+			continue
+		}
+
 		caCert, err := ioutil.ReadFile(file)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to load cert file")
@@ -35,7 +35,19 @@ type Config struct {
 	TokenFile       string
 	Local           bool
 	Upload          bool
-	UploaderTarget
+	Spool           bool
+	SpoolDirectory  string
+	UploaderTargets UploaderTargets
+	S3Uploader      S3UploaderConfig
+	LocalUploader   LocalUploaderConfig
+	UploadRetry     UploadRetryConfig
+	Signing         SigningConfig
+	DryRun          bool
+	Coverage        CoverageConfig
+
+	// PrometheusFixture is queried instead of the report's Prometheus
+	// service when set.
+	PrometheusFixture string
 }
 
 const (
@@ -56,8 +68,15 @@ func (c *Config) SetDefaults() {
 		c.Retry = ptr.Int(5)
 	}
 
-	if c.UploaderTarget == "" {
-		c.UploaderTarget = UploaderTargetRedHatInsights
+	c.UploadRetry.SetDefaults()
+	c.Coverage.SetDefaults()
+
+	if c.DryRun {
+		c.Upload = false
+	}
+
+	if len(c.UploaderTargets) == 0 {
+		c.UploaderTargets = UploaderTargets{UploaderTargetRedHatInsights}
 	}
 }
 
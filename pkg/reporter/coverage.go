@@ -0,0 +1,338 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+	prom "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/prometheus"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// CoverageConfig configures the scrape health check of the report window.
+type CoverageConfig struct {
+	// Enabled queries the health of the targets the meter definitions
+	// depend on and records the coverage on the report.
+	Enabled bool
+	// MinPercent holds the upload when coverage is below it. Zero never holds.
+	MinPercent float64
+	// Step is the resolution coverage is measured at.
+	Step time.Duration
+}
+
+const defaultCoverageStep = 5 * time.Minute
+
+func (c *CoverageConfig) SetDefaults() {
+	if c.Step <= 0 {
+		c.Step = defaultCoverageStep
+	}
+}
+
+// ShouldHold is true when the coverage is below the configured minimum.
+func (c *CoverageConfig) ShouldHold(coverage *ReportCoverage) bool {
+	return c.MinPercent > 0 && coverage != nil && coverage.Percent < c.MinPercent
+}
+
+// ReportCoverage is how much of the report window Prometheus was scraping
+// the targets the meter definitions depend on.
+type ReportCoverage struct {
+	Percent          float64                   `json:"percent"`
+	Gaps             []CoverageGap             `json:"gaps,omitempty"`
+	MeterDefinitions []MeterDefinitionCoverage `json:"meter_definitions,omitempty"`
+}
+
+// CoverageGap is an interval at least one target was down or not scraped.
+type CoverageGap struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// MeterDefinitionCoverage is the coverage of a single meter definition.
+type MeterDefinitionCoverage struct {
+	Name      string        `json:"name"`
+	Namespace string        `json:"namespace"`
+	Percent   float64       `json:"percent"`
+	Gaps      []CoverageGap `json:"gaps,omitempty"`
+}
+
+// Status converts the coverage to the MeterReport status, keeping at most
+// limit gaps and the limit least covered meter definitions.
+func (c *ReportCoverage) Status(limit int) *marketplacev1alpha1.ReportCoverage {
+	status := &marketplacev1alpha1.ReportCoverage{
+		Percent: formatPercent(c.Percent),
+	}
+
+	for i, gap := range c.Gaps {
+		if i == limit {
+			status.Truncated = true
+			break
+		}
+
+		status.Gaps = append(status.Gaps, marketplacev1alpha1.CoverageGap{
+			Start: metav1.NewTime(gap.Start),
+			End:   metav1.NewTime(gap.End),
+		})
+	}
+
+	mdefs := []MeterDefinitionCoverage{}
+	for _, mdef := range c.MeterDefinitions {
+		if mdef.Percent < 100 {
+			mdefs = append(mdefs, mdef)
+		}
+	}
+
+	sort.SliceStable(mdefs, func(i, j int) bool {
+		return mdefs[i].Percent < mdefs[j].Percent
+	})
+
+	for i, mdef := range mdefs {
+		if i == limit {
+			status.Truncated = true
+			break
+		}
+
+		status.MeterDefinitions = append(status.MeterDefinitions, marketplacev1alpha1.MeterDefinitionCoverage{
+			Name:      mdef.Name,
+			Namespace: mdef.Namespace,
+			Percent:   formatPercent(mdef.Percent),
+		})
+	}
+
+	return status
+}
+
+func formatPercent(percent float64) string {
+	return fmt.Sprintf("%.2f", percent)
+}
+
+// CheckCoverage measures, at every step of the report window, whether the
+// targets each meter definition depends on were up and returned samples.
+// A step without any data, for example while Prometheus was down, counts as
+// a gap.
+func (r *MarketplaceReporter) CheckCoverage(ctx context.Context) (*ReportCoverage, error) {
+	if len(r.meterDefinitions) == 0 {
+		return nil, nil
+	}
+
+	start := r.report.Spec.StartTime.Time
+	end := r.report.Spec.EndTime.Time
+	step := r.Coverage.Step
+
+	steps := coverageSteps(start, end, step)
+
+	if len(steps) == 0 {
+		return nil, errors.Errorf("report window %s to %s is empty", start, end)
+	}
+
+	healthy := make([]bool, len(steps))
+	for i := range healthy {
+		healthy[i] = true
+	}
+
+	coverage := &ReportCoverage{}
+
+	for _, mdef := range r.meterDefinitions {
+		query := coverageQuery(mdef)
+
+		var val model.Value
+
+		err := utils.Retry(func() error {
+			var err error
+			val, _, err = r.queryCoverage(ctx, query, steps[0], steps[len(steps)-1], step)
+
+			if err != nil {
+				return errors.Wrap(err, "error with coverage query")
+			}
+
+			return nil
+		}, *r.Retry)
+
+		if err != nil {
+			return nil, errors.WithDetails(err, "meterdef", mdef.Namespace+"/"+mdef.Name)
+		}
+
+		mdefHealthy := healthySteps(val, steps)
+
+		for i := range healthy {
+			healthy[i] = healthy[i] && mdefHealthy[i]
+		}
+
+		coverage.MeterDefinitions = append(coverage.MeterDefinitions, MeterDefinitionCoverage{
+			Name:      mdef.Name,
+			Namespace: mdef.Namespace,
+			Percent:   coveragePercent(mdefHealthy),
+			Gaps:      coverageGaps(mdefHealthy, steps, step, end),
+		})
+	}
+
+	coverage.Percent = coveragePercent(healthy)
+	coverage.Gaps = coverageGaps(healthy, steps, step, end)
+
+	return coverage, nil
+}
+
+func (r *MarketplaceReporter) queryCoverage(
+	ctx context.Context,
+	query string,
+	start, end time.Time,
+	step time.Duration,
+) (model.Value, v1.Warnings, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, warnings, err := r.api.QueryRange(ctx, query, v1.Range{
+		Start: start,
+		End:   end,
+		Step:  step,
+	})
+
+	if err != nil {
+		logger.Error(err, "querying prometheus", "warnings", warnings)
+		return nil, warnings, toError(err)
+	}
+
+	return result, warnings, nil
+}
+
+// metricStateService is the service scraped for the meterdef_*_info series
+// every metric query joins against.
+const metricStateService = "rhm-metric-state-service"
+
+// targetHealth is 1 for a target that is up and returned samples, else 0.
+func targetHealth(selector string) string {
+	return fmt.Sprintf(`(up%[1]s * (scrape_samples_scraped%[1]s > bool 0))`, selector)
+}
+
+// coverageQuery is the minimum health of the metric-state exporter and of
+// the targets scraping the meter definition's pods and services. It is 0 if
+// any target is unhealthy and has no value if there is no data.
+func coverageQuery(mdef marketplacev1alpha1.MeterDefinition) string {
+	name := types.NamespacedName{Name: mdef.Name, Namespace: mdef.Namespace}
+	targets := []string{targetHealth(fmt.Sprintf(`{service="%s"}`, metricStateService))}
+	seen := map[string]bool{}
+
+	for _, workload := range mdef.Spec.Workloads {
+		info := workloadInfoMetric(workload.WorkloadType)
+
+		if info == "" || seen[info] {
+			continue
+		}
+
+		seen[info] = true
+		joinLabels := (&prom.PromQuery{Type: workload.WorkloadType}).JoinLabels()
+		targets = append(targets, fmt.Sprintf(
+			`%s * on(%s) group_left() max by (%s) (%s{meter_def_name="%v",meter_def_namespace="%v"})`,
+			targetHealth(""), joinLabels, joinLabels, info, name.Name, name.Namespace))
+	}
+
+	return fmt.Sprintf("min(%s)", strings.Join(targets, " or "))
+}
+
+// workloadInfoMetric is the info series of a workload type whose labels
+// match the labels of its scrape targets. Persistent volume claims, nodes
+// and generic resources aren't scrape targets, so only the metric-state
+// exporter is checked for them.
+func workloadInfoMetric(workloadType marketplacev1alpha1.WorkloadType) string {
+	switch workloadType {
+	case marketplacev1alpha1.WorkloadTypePod:
+		return "meterdef_pod_info"
+	case marketplacev1alpha1.WorkloadTypeService, marketplacev1alpha1.WorkloadTypeServiceMonitor:
+		return "meterdef_service_info"
+	default:
+		return ""
+	}
+}
+
+// coverageSteps are the start times of each step of the window.
+func coverageSteps(start, end time.Time, step time.Duration) []time.Time {
+	steps := []time.Time{}
+	for t := start; t.Before(end); t = t.Add(step) {
+		steps = append(steps, t)
+	}
+	return steps
+}
+
+// healthySteps is true for each step with a sample of at least 1.
+func healthySteps(val model.Value, steps []time.Time) []bool {
+	healthy := make([]bool, len(steps))
+	values := map[int64]model.SampleValue{}
+
+	if matrix, ok := val.(model.Matrix); ok {
+		for _, stream := range matrix {
+			for _, pair := range stream.Values {
+				ts := pair.Timestamp.Unix()
+				if v, ok := values[ts]; !ok || pair.Value < v {
+					values[ts] = pair.Value
+				}
+			}
+		}
+	}
+
+	for i, t := range steps {
+		if v, ok := values[t.Unix()]; ok && v >= 1 {
+			healthy[i] = true
+		}
+	}
+
+	return healthy
+}
+
+func coveragePercent(healthy []bool) float64 {
+	count := 0
+	for _, ok := range healthy {
+		if ok {
+			count++
+		}
+	}
+
+	return math.Round(float64(count)/float64(len(healthy))*10000) / 100
+}
+
+// coverageGaps merges consecutive unhealthy steps into intervals.
+func coverageGaps(healthy []bool, steps []time.Time, step time.Duration, end time.Time) []CoverageGap {
+	gaps := []CoverageGap{}
+
+	for i := 0; i < len(steps); i++ {
+		if healthy[i] {
+			continue
+		}
+
+		gap := CoverageGap{Start: steps[i]}
+
+		for i < len(steps) && !healthy[i] {
+			i++
+		}
+
+		gap.End = steps[i-1].Add(step)
+		if gap.End.After(end) {
+			gap.End = end
+		}
+
+		gaps = append(gaps, gap)
+	}
+
+	return gaps
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/common/model"
+	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Coverage", func() {
+	var (
+		start   = time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+		end     = start.Add(time.Hour)
+		mdef    marketplacev1alpha1.MeterDefinition
+		queries []string
+		sut     *MarketplaceReporter
+	)
+
+	// coverageResponse is healthy at every step except a missing 15m to 25m
+	// and a down target at 40m.
+	coverageResponse := func(req *http.Request) *http.Response {
+		Expect(req.ParseForm()).To(Succeed())
+		queries = append(queries, req.Form.Get("query"))
+
+		values := []interface{}{}
+		for t := start; t.Before(end); t = t.Add(5 * time.Minute) {
+			offset := t.Sub(start)
+
+			switch {
+			case offset == 15*time.Minute || offset == 20*time.Minute:
+				continue
+			case offset == 40*time.Minute:
+				values = append(values, []interface{}{t.Unix(), "0"})
+			default:
+				values = append(values, []interface{}{t.Unix(), "1"})
+			}
+		}
+
+		body, err := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data": map[string]interface{}{
+				"resultType": "matrix",
+				"result": []interface{}{
+					map[string]interface{}{"metric": map[string]string{}, "values": values},
+				},
+			},
+		})
+		Expect(err).To(Succeed())
+
+		headers := make(http.Header)
+		headers.Add("content-type", "application/json")
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewBuffer(body)),
+			Header:     headers,
+		}
+	}
+
+	BeforeEach(func() {
+		queries = []string{}
+		mdef = marketplacev1alpha1.MeterDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "robin", Namespace: "marketplace"},
+			Spec: marketplacev1alpha1.MeterDefinitionSpec{
+				Workloads: []marketplacev1alpha1.Workload{
+					{Name: "pods", WorkloadType: marketplacev1alpha1.WorkloadTypePod},
+					{Name: "more-pods", WorkloadType: marketplacev1alpha1.WorkloadTypePod},
+					{Name: "claims", WorkloadType: marketplacev1alpha1.WorkloadTypePVC},
+				},
+			},
+		}
+
+		cfg := &Config{
+			Coverage: CoverageConfig{Enabled: true, MinPercent: 90},
+		}
+		cfg.SetDefaults()
+
+		sut = &MarketplaceReporter{
+			api:    getTestAPI(coverageResponse),
+			Config: cfg,
+			report: &marketplacev1alpha1.MeterReport{
+				Spec: marketplacev1alpha1.MeterReportSpec{
+					StartTime: metav1.NewTime(start),
+					EndTime:   metav1.NewTime(end),
+				},
+			},
+			meterDefinitions: []marketplacev1alpha1.MeterDefinition{mdef},
+		}
+	})
+
+	It("should query the metric-state exporter and the workload targets", func() {
+		Expect(coverageQuery(mdef)).To(Equal(
+			`min((up{service="rhm-metric-state-service"} * (scrape_samples_scraped{service="rhm-metric-state-service"} > bool 0))` +
+				` or (up * (scrape_samples_scraped > bool 0)) * on(pod,namespace) group_left() max by (pod,namespace) (meterdef_pod_info{meter_def_name="robin",meter_def_namespace="marketplace"}))`))
+	})
+
+	It("should record coverage and gaps", func() {
+		coverage, err := sut.CheckCoverage(context.TODO())
+		Expect(err).To(Succeed())
+		Expect(queries).To(HaveLen(1))
+
+		Expect(coverage.Percent).To(Equal(75.0))
+		Expect(coverage.Gaps).To(Equal([]CoverageGap{
+			{Start: start.Add(15 * time.Minute), End: start.Add(25 * time.Minute)},
+			{Start: start.Add(40 * time.Minute), End: start.Add(45 * time.Minute)},
+		}))
+		Expect(coverage.MeterDefinitions).To(HaveLen(1))
+		Expect(coverage.MeterDefinitions[0].Name).To(Equal("robin"))
+		Expect(coverage.MeterDefinitions[0].Percent).To(Equal(75.0))
+
+		Expect(sut.Coverage.ShouldHold(coverage)).To(BeTrue())
+		sut.Coverage.MinPercent = 75
+		Expect(sut.Coverage.ShouldHold(coverage)).To(BeFalse())
+		sut.Coverage.MinPercent = 0
+		Expect(sut.Coverage.ShouldHold(coverage)).To(BeFalse())
+	})
+
+	It("should bound the coverage status", func() {
+		coverage, err := sut.CheckCoverage(context.TODO())
+		Expect(err).To(Succeed())
+
+		status := coverage.Status(1)
+		Expect(status.Percent).To(Equal("75.00"))
+		Expect(status.Gaps).To(HaveLen(1))
+		Expect(status.Gaps[0].Start.Time).To(Equal(start.Add(15 * time.Minute)))
+		Expect(status.MeterDefinitions).To(HaveLen(1))
+		Expect(status.MeterDefinitions[0].Percent).To(Equal("75.00"))
+		Expect(status.Truncated).To(BeTrue())
+	})
+
+	It("should treat a window without data as a gap", func() {
+		steps := coverageSteps(start, end, 5*time.Minute)
+		healthy := healthySteps(model.Matrix{}, steps)
+
+		Expect(coveragePercent(healthy)).To(Equal(0.0))
+		Expect(coverageGaps(healthy, steps, 5*time.Minute, end)).To(Equal([]CoverageGap{
+			{Start: start, End: end},
+		}))
+	})
+})
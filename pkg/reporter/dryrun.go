@@ -0,0 +1,156 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"container/heap"
+	"sort"
+	"strconv"
+
+	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+)
+
+// NewDryRunSummary summarizes the report written to dir. At most limit
+// workload totals and metric values are kept, the largest first, so the
+// summary stays small enough for the MeterReport status. The slice files
+// are read one at a time and only the largest limit rows are held.
+func NewDryRunSummary(dir string, limit int) (*marketplacev1alpha1.DryRunSummary, error) {
+	workloads := map[marketplacev1alpha1.DryRunWorkloadTotal]*ReportSummaryRow{}
+	top := &topRows{}
+	summary := &marketplacev1alpha1.DryRunSummary{}
+
+	_, err := walkReportDir(dir, func(base *MetricBase) error {
+		rows, err := metricRows(base)
+
+		if err != nil {
+			return err
+		}
+
+		summary.MetricCount = summary.MetricCount + 1
+		summary.RowCount = summary.RowCount + len(rows)
+
+		for _, row := range rows {
+			key := marketplacev1alpha1.DryRunWorkloadTotal{
+				Domain:   row.MeterDomain,
+				Kind:     row.MeterKind,
+				Workload: row.Workload,
+				Metric:   row.Metric,
+			}
+
+			workload, ok := workloads[key]
+
+			if !ok {
+				workload = &ReportSummaryRow{}
+				workloads[key] = workload
+			}
+
+			workload.Count = workload.Count + 1
+			workload.Total = workload.Total + row.Value
+
+			switch {
+			case top.Len() < limit:
+				heap.Push(top, row)
+			case limit > 0 && rowLess((*top)[0], row):
+				(*top)[0] = row
+				heap.Fix(top, 0)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]marketplacev1alpha1.DryRunWorkloadTotal, 0, len(workloads))
+	for key := range workloads {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := workloads[keys[i]].Total, workloads[keys[j]].Total
+		if a != b {
+			return a > b
+		}
+		if keys[i].Workload != keys[j].Workload {
+			return keys[i].Workload < keys[j].Workload
+		}
+		return keys[i].Metric < keys[j].Metric
+	})
+
+	summary.Truncated = len(keys) > limit || summary.RowCount > limit
+
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	for _, key := range keys {
+		total := key
+		total.Count = workloads[key].Count
+		total.Total = formatFloat(workloads[key].Total)
+		summary.Workloads = append(summary.Workloads, total)
+	}
+
+	rows := []*ReportMetricRow(*top)
+	sort.Slice(rows, func(i, j int) bool {
+		return rowLess(rows[j], rows[i])
+	})
+
+	for _, row := range rows {
+		summary.TopMetrics = append(summary.TopMetrics, marketplacev1alpha1.DryRunMetric{
+			MetricID:      row.MetricID,
+			IntervalStart: row.IntervalStart,
+			Namespace:     row.Namespace,
+			ResourceName:  row.ResourceName,
+			Metric:        row.Metric,
+			Value:         formatFloat(row.Value),
+		})
+	}
+
+	return summary, nil
+}
+
+// rowLess orders rows by value, with ties going to the later metric id so
+// the smaller id ranks first.
+func rowLess(a, b *ReportMetricRow) bool {
+	switch {
+	case a.Value != b.Value:
+		return a.Value < b.Value
+	case a.MetricID != b.MetricID:
+		return a.MetricID > b.MetricID
+	default:
+		return a.Metric > b.Metric
+	}
+}
+
+// topRows is a min heap of the largest rows seen.
+type topRows []*ReportMetricRow
+
+func (h topRows) Len() int            { return len(h) }
+func (h topRows) Less(i, j int) bool  { return rowLess(h[i], h[j]) }
+func (h topRows) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *topRows) Push(x interface{}) { *h = append(*h, x.(*ReportMetricRow)) }
+
+func (h *topRows) Pop() interface{} {
+	old := *h
+	row := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return row
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
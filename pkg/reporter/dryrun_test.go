@@ -0,0 +1,126 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+)
+
+var _ = Describe("DryRunSummary", func() {
+	var (
+		dir    string
+		report string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "dryrun")
+		Expect(err).To(Succeed())
+
+		report = filepath.Join(dir, "report")
+		sut, err := NewReportWriter(report, NewReportMetadata(uuid.New(), ReportSourceMetadata{}), 10)
+		Expect(err).To(Succeed())
+
+		for i := 0; i < 30; i++ {
+			workload := "small-pods"
+			if i%3 == 0 {
+				workload = "big-pods"
+			}
+
+			key := MetricKey{
+				IntervalStart: "2020-04-19T00:00:00Z",
+				IntervalEnd:   "2020-04-19T01:00:00Z",
+				MeterDomain:   "apps.partner.metering.com",
+				MeterKind:     "App",
+				Workload:      workload,
+				Namespace:     "metering-example-operator",
+				ResourceName:  fmt.Sprintf("pod-%d", i),
+			}
+			key.Init("foo-id")
+
+			base := &MetricBase{Key: key}
+			Expect(base.AddAdditionalLabels()).To(Succeed())
+			Expect(base.AddMetrics("rpc_durations_seconds_count", fmt.Sprintf("%d", i))).To(Succeed())
+			Expect(sut.Write(base)).To(Succeed())
+		}
+
+		_, err = sut.Close()
+		Expect(err).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should keep the largest values within the limit", func() {
+		summary, err := NewDryRunSummary(report, 5)
+		Expect(err).To(Succeed())
+
+		Expect(summary.MetricCount).To(Equal(30))
+		Expect(summary.RowCount).To(Equal(30))
+		Expect(summary.Truncated).To(BeTrue())
+
+		Expect(summary.Workloads).To(Equal([]marketplacev1alpha1.DryRunWorkloadTotal{
+			{
+				Domain: "apps.partner.metering.com", Kind: "App", Workload: "small-pods",
+				Metric: "rpc_durations_seconds_count", Count: 20, Total: "300",
+			},
+			{
+				Domain: "apps.partner.metering.com", Kind: "App", Workload: "big-pods",
+				Metric: "rpc_durations_seconds_count", Count: 10, Total: "135",
+			},
+		}))
+
+		Expect(summary.TopMetrics).To(HaveLen(5))
+		Expect(summary.TopMetrics[0].ResourceName).To(Equal("pod-29"))
+		Expect(summary.TopMetrics[0].Value).To(Equal("29"))
+		Expect(summary.TopMetrics[4].Value).To(Equal("25"))
+	})
+
+	It("should keep the same top metrics as the inspected report", func() {
+		inspected, err := InspectReport(report)
+		Expect(err).To(Succeed())
+		rows, err := inspected.Rows()
+		Expect(err).To(Succeed())
+		sort.SliceStable(rows, func(i, j int) bool {
+			return rows[i].Value > rows[j].Value
+		})
+
+		summary, err := NewDryRunSummary(report, 7)
+		Expect(err).To(Succeed())
+		Expect(summary.TopMetrics).To(HaveLen(7))
+
+		for i, metric := range summary.TopMetrics {
+			Expect(metric.MetricID).To(Equal(rows[i].MetricID))
+			Expect(metric.Value).To(Equal(formatFloat(rows[i].Value)))
+		}
+	})
+
+	It("should not truncate a small report", func() {
+		summary, err := NewDryRunSummary(report, 50)
+		Expect(err).To(Succeed())
+		Expect(summary.Truncated).To(BeFalse())
+		Expect(summary.TopMetrics).To(HaveLen(30))
+	})
+})
@@ -0,0 +1,261 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gotidy/ptr"
+	"github.com/mitchellh/mapstructure"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "rewrite the golden reports in testdata/golden")
+
+const goldenReportFile = "report.golden"
+
+// prometheusRecordURLEnv switches the golden tests to record the responses
+// of the Prometheus at the url.
+const prometheusRecordURLEnv = "PROMETHEUS_RECORD_URL"
+
+// goldenClient replays the responses recorded in the directory, or records
+// them from the Prometheus in PROMETHEUS_RECORD_URL if it is set.
+func goldenClient(directory string) (*PrometheusFixture, api.Client) {
+	if address := os.Getenv(prometheusRecordURLEnv); address != "" {
+		apiClient, err := NewRecordingClient(address, directory)
+		Expect(err).To(Succeed())
+		return &PrometheusFixture{}, apiClient
+	}
+
+	fixture, err := LoadPrometheusFixture(directory)
+	Expect(err).To(Succeed())
+	apiClient, err := NewFixtureClient(fixture)
+	Expect(err).To(Succeed())
+	return fixture, apiClient
+}
+
+// goldenReport is the report's metrics in the slice file format, sorted by
+// metric id.
+func goldenReport(dir string) []byte {
+	report, err := InspectReport(dir)
+	Expect(err).To(Succeed())
+
+	sort.Slice(report.Metrics, func(i, j int) bool {
+		return report.Metrics[i].Key.MetricID < report.Metrics[j].Key.MetricID
+	})
+
+	metrics := []map[string]interface{}{}
+
+	for _, metric := range report.Metrics {
+		result := map[string]interface{}{}
+		Expect(mapstructure.Decode(metric, &result)).To(Succeed())
+		metrics = append(metrics, result)
+	}
+
+	data, err := json.MarshalIndent(metrics, "", "  ")
+	Expect(err).To(Succeed())
+	return append(data, '\n')
+}
+
+var _ = Describe("Golden reports", func() {
+	var (
+		dir      string
+		start, _ = time.Parse(time.RFC3339, "2020-04-19T00:00:00Z")
+		end, _   = time.Parse(time.RFC3339, "2020-04-19T04:00:00Z")
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "golden")
+		Expect(err).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should report queries without a recorded response", func() {
+		fixture, err := LoadPrometheusFixture(dir)
+		Expect(err).To(Succeed())
+		apiClient, err := NewFixtureClient(fixture)
+		Expect(err).To(Succeed())
+		fake := v1.NewAPI(apiClient)
+
+		_, _, err = fake.QueryRange(context.TODO(), "up", v1.Range{Start: start, End: end, Step: time.Hour})
+		Expect(err).To(MatchError(ContainSubstring(`no recorded response for query "up"`)))
+
+		_, _, err = fake.Query(context.TODO(), "up", end)
+		Expect(err).ToNot(Succeed())
+		Expect(fixture.Unmatched()).To(Equal([]string{"up"}))
+	})
+
+	It("should answer queries without a recorded response with the default response", func() {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "default.json"),
+			[]byte(`{"default":true,"status":"success","data":{"resultType":"vector","result":[]}}`), 0600)).To(Succeed())
+
+		fixture, err := LoadPrometheusFixture(dir)
+		Expect(err).To(Succeed())
+		apiClient, err := NewFixtureClient(fixture)
+		Expect(err).To(Succeed())
+
+		_, _, err = v1.NewAPI(apiClient).Query(context.TODO(), "up", end)
+		Expect(err).To(Succeed())
+		Expect(fixture.Unmatched()).To(Equal([]string{"up"}))
+	})
+
+	It("should require a query or the default flag on a recorded response", func() {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "response.json"),
+			[]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`), 0600)).To(Succeed())
+
+		_, err := LoadPrometheusFixture(dir)
+		Expect(err).To(MatchError(ContainSubstring("has no query")))
+	})
+
+	It("should record responses in the fixture format", func() {
+		recorded := filepath.Join(dir, "recorded")
+		fixture := filepath.Join("testdata", "golden", "service")
+
+		files, err := filepath.Glob(filepath.Join(fixture, "*.json"))
+		Expect(err).To(Succeed())
+		response := &RecordedResponse{}
+		data, err := ioutil.ReadFile(files[0])
+		Expect(err).To(Succeed())
+		Expect(json.Unmarshal(data, response)).To(Succeed())
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(&RecordedResponse{Status: response.Status, Data: response.Data})
+		}))
+		defer server.Close()
+
+		apiClient, err := NewRecordingClient(server.URL, recorded)
+		Expect(err).To(Succeed())
+		_, _, err = v1.NewAPI(apiClient).QueryRange(context.TODO(), response.Query, v1.Range{Start: start, End: end, Step: time.Hour})
+		Expect(err).To(Succeed())
+
+		actual, err := ioutil.ReadFile(filepath.Join(recorded, RecordedResponseFile(response.Query)))
+		Expect(err).To(Succeed())
+		Expect(string(actual)).To(Equal(string(data)))
+	})
+
+	// Recordings are made with PROMETHEUS_RECORD_URL set to a Prometheus
+	// with the workload's series; run with -update-golden to accept the
+	// new report.
+	DescribeTable("CollectMetrics",
+		func(name string, workload marketplacev1alpha1.Workload) {
+			testdata := filepath.Join("testdata", "golden", name)
+			fixture, apiClient := goldenClient(testdata)
+
+			cfg := &Config{Retry: ptr.Int(0)}
+			cfg.SetDefaults()
+
+			sut := &MarketplaceReporter{
+				api:    v1.NewAPI(apiClient),
+				Config: cfg,
+				report: &marketplacev1alpha1.MeterReport{
+					Spec: marketplacev1alpha1.MeterReportSpec{
+						StartTime: metav1.NewTime(start),
+						EndTime:   metav1.NewTime(end),
+					},
+				},
+				mktconfig: &marketplacev1alpha1.MarketplaceConfig{
+					Spec: marketplacev1alpha1.MarketplaceConfigSpec{
+						ClusterUUID: "foo-id",
+					},
+				},
+				meterDefinitions: []marketplacev1alpha1.MeterDefinition{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "example-meterdefinition", Namespace: "metering-example-operator"},
+						Spec: marketplacev1alpha1.MeterDefinitionSpec{
+							Group:     "apps.partner.metering.com",
+							Kind:      "App",
+							Workloads: []marketplacev1alpha1.Workload{workload},
+						},
+					},
+				},
+			}
+
+			reportDir := filepath.Join(dir, "report")
+			writer, err := NewReportWriter(reportDir, NewReportMetadata(uuid.New(), ReportSourceMetadata{}), 500)
+			Expect(err).To(Succeed())
+
+			_, err = sut.CollectMetrics(context.TODO(), writer)
+			Expect(fixture.Unmatched()).To(BeEmpty(), "queries without a recorded response")
+			Expect(err).To(Succeed())
+
+			_, err = writer.Close()
+			Expect(err).To(Succeed())
+
+			actual := goldenReport(reportDir)
+			goldenFile := filepath.Join(testdata, goldenReportFile)
+
+			if *updateGolden {
+				Expect(ioutil.WriteFile(goldenFile, actual, 0644)).To(Succeed())
+			}
+
+			expected, err := ioutil.ReadFile(goldenFile)
+			Expect(err).To(Succeed())
+			Expect(string(actual)).To(Equal(string(expected)))
+		},
+		Entry("pod", "pod", marketplacev1alpha1.Workload{
+			Name:         "app-pods",
+			WorkloadType: marketplacev1alpha1.WorkloadTypePod,
+			MetricLabels: []marketplacev1alpha1.MeterLabelQuery{
+				{Label: "rpc_durations_seconds_count", Aggregation: "sum"},
+			},
+		}),
+		Entry("service", "service", marketplacev1alpha1.Workload{
+			Name:         "app-services",
+			WorkloadType: marketplacev1alpha1.WorkloadTypeService,
+			MetricLabels: []marketplacev1alpha1.MeterLabelQuery{
+				{Label: "rpc_durations_seconds_sum", Aggregation: "sum"},
+			},
+		}),
+		Entry("persistentvolumeclaim", "persistentvolumeclaim", marketplacev1alpha1.Workload{
+			Name:         "app-pvcs",
+			WorkloadType: marketplacev1alpha1.WorkloadTypePVC,
+			MetricLabels: []marketplacev1alpha1.MeterLabelQuery{
+				{Label: "kube_persistentvolumeclaim_resource_requests_storage_bytes", Aggregation: "max"},
+			},
+		}),
+		Entry("pod histogram", "pod-histogram", marketplacev1alpha1.Workload{
+			Name:         "app-pods",
+			WorkloadType: marketplacev1alpha1.WorkloadTypePod,
+			MetricLabels: []marketplacev1alpha1.MeterLabelQuery{
+				{
+					Label:      "http_request_duration_seconds",
+					MetricType: marketplacev1alpha1.MetricTypeHistogram,
+					Quantiles:  []string{"0.5", "0.95"},
+				},
+			},
+		}),
+	)
+})
@@ -0,0 +1,382 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"emperror.dev/errors"
+	"github.com/mitchellh/mapstructure"
+)
+
+// InspectedReport is a report read back from a tarball or directory.
+type InspectedReport struct {
+	Metadata *ReportMetadata
+	Metrics  []*MetricBase
+}
+
+// ReportMetricRow is a single metric value of a report.
+type ReportMetricRow struct {
+	MetricKey
+	Metric string
+	Value  float64
+}
+
+// ReportSummaryRow is the total of a metric per domain, kind, namespace
+// and workload.
+type ReportSummaryRow struct {
+	MeterDomain string
+	MeterKind   string
+	Namespace   string
+	Workload    string
+	Metric      string
+	Count       int
+	Total       float64
+}
+
+type ReportDiffChange string
+
+const (
+	ReportDiffAdded   ReportDiffChange = "added"
+	ReportDiffRemoved ReportDiffChange = "removed"
+	ReportDiffChanged ReportDiffChange = "changed"
+)
+
+// ReportDiffRow is a metric value that differs between two reports.
+type ReportDiffRow struct {
+	MetricID string
+	Metric   string
+	Change   ReportDiffChange
+	Left     *ReportMetricRow
+	Right    *ReportMetricRow
+}
+
+// InspectReport reads the report at path, either a tarball or a directory
+// written by the ReportWriter.
+func InspectReport(path string) (*InspectedReport, error) {
+	fi, err := os.Stat(path)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open report")
+	}
+
+	report := &InspectedReport{}
+	collect := func(base *MetricBase) error {
+		report.Metrics = append(report.Metrics, base)
+		return nil
+	}
+
+	if fi.IsDir() {
+		report.Metadata, err = walkReportDir(path, collect)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return report, nil
+	}
+
+	files, err := ReadReportFiles(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	metadataBytes, ok := files[metadataFileName]
+
+	if !ok {
+		return nil, errors.Errorf("%s is missing", metadataFileName)
+	}
+
+	report.Metadata, err = parseReportMetadata(metadataBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range sliceFileNames(report.Metadata) {
+		data, ok := files[name]
+
+		if !ok {
+			return nil, errors.Errorf("slice %s is missing", name)
+		}
+
+		err = decodeReportSlice(name, data, collect)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// walkReportDir reads the report written to dir one slice file at a time
+// and calls fn with each metric, so the whole report is never in memory.
+func walkReportDir(dir string, fn func(*MetricBase) error) (*ReportMetadata, error) {
+	metadataBytes, err := ioutil.ReadFile(filepath.Join(dir, metadataFileName))
+
+	if os.IsNotExist(err) {
+		return nil, errors.Errorf("%s is missing", metadataFileName)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read report file")
+	}
+
+	metadata, err := parseReportMetadata(metadataBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range sliceFileNames(metadata) {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("slice %s is missing", name)
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read report file")
+		}
+
+		err = decodeReportSlice(name, data, fn)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return metadata, nil
+}
+
+func parseReportMetadata(data []byte) (*ReportMetadata, error) {
+	metadata := &ReportMetadata{}
+	err := json.Unmarshal(data, metadata)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", metadataFileName)
+	}
+
+	return metadata, nil
+}
+
+// sliceFileNames are the slice files of the report, sorted by slice id.
+func sliceFileNames(metadata *ReportMetadata) []string {
+	names := make([]string, 0, len(metadata.ReportSlices))
+	for sliceID := range metadata.ReportSlices {
+		names = append(names, fmt.Sprintf("%s.json", sliceID.String()))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func decodeReportSlice(name string, data []byte, fn func(*MetricBase) error) error {
+	metricsReport := &MetricsReport{}
+	err := json.Unmarshal(data, metricsReport)
+
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse slice %s", name)
+	}
+
+	for _, metric := range metricsReport.Metrics {
+		base := &MetricBase{}
+		err = mapstructure.Decode(metric, base)
+
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode metric in slice %s", name)
+		}
+
+		err = fn(base)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rows flattens the report to one row per metric value, sorted by metric
+// id and metric name.
+func (r *InspectedReport) Rows() ([]*ReportMetricRow, error) {
+	rows := []*ReportMetricRow{}
+
+	for _, base := range r.Metrics {
+		baseRows, err := metricRows(base)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rows = append(rows, baseRows...)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].MetricID != rows[j].MetricID {
+			return rows[i].MetricID < rows[j].MetricID
+		}
+		return rows[i].Metric < rows[j].Metric
+	})
+
+	return rows, nil
+}
+
+// Summary totals the metric values per domain, kind, namespace and workload.
+func (r *InspectedReport) Summary() ([]*ReportSummaryRow, error) {
+	rows, err := r.Rows()
+
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := map[ReportSummaryRow]*ReportSummaryRow{}
+
+	for _, row := range rows {
+		key := ReportSummaryRow{
+			MeterDomain: row.MeterDomain,
+			MeterKind:   row.MeterKind,
+			Namespace:   row.Namespace,
+			Workload:    row.Workload,
+			Metric:      row.Metric,
+		}
+
+		summary, ok := summaries[key]
+
+		if !ok {
+			summary = &key
+			summaries[key] = summary
+		}
+
+		summary.Count = summary.Count + 1
+		summary.Total = summary.Total + row.Value
+	}
+
+	results := make([]*ReportSummaryRow, 0, len(summaries))
+	for _, summary := range summaries {
+		results = append(results, summary)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		switch {
+		case a.MeterDomain != b.MeterDomain:
+			return a.MeterDomain < b.MeterDomain
+		case a.MeterKind != b.MeterKind:
+			return a.MeterKind < b.MeterKind
+		case a.Namespace != b.Namespace:
+			return a.Namespace < b.Namespace
+		case a.Workload != b.Workload:
+			return a.Workload < b.Workload
+		default:
+			return a.Metric < b.Metric
+		}
+	})
+
+	return results, nil
+}
+
+// DiffReports compares two reports by metric id and metric name.
+func DiffReports(left, right *InspectedReport) ([]*ReportDiffRow, error) {
+	leftRows, err := left.Rows()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rightRows, err := right.Rows()
+
+	if err != nil {
+		return nil, err
+	}
+
+	type diffKey struct {
+		metricID, metric string
+	}
+
+	rightByKey := map[diffKey]*ReportMetricRow{}
+	for _, row := range rightRows {
+		rightByKey[diffKey{row.MetricID, row.Metric}] = row
+	}
+
+	diffs := []*ReportDiffRow{}
+
+	for _, row := range leftRows {
+		key := diffKey{row.MetricID, row.Metric}
+		other, ok := rightByKey[key]
+		delete(rightByKey, key)
+
+		switch {
+		case !ok:
+			diffs = append(diffs, &ReportDiffRow{MetricID: row.MetricID, Metric: row.Metric, Change: ReportDiffRemoved, Left: row})
+		case other.Value != row.Value:
+			diffs = append(diffs, &ReportDiffRow{MetricID: row.MetricID, Metric: row.Metric, Change: ReportDiffChanged, Left: row, Right: other})
+		}
+	}
+
+	for _, row := range rightRows {
+		if _, ok := rightByKey[diffKey{row.MetricID, row.Metric}]; ok {
+			diffs = append(diffs, &ReportDiffRow{MetricID: row.MetricID, Metric: row.Metric, Change: ReportDiffAdded, Right: row})
+		}
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].MetricID != diffs[j].MetricID {
+			return diffs[i].MetricID < diffs[j].MetricID
+		}
+		return diffs[i].Metric < diffs[j].Metric
+	})
+
+	return diffs, nil
+}
+
+// metricRows is one row per metric value of the metric.
+func metricRows(base *MetricBase) ([]*ReportMetricRow, error) {
+	rows := make([]*ReportMetricRow, 0, len(base.Metrics))
+
+	for name, value := range base.Metrics {
+		floatValue, err := metricValue(value)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "metric %s of %s", name, base.Key.MetricID)
+		}
+
+		rows = append(rows, &ReportMetricRow{
+			MetricKey: base.Key,
+			Metric:    name,
+			Value:     floatValue,
+		})
+	}
+
+	return rows, nil
+}
+
+func metricValue(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, errors.Errorf("unsupported value type %T", value)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Inspect", func() {
+	var dir string
+
+	newMetric := func(namespace, pod, value string) *MetricBase {
+		key := MetricKey{
+			ReportPeriodStart: "2020-04-19T00:00:00Z",
+			ReportPeriodEnd:   "2020-04-20T00:00:00Z",
+			IntervalStart:     "2020-04-19T00:00:00Z",
+			IntervalEnd:       "2020-04-19T01:00:00Z",
+			MeterDomain:       "apps.partner.metering.com",
+			MeterKind:         "App",
+			Workload:          "app-pods",
+			Namespace:         namespace,
+			ResourceName:      pod,
+		}
+		key.Init("foo-id")
+
+		base := &MetricBase{Key: key}
+		Expect(base.AddAdditionalLabels("pod", pod)).To(Succeed())
+		Expect(base.AddMetrics("rpc_durations_seconds_count", value)).To(Succeed())
+		return base
+	}
+
+	writeReport := func(name string, metrics ...*MetricBase) string {
+		path := filepath.Join(dir, name)
+		sut, err := NewReportWriter(path, NewReportMetadata(uuid.New(), ReportSourceMetadata{}), 2)
+		Expect(err).To(Succeed())
+		Expect(sut.Write(metrics...)).To(Succeed())
+		_, err = sut.Close()
+		Expect(err).To(Succeed())
+		return path
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "inspect")
+		Expect(err).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should summarize a report tarball", func() {
+		path := writeReport("report",
+			newMetric("ns-a", "pod-1", "10"),
+			newMetric("ns-a", "pod-2", "2.5"),
+			newMetric("ns-b", "pod-3", "1"),
+		)
+		tarball := filepath.Join(dir, "report.tar.gz")
+		Expect(TargzFolder(path, tarball)).To(Succeed())
+
+		report, err := InspectReport(tarball)
+		Expect(err).To(Succeed())
+		Expect(report.Metrics).To(HaveLen(3))
+
+		summary, err := report.Summary()
+		Expect(err).To(Succeed())
+		Expect(summary).To(Equal([]*ReportSummaryRow{
+			{
+				MeterDomain: "apps.partner.metering.com", MeterKind: "App", Namespace: "ns-a", Workload: "app-pods",
+				Metric: "rpc_durations_seconds_count", Count: 2, Total: 12.5,
+			},
+			{
+				MeterDomain: "apps.partner.metering.com", MeterKind: "App", Namespace: "ns-b", Workload: "app-pods",
+				Metric: "rpc_durations_seconds_count", Count: 1, Total: 1,
+			},
+		}))
+	})
+
+	It("should diff two reports by metric id", func() {
+		left, err := InspectReport(writeReport("left",
+			newMetric("ns-a", "pod-1", "10"),
+			newMetric("ns-a", "pod-2", "2"),
+		))
+		Expect(err).To(Succeed())
+
+		right, err := InspectReport(writeReport("right",
+			newMetric("ns-a", "pod-1", "10"),
+			newMetric("ns-a", "pod-2", "3"),
+			newMetric("ns-b", "pod-3", "1"),
+		))
+		Expect(err).To(Succeed())
+
+		diffs, err := DiffReports(left, right)
+		Expect(err).To(Succeed())
+		Expect(diffs).To(HaveLen(2))
+
+		changes := map[string]ReportDiffChange{}
+		for _, diff := range diffs {
+			row := diff.Right
+			changes[row.ResourceName] = diff.Change
+
+			if diff.Change == ReportDiffChanged {
+				Expect(diff.Left.Value).To(Equal(2.0))
+				Expect(diff.Right.Value).To(Equal(3.0))
+			}
+		}
+
+		Expect(changes).To(Equal(map[string]ReportDiffChange{
+			"pod-2": ReportDiffChanged,
+			"pod-3": ReportDiffAdded,
+		}))
+
+		diffs, err = DiffReports(right, left)
+		Expect(err).To(Succeed())
+		Expect(diffs).To(HaveLen(2))
+		Expect(diffs[0].Change).To(Or(Equal(ReportDiffRemoved), Equal(ReportDiffChanged)))
+		Expect(diffs[1].Change).To(Or(Equal(ReportDiffRemoved), Equal(ReportDiffChanged)))
+	})
+})
@@ -0,0 +1,222 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/api"
+	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// OfflineConfig is the input of a report run without a cluster. Either
+// the PrometheusURL or the PrometheusFixture is queried.
+type OfflineConfig struct {
+	PrometheusURL        string
+	PrometheusFixture    string
+	MeterDefinitionFiles []string
+	ClusterID            string
+	AccountID            string
+	Environment          ReportEnvironment
+	StartTime            time.Time
+	EndTime              time.Time
+	SigningKey           []byte
+}
+
+func (c *OfflineConfig) validate() error {
+	switch {
+	case c.PrometheusURL == "" && c.PrometheusFixture == "":
+		return errors.New("a prometheus url or fixture is required")
+	case c.PrometheusURL != "" && c.PrometheusFixture != "":
+		return errors.New("only one of prometheus url or fixture can be set")
+	case len(c.MeterDefinitionFiles) == 0:
+		return errors.New("at least one meter definition file is required")
+	case c.ClusterID == "":
+		return errors.New("cluster id is required")
+	case !c.EndTime.After(c.StartTime):
+		return errors.New("end time must be after the start time")
+	}
+
+	return nil
+}
+
+// OfflineResult is the report written by RunOffline.
+type OfflineResult struct {
+	ReportID string
+	File     string
+	Count    int
+	Errors   []error
+}
+
+// RunOffline writes a report tarball from meter definition files and a
+// Prometheus url or fixture, without a MeterReport, MarketplaceConfig or
+// any other cluster resource. Nothing is uploaded.
+func RunOffline(ctx context.Context, config *Config, offline *OfflineConfig) (*OfflineResult, error) {
+	err := offline.validate()
+
+	if err != nil {
+		return nil, err
+	}
+
+	meterDefinitions, err := LoadMeterDefinitions(offline.MeterDefinitionFiles...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var apiClient api.Client
+
+	if offline.PrometheusFixture != "" {
+		fixture, err := LoadPrometheusFixture(offline.PrometheusFixture)
+
+		if err != nil {
+			return nil, err
+		}
+
+		apiClient, err = NewFixtureClient(fixture)
+
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var token []byte
+
+		if config.TokenFile != "" {
+			token, err = ioutil.ReadFile(config.TokenFile)
+
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to read prometheus token")
+			}
+		}
+
+		apiClient, err = NewSecureClient(&PrometheusSecureClientConfig{
+			Address:        offline.PrometheusURL,
+			Token:          strings.TrimSpace(string(token)),
+			ServerCertFile: config.CaFile,
+		})
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create prometheus client")
+		}
+	}
+
+	var signer *ReportSigner
+
+	if len(offline.SigningKey) != 0 {
+		signer, err = NewReportSigner(offline.SigningKey)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report := &marketplacev1alpha1.MeterReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "offline",
+		},
+		Spec: marketplacev1alpha1.MeterReportSpec{
+			StartTime:        metav1.NewTime(offline.StartTime),
+			EndTime:          metav1.NewTime(offline.EndTime),
+			MeterDefinitions: meterDefinitions,
+		},
+	}
+
+	mktconfig := &marketplacev1alpha1.MarketplaceConfig{
+		Spec: marketplacev1alpha1.MarketplaceConfigSpec{
+			ClusterUUID:  offline.ClusterID,
+			RhmAccountID: offline.AccountID,
+		},
+	}
+
+	if offline.Environment == ReportSandboxEnv {
+		mktconfig.Annotations = map[string]string{
+			"marketplace.redhat.com/environment": ReportSandboxEnv.String(),
+		}
+	}
+
+	reporter, err := NewMarketplaceReporter(config, nil, report, mktconfig, meterDefinitions, nil, apiClient)
+
+	if err != nil {
+		return nil, err
+	}
+
+	collected, err := collectReport(ctx, reporter, uuid.New(), signer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &OfflineResult{
+		ReportID: collected.ReportID,
+		File:     filepath.Clean(collected.File),
+		Count:    collected.Count,
+		Errors:   collected.Errors,
+	}, nil
+}
+
+// LoadMeterDefinitions reads MeterDefinitions from YAML or JSON files.
+// A file may hold several documents separated by ---.
+func LoadMeterDefinitions(files ...string) ([]marketplacev1alpha1.MeterDefinition, error) {
+	meterDefinitions := []marketplacev1alpha1.MeterDefinition{}
+
+	for _, file := range files {
+		f, err := os.Open(file)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open meter definition")
+		}
+
+		decoder := yaml.NewYAMLOrJSONDecoder(f, 4096)
+
+		for {
+			meterDefinition := marketplacev1alpha1.MeterDefinition{}
+			err = decoder.Decode(&meterDefinition)
+
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				f.Close()
+				return nil, errors.Wrapf(err, "failed to parse meter definition %s", file)
+			}
+
+			if meterDefinition.Kind == "" && meterDefinition.Name == "" {
+				continue
+			}
+
+			if meterDefinition.Kind != "MeterDefinition" {
+				f.Close()
+				return nil, errors.Errorf("%s has kind %s, expected MeterDefinition", file, meterDefinition.Kind)
+			}
+
+			meterDefinitions = append(meterDefinitions, meterDefinition)
+		}
+
+		f.Close()
+	}
+
+	return meterDefinitions, nil
+}
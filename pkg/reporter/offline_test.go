@@ -0,0 +1,143 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const offlineMeterDefinitions = `
+apiVersion: marketplace.redhat.com/v1alpha1
+kind: MeterDefinition
+metadata:
+  name: example-meterdefinition
+  namespace: metering-example-operator
+spec:
+  meterGroup: apps.partner.metering.com
+  meterKind: App
+  workloadVertexType: OperatorGroup
+  workloads:
+    - name: app-pods
+      type: Pod
+      ownerCRD:
+        apiVersion: partner.metering.com/v1alpha1
+        kind: App
+      metricLabels:
+        - label: rpc_durations_seconds_count
+          aggregation: sum
+---
+apiVersion: marketplace.redhat.com/v1alpha1
+kind: MeterDefinition
+metadata:
+  name: example-meterdefinition-2
+  namespace: metering-example-operator
+spec:
+  meterGroup: apps.partner.metering.com
+  meterKind: App2
+  workloadVertexType: OperatorGroup
+  workloads:
+    - name: app-pods
+      type: Pod
+      ownerCRD:
+        apiVersion: partner.metering.com/v1alpha1
+        kind: App
+      metricLabels:
+        - label: rpc_durations_seconds_sum
+          aggregation: sum
+`
+
+var _ = Describe("RunOffline", func() {
+	var (
+		dir     string
+		config  *Config
+		offline *OfflineConfig
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "offline")
+		Expect(err).To(Succeed())
+
+		meterDefinitionFile := filepath.Join(dir, "meterdefinitions.yaml")
+		Expect(ioutil.WriteFile(meterDefinitionFile, []byte(offlineMeterDefinitions), 0600)).To(Succeed())
+
+		config = &Config{OutputDirectory: dir}
+		config.SetDefaults()
+
+		start, _ := time.Parse(time.RFC3339, "2020-04-19T00:00:00Z")
+		offline = &OfflineConfig{
+			PrometheusFixture:    "../../test/mockresponses/prometheus-query-range.json",
+			MeterDefinitionFiles: []string{meterDefinitionFile},
+			ClusterID:            "foo-id",
+			AccountID:            "bar-id",
+			Environment:          ReportSandboxEnv,
+			StartTime:            start,
+			EndTime:              start.Add(24 * time.Hour),
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should load every meter definition of a file", func() {
+		meterDefinitions, err := LoadMeterDefinitions(offline.MeterDefinitionFiles...)
+		Expect(err).To(Succeed())
+		Expect(meterDefinitions).To(HaveLen(2))
+		Expect(meterDefinitions[1].Name).To(Equal("example-meterdefinition-2"))
+		Expect(meterDefinitions[1].Spec.Workloads[0].MetricLabels[0].Label).To(Equal("rpc_durations_seconds_sum"))
+	})
+
+	It("should write a report from a fixture", func() {
+		result, err := RunOffline(context.TODO(), config, offline)
+		Expect(err).To(Succeed())
+		Expect(result.Errors).To(BeEmpty())
+		Expect(result.Count).To(BeNumerically(">", 0))
+
+		verified, err := VerifyReport(result.File, nil)
+		Expect(err).To(Succeed())
+		Expect(verified.ReportID).To(Equal(result.ReportID))
+		Expect(verified.Metrics).To(Equal(result.Count))
+
+		report, err := InspectReport(result.File)
+		Expect(err).To(Succeed())
+		Expect(report.Metadata.SourceMetadata.RhmClusterID).To(Equal("foo-id"))
+		Expect(report.Metadata.SourceMetadata.RhmAccountID).To(Equal("bar-id"))
+		Expect(report.Metadata.SourceMetadata.RhmEnvironment).To(Equal(ReportSandboxEnv))
+	})
+
+	It("should fail queries without a recorded response", func() {
+		fixture := filepath.Join(dir, "fixture.json")
+		Expect(ioutil.WriteFile(fixture, []byte(`{"query":"up","status":"success","data":{"resultType":"vector","result":[]}}`), 0600)).To(Succeed())
+		offline.PrometheusFixture = fixture
+
+		_, err := RunOffline(context.TODO(), config, offline)
+		Expect(err).ToNot(Succeed())
+		Expect(err.Error()).To(ContainSubstring("no recorded response"))
+	})
+
+	It("should require a prometheus", func() {
+		offline.PrometheusFixture = ""
+		_, err := RunOffline(context.TODO(), config, offline)
+		Expect(err).To(MatchError("a prometheus url or fixture is required"))
+	})
+})
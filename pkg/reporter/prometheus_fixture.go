@@ -0,0 +1,383 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/prometheus/client_golang/api"
+	"github.com/prometheus/common/model"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils"
+)
+
+// RecordedResponse is a Prometheus API response with the query it
+// answers. A response captured from the Prometheus API only needs the
+// query added to be replayed. A default response has no query and answers
+// every query that has no recorded response of its own; those queries are
+// still reported by Unmatched.
+type RecordedResponse struct {
+	Query   string          `json:"query,omitempty"`
+	Default bool            `json:"default,omitempty"`
+	Status  string          `json:"status"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// PrometheusFixture is a set of recorded Prometheus API responses, read
+// from a recorded response file or a directory of them. Queries without a
+// recorded response fail, or get the default response if there is one, and
+// are kept for Unmatched.
+type PrometheusFixture struct {
+	mutex     sync.Mutex
+	responses map[string][]byte
+	fallback  []byte
+	unmatched []string
+}
+
+// LoadPrometheusFixture reads the recorded response file, or every
+// recorded response file of the directory, at path.
+func LoadPrometheusFixture(path string) (*PrometheusFixture, error) {
+	fi, err := os.Stat(path)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read prometheus fixture")
+	}
+
+	files := []string{path}
+
+	if fi.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.json"))
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list recorded responses")
+		}
+	}
+
+	fixture := &PrometheusFixture{responses: map[string][]byte{}}
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read recorded response")
+		}
+
+		response := &RecordedResponse{}
+		err = json.Unmarshal(data, response)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse recorded response %s", file)
+		}
+
+		switch {
+		case response.Default && response.Query != "":
+			return nil, errors.Errorf("default recorded response %s has a query", file)
+		case response.Default:
+			fixture.fallback = data
+			continue
+		case response.Query == "":
+			return nil, errors.Errorf("recorded response %s has no query", file)
+		}
+
+		fixture.responses[response.Query] = data
+	}
+
+	return fixture, nil
+}
+
+// Response returns the recorded response for the query.
+func (f *PrometheusFixture) Response(query string) ([]byte, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if data, ok := f.responses[query]; ok {
+		return data, true
+	}
+
+	if !utils.Contains(f.unmatched, query) {
+		f.unmatched = append(f.unmatched, query)
+	}
+
+	return f.fallback, f.fallback != nil
+}
+
+// Unmatched returns the queries that had no recorded response of their own,
+// sorted.
+func (f *PrometheusFixture) Unmatched() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	unmatched := append([]string{}, f.unmatched...)
+	sort.Strings(unmatched)
+	return unmatched
+}
+
+// NewFixtureClient returns a Prometheus client that answers queries from
+// the fixture instead of a server.
+func NewFixtureClient(fixture *PrometheusFixture) (api.Client, error) {
+	return api.NewClient(api.Config{
+		Address:      "http://prometheus.fixture",
+		RoundTripper: &fixtureRoundTripper{fixture: fixture},
+	})
+}
+
+// NewRecordingClient returns a Prometheus client that queries the
+// Prometheus at address and writes each response to the directory as a
+// recorded response file.
+func NewRecordingClient(address, directory string) (api.Client, error) {
+	err := os.MkdirAll(directory, 0755)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create recording directory")
+	}
+
+	return api.NewClient(api.Config{
+		Address: address,
+		RoundTripper: &recordingRoundTripper{
+			directory: directory,
+			next:      api.DefaultRoundTripper,
+		},
+	})
+}
+
+// RecordedResponseFile is the name of the file a query's response is
+// recorded to.
+func RecordedResponseFile(query string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(query)))[:16] + ".json"
+}
+
+type fixtureRoundTripper struct {
+	fixture *PrometheusFixture
+}
+
+func (f *fixtureRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	form, err := requestForm(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := form.Get("query")
+	status := http.StatusOK
+	body, ok := f.fixture.Response(query)
+
+	if ok {
+		body, err = trimToRange(body, form.Get("start"), form.Get("end"))
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !ok {
+		status = http.StatusBadRequest
+		body, _ = json.Marshal(map[string]string{
+			"status":    "error",
+			"errorType": "bad_data",
+			"error":     fmt.Sprintf("no recorded response for query %q", query),
+		})
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+type recordingRoundTripper struct {
+	directory string
+	next      http.RoundTripper
+}
+
+func (r *recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	query, err := requestQuery(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.next.RoundTrip(req)
+
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read prometheus response")
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	response := &RecordedResponse{}
+	err = json.Unmarshal(body, response)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse prometheus response")
+	}
+
+	response.Query = query
+	data, err := json.MarshalIndent(response, "", "  ")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode recorded response")
+	}
+
+	err = ioutil.WriteFile(
+		filepath.Join(r.directory, RecordedResponseFile(query)), append(data, '\n'), 0644)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to write recorded response")
+	}
+
+	return resp, nil
+}
+
+// requestQuery is the query parameter of a Prometheus API request.
+func requestQuery(req *http.Request) (string, error) {
+	form, err := requestForm(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	return form.Get("query"), nil
+}
+
+// requestForm is the parameters of a Prometheus API request, sent in the url
+// or the form body. The body is restored for the next reader.
+func requestForm(req *http.Request) (url.Values, error) {
+	if form := req.URL.Query(); form.Get("query") != "" || req.Body == nil {
+		return form, nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read prometheus request")
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	form, err := url.ParseQuery(string(body))
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse prometheus request")
+	}
+
+	return form, nil
+}
+
+// trimToRange drops the samples of a recorded matrix outside the range of
+// a range query, so a response recorded for a whole report window answers
+// the query of each chunk of the window.
+func trimToRange(body []byte, start, end string) ([]byte, error) {
+	if start == "" || end == "" {
+		return body, nil
+	}
+
+	response := &RecordedResponse{}
+	data := &struct {
+		ResultType model.ValueType `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	}{}
+
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, errors.Wrap(err, "failed to parse recorded response")
+	}
+
+	if err := json.Unmarshal(response.Data, data); err != nil || data.ResultType != model.ValMatrix {
+		return body, nil
+	}
+
+	startTime, err := parseAPITime(start)
+
+	if err != nil {
+		return nil, err
+	}
+
+	endTime, err := parseAPITime(end)
+
+	if err != nil {
+		return nil, err
+	}
+
+	matrix := model.Matrix{}
+
+	if err := json.Unmarshal(data.Result, &matrix); err != nil {
+		return nil, errors.Wrap(err, "failed to parse recorded matrix")
+	}
+
+	trimmed := model.Matrix{}
+
+	for _, series := range matrix {
+		values := []model.SamplePair{}
+
+		for _, pair := range series.Values {
+			if !pair.Timestamp.Before(startTime) && !pair.Timestamp.After(endTime) {
+				values = append(values, pair)
+			}
+		}
+
+		if len(values) > 0 {
+			trimmed = append(trimmed, &model.SampleStream{Metric: series.Metric, Values: values})
+		}
+	}
+
+	data.Result, err = json.Marshal(trimmed)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode recorded matrix")
+	}
+
+	response.Data, err = json.Marshal(data)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode recorded response")
+	}
+
+	return json.Marshal(response)
+}
+
+// parseAPITime parses a time sent to the Prometheus API, in seconds since
+// the epoch.
+func parseAPITime(value string) (model.Time, error) {
+	seconds, err := strconv.ParseFloat(value, 64)
+
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse prometheus time %q", value)
+	}
+
+	return model.TimeFromUnixNano(int64(seconds * float64(time.Second))), nil
+}
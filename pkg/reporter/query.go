@@ -16,103 +16,44 @@ package reporter
 
 import (
 	"context"
-	"fmt"
 	"strings"
 	"time"
 
 	"emperror.dev/errors"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
-	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
-	"k8s.io/apimachinery/pkg/types"
+	prom "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/prometheus"
 )
 
-type PromQuery struct {
-	Type          v1alpha1.WorkloadType
-	MeterDef      types.NamespacedName
-	Metric        string
-	Query         string
-	Start, End    time.Time
-	Step          time.Duration
-	Time          string
-	AggregateFunc string
-	AggregateBy   []string
-}
+func (r *MarketplaceReporter) queryRange(query *prom.PromQuery) (model.Value, v1.Warnings, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-func (q *PromQuery) makeLeftSide() string {
-	switch q.Type {
-	case v1alpha1.WorkloadTypePVC:
-		return fmt.Sprintf(`avg(meterdef_persistentvolumeclaim_info{meter_def_name="%v",meter_def_namespace="%v",phase="Bound"}) without (instance, container, endpoint, job, service)`, q.MeterDef.Name, q.MeterDef.Namespace)
-	case v1alpha1.WorkloadTypePod:
-		return fmt.Sprintf(`avg(meterdef_pod_info{meter_def_name="%v",meter_def_namespace="%v"}) without (pod_uid, instance, container, endpoint, job, service)`, q.MeterDef.Name, q.MeterDef.Namespace)
-	case v1alpha1.WorkloadTypeService:
-		// Service and service monitor are handled the same
-		fallthrough
-	case v1alpha1.WorkloadTypeServiceMonitor:
-		return fmt.Sprintf(`avg(meterdef_service_info{meter_def_name="%v",meter_def_namespace="%v"}) without (pod_uid, instance, container, endpoint, job, pod)`, q.MeterDef.Name, q.MeterDef.Namespace)
-	default:
-		return "NOTSUPPORTED"
+	timeRange := v1.Range{
+		Start: query.Start,
+		End:   query.End,
+		Step:  query.Step,
 	}
-}
 
-func (q *PromQuery) makeJoin() string {
-	switch q.Type {
-	case v1alpha1.WorkloadTypePVC:
-		return "* on(persistentvolumeclaim,namespace) group_right"
-	case v1alpha1.WorkloadTypePod:
-		return "* on(pod,namespace) group_right"
-	case v1alpha1.WorkloadTypeService:
-		fallthrough
-	case v1alpha1.WorkloadTypeServiceMonitor:
-		return "* on(service,namespace) group_right"
-	default:
-		return "NOTSUPPORTED"
-	}
-}
+	result, warnings, err := r.api.QueryRange(ctx, query.String(), timeRange)
 
-func (q *PromQuery) makeAggregateBy() string {
-	switch q.Type {
-	case v1alpha1.WorkloadTypePVC:
-		return fmt.Sprintf(`%v by (persistentvolumeclaim,namespace)`, q.AggregateFunc)
-	case v1alpha1.WorkloadTypePod:
-		return fmt.Sprintf(`%v by (pod,namespace)`, q.AggregateFunc)
-	case v1alpha1.WorkloadTypeService:
-		fallthrough
-	case v1alpha1.WorkloadTypeServiceMonitor:
-		return fmt.Sprintf(`%v by (service,namespace)`, q.AggregateFunc)
-	default:
-		return "NOTSUPPORTED"
+	if err != nil {
+		logger.Error(err, "querying prometheus", "warnings", warnings)
+		return nil, warnings, toError(err)
 	}
-}
-
-func (q *PromQuery) String() string {
-	aggregate := q.makeAggregateBy()
-	leftSide := q.makeLeftSide()
-	join := q.makeJoin()
-
-	var query string
-	if q.Query != "" {
-		query = q.Query
-	} else {
-		query = fmt.Sprintf("%s{}", q.Metric)
+	if len(warnings) > 0 {
+		logger.Info("warnings", "warnings", warnings)
 	}
 
-	return fmt.Sprintf(
-		`%v (%v %v %v)`, aggregate, leftSide, join, query,
-	)
+	return result, warnings, nil
 }
 
-func (r *MarketplaceReporter) queryRange(query *PromQuery) (model.Value, v1.Warnings, error) {
+// queryInstant evaluates the query at the end of its window.
+func (r *MarketplaceReporter) queryInstant(query *prom.PromQuery) (model.Value, v1.Warnings, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	timeRange := v1.Range{
-		Start: query.Start,
-		End:   query.End,
-		Step:  query.Step,
-	}
-
-	result, warnings, err := r.api.QueryRange(ctx, query.String(), timeRange)
+	result, warnings, err := r.api.Query(ctx, query.String(), query.End)
 
 	if err != nil {
 		logger.Error(err, "querying prometheus", "warnings", warnings)
@@ -18,11 +18,10 @@ import (
 	"time"
 
 	"github.com/prometheus/common/model"
-	"k8s.io/apimachinery/pkg/types"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
-	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+	prom "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/prometheus"
 )
 
 var _ = Describe("Query", func() {
@@ -32,11 +31,11 @@ var _ = Describe("Query", func() {
 		start, _ = time.Parse(time.RFC3339, "2020-04-19T13:00:00Z")
 		end, _   = time.Parse(time.RFC3339, "2020-04-19T16:00:00Z")
 
-		rpcDurationSecondsQuery *PromQuery
+		rpcDurationSecondsQuery *prom.PromQuery
 	)
 
 	BeforeEach(func() {
-		rpcDurationSecondsQuery = &PromQuery{
+		rpcDurationSecondsQuery = &prom.PromQuery{
 			Metric: "rpc_durations_seconds_count",
 			Query:  `foo{bar="true"}`,
 			Start:  start,
@@ -62,37 +61,4 @@ var _ = Describe("Query", func() {
 		Expect(ok).To(BeTrue(), "result is not a matrix")
 		Expect(len(matrixResult)).To(Equal(2))
 	})
-
-	It("should build a query", func() {
-		q1 := &PromQuery{
-			Metric: "foo",
-			Query:  "kube_persistentvolumeclaim_resource_requests_storage_bytes",
-			MeterDef: types.NamespacedName{
-				Name:      "foo",
-				Namespace: "foons",
-			},
-			AggregateFunc: "sum",
-			Type:          v1alpha1.WorkloadTypePVC,
-		}
-
-		expected := "sum by (persistentvolumeclaim,namespace) (avg(meterdef_persistentvolumeclaim_info{meter_def_name=\"foo\",meter_def_namespace=\"foons\",phase=\"Bound\"}) without (instance, container, endpoint, job, service) * on(persistentvolumeclaim,namespace) group_right kube_persistentvolumeclaim_resource_requests_storage_bytes)"
-		Expect(q1.String()).To(Equal(expected), "failed to create query for pvc")
-	})
-
-	PIt("should build a query", func() {
-		By("building a query with no args")
-		q1 := &PromQuery{
-			Metric: "foo",
-		}
-
-		Expect(q1.String()).To(Equal("foo"), "failed to create query with no args")
-
-		By("building a complicated query")
-
-		expectedFields := []string{`rpc_durations_seconds_count`, `meter_kind="App"`, `meter_domain="apps.partner.metering.com"`}
-
-		for _, field := range expectedFields {
-			Expect(rpcDurationSecondsQuery).To(ContainSubstring(field))
-		}
-	})
 })
@@ -0,0 +1,203 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"emperror.dev/errors"
+)
+
+// ReportWriter writes metrics to rolling report slice files as they
+// arrive, so only the current slice is held in memory. Each slice is
+// recorded on the metadata when it is written.
+type ReportWriter struct {
+	Directory string
+
+	// Signer, if set, writes a detached signature of the metadata.
+	Signer *ReportSigner
+
+	metricsPerFile int
+	metadata       *ReportMetadata
+	current        *MetricsReport
+	filenames      []string
+	count          int
+	closed         bool
+	mutex          sync.Mutex
+}
+
+func NewReportWriter(
+	directory string,
+	metadata *ReportMetadata,
+	metricsPerFile int,
+) (*ReportWriter, error) {
+	if metricsPerFile < 1 {
+		return nil, errors.New("metrics per file must be at least 1")
+	}
+
+	err := os.Mkdir(directory, 0755)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating directory")
+	}
+
+	return &ReportWriter{
+		Directory:      directory,
+		metricsPerFile: metricsPerFile,
+		metadata:       metadata,
+		filenames:      []string{},
+	}, nil
+}
+
+// Write adds the metrics to the current slice and writes the slice out
+// whenever it is full.
+func (w *ReportWriter) Write(metrics ...*MetricBase) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return errors.New("report writer is closed")
+	}
+
+	for _, metric := range metrics {
+		if w.current == nil {
+			w.current = NewReport()
+		}
+
+		err := w.current.AddMetrics(metric)
+
+		if err != nil {
+			return err
+		}
+
+		w.count = w.count + 1
+
+		if len(w.current.Metrics) >= w.metricsPerFile {
+			err := w.flush()
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Count is the number of metrics written.
+func (w *ReportWriter) Count() int {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.count
+}
+
+// Close writes the last slice, the metadata and its signature. It returns
+// every file written, with the metadata last.
+func (w *ReportWriter) Close() ([]string, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return w.filenames, nil
+	}
+
+	err := w.flush()
+
+	if err != nil {
+		return nil, err
+	}
+
+	w.closed = true
+
+	marshallBytes, err := json.Marshal(w.metadata)
+	if err != nil {
+		logger.Error(err, "failed to marshal report metadata", "metadata", w.metadata)
+		return nil, err
+	}
+
+	filename := filepath.Join(w.Directory, metadataFileName)
+	err = ioutil.WriteFile(filename, marshallBytes, 0600)
+	if err != nil {
+		logger.Error(err, "failed to write file", "file", filename)
+		return nil, err
+	}
+
+	if w.Signer != nil {
+		signature, err := w.Signer.Sign(marshallBytes)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sigFilename := filepath.Join(w.Directory, signatureFileName)
+		err = ioutil.WriteFile(sigFilename, signature, 0600)
+		if err != nil {
+			logger.Error(err, "failed to write file", "file", sigFilename)
+			return nil, err
+		}
+
+		w.filenames = append(w.filenames, sigFilename)
+	}
+
+	w.filenames = append(w.filenames, filename)
+	return w.filenames, nil
+}
+
+// Discard removes everything written, used when collection fails.
+func (w *ReportWriter) Discard() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.closed = true
+	w.current = nil
+	return os.RemoveAll(w.Directory)
+}
+
+func (w *ReportWriter) flush() error {
+	if w.current == nil || len(w.current.Metrics) == 0 {
+		return nil
+	}
+
+	metricReport := w.current
+	w.current = nil
+
+	marshallBytes, err := json.Marshal(metricReport)
+	if err != nil {
+		logger.Error(err, "failed to marshal metrics report", "reportSliceID", metricReport.ReportSliceID)
+		return err
+	}
+
+	filename := filepath.Join(
+		w.Directory,
+		fmt.Sprintf("%s.json", metricReport.ReportSliceID.String()))
+
+	err = ioutil.WriteFile(filename, marshallBytes, 0600)
+
+	if err != nil {
+		logger.Error(err, "failed to write file", "file", filename)
+		return errors.Wrap(err, "failed to write file")
+	}
+
+	w.metadata.AddMetricsReportFile(metricReport, marshallBytes)
+	w.filenames = append(w.filenames, filename)
+
+	logger.Info("wrote report slice", "file", filename, "metrics", len(metricReport.Metrics))
+	return nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReportWriter", func() {
+	var (
+		dir      string
+		metadata *ReportMetadata
+	)
+
+	newMetric := func(i int) *MetricBase {
+		key := MetricKey{
+			ReportPeriodStart: "2020-04-19T00:00:00Z",
+			ReportPeriodEnd:   "2020-04-20T00:00:00Z",
+			IntervalStart:     "2020-04-19T00:00:00Z",
+			IntervalEnd:       "2020-04-19T01:00:00Z",
+			MeterDomain:       "apps.partner.metering.com",
+			MeterKind:         "App",
+			Namespace:         "metering-example-operator",
+			ResourceName:      fmt.Sprintf("example-app-pod-%d", i),
+		}
+		key.Init("foo-id")
+
+		return &MetricBase{
+			Key:              key,
+			AdditionalLabels: map[string]interface{}{"pod": key.ResourceName, "namespace": key.Namespace},
+			Metrics:          map[string]interface{}{"rpc_durations_seconds_count": "42"},
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "writer")
+		Expect(err).To(Succeed())
+
+		metadata = NewReportMetadata(uuid.New(), ReportSourceMetadata{})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should write rolling slices", func() {
+		sut, err := NewReportWriter(filepath.Join(dir, "report"), metadata, 10)
+		Expect(err).To(Succeed())
+
+		for i := 0; i < 25; i++ {
+			Expect(sut.Write(newMetric(i))).To(Succeed())
+		}
+
+		files, err := sut.Close()
+		Expect(err).To(Succeed())
+		Expect(files).To(HaveLen(4))
+		Expect(filepath.Base(files[3])).To(Equal("metadata.json"))
+		Expect(sut.Count()).To(Equal(25))
+
+		total := 0
+		for _, file := range files[:3] {
+			data, err := ioutil.ReadFile(file)
+			Expect(err).To(Succeed())
+
+			metricsReport := &MetricsReport{}
+			Expect(json.Unmarshal(data, metricsReport)).To(Succeed())
+			Expect(metadata.ReportSlices).To(HaveKeyWithValue(
+				metricsReport.ReportSliceID,
+				ReportSlicesValue{NumberMetrics: len(metricsReport.Metrics), SHA256: sha256Hex(data)}))
+
+			total = total + len(metricsReport.Metrics)
+		}
+
+		Expect(total).To(Equal(25))
+		Expect(metadata.ReportSlices).To(HaveLen(3))
+
+		Expect(sut.Write(newMetric(26))).ToNot(Succeed())
+	})
+
+	It("should only write metadata with no metrics", func() {
+		sut, err := NewReportWriter(filepath.Join(dir, "report"), metadata, 10)
+		Expect(err).To(Succeed())
+
+		files, err := sut.Close()
+		Expect(err).To(Succeed())
+		Expect(files).To(HaveLen(1))
+		Expect(metadata.ReportSlices).To(BeEmpty())
+	})
+
+	It("should discard the report", func() {
+		sut, err := NewReportWriter(filepath.Join(dir, "report"), metadata, 10)
+		Expect(err).To(Succeed())
+		Expect(sut.Write(newMetric(0))).To(Succeed())
+
+		Expect(sut.Discard()).To(Succeed())
+		Expect(filepath.Join(dir, "report")).ToNot(BeADirectory())
+	})
+
+	It("should only hold the current slice in memory", func() {
+		const count, metricsPerFile = 5000, 500
+
+		sut, err := NewReportWriter(filepath.Join(dir, "report"), metadata, metricsPerFile)
+		Expect(err).To(Succeed())
+
+		for i := 0; i < count; i++ {
+			Expect(sut.Write(newMetric(i))).To(Succeed())
+
+			held := 0
+			if sut.current != nil {
+				held = len(sut.current.Metrics)
+			}
+
+			Expect(held).To(BeNumerically("<", metricsPerFile))
+			Expect(sut.filenames).To(HaveLen((i + 1) / metricsPerFile))
+		}
+
+		files, err := sut.Close()
+		Expect(err).To(Succeed())
+		Expect(files).To(HaveLen(count/metricsPerFile + 1))
+		Expect(metadata.ReportSlices).To(HaveLen(count / metricsPerFile))
+	})
+})
+
+func BenchmarkReportWriter(b *testing.B) {
+	dir, err := ioutil.TempDir("", "writer")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	metric := &MetricBase{
+		Key: MetricKey{
+			ReportPeriodStart: "2020-04-19T00:00:00Z",
+			ReportPeriodEnd:   "2020-04-20T00:00:00Z",
+			IntervalStart:     "2020-04-19T00:00:00Z",
+			IntervalEnd:       "2020-04-19T01:00:00Z",
+			MeterDomain:       "apps.partner.metering.com",
+			MeterKind:         "App",
+			Namespace:         "metering-example-operator",
+			ResourceName:      "example-app-pod",
+		},
+		AdditionalLabels: map[string]interface{}{"pod": "example-app-pod"},
+		Metrics:          map[string]interface{}{"rpc_durations_seconds_count": "42"},
+	}
+	metric.Key.Init("foo-id")
+
+	sut, err := NewReportWriter(
+		filepath.Join(dir, "report"),
+		NewReportMetadata(uuid.New(), ReportSourceMetadata{}),
+		500)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := sut.Write(metric); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	if _, err := sut.Close(); err != nil {
+		b.Fatal(err)
+	}
+}
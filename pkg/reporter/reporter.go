@@ -16,22 +16,19 @@ package reporter
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"emperror.dev/errors"
 	"github.com/google/uuid"
-	"github.com/meirf/gopart"
 	"github.com/prometheus/client_golang/api"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
 	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
 	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+	prom "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/prometheus"
 	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils"
 	"github.com/redhat-marketplace/redhat-marketplace-operator/version"
 	corev1 "k8s.io/api/core/v1"
@@ -48,7 +45,7 @@ var (
 // Goals of the reporter:
 // Get current meters to query
 //
-// Build a report for hourly since last reprot
+// Build a report for each interval since last reprot
 //
 // Query all the meters
 //
@@ -92,21 +89,23 @@ func NewMarketplaceReporter(
 
 var ErrNoMeterDefinitionsFound = errors.New("no meterDefinitions found")
 
-func (r *MarketplaceReporter) CollectMetrics(ctxIn context.Context) (map[MetricKey]*MetricBase, []error, error) {
+// CollectMetrics queries every meter definition and streams the results
+// to the writer. A workload's report window is queried in chunks and the
+// results are merged by MetricKey within a chunk, so only one chunk's
+// results are held in memory at a time. The errors of single queries are
+// returned without failing the collection.
+func (r *MarketplaceReporter) CollectMetrics(ctxIn context.Context, writer *ReportWriter) ([]error, error) {
 	ctx, cancel := context.WithCancel(ctxIn)
 	defer cancel()
 
-	resultsMap := make(map[MetricKey]*MetricBase)
-	var resultsMapMutex sync.Mutex
-
 	if len(r.meterDefinitions) == 0 {
 		logger.Info("no meterdefs found")
-		return resultsMap, []error{}, nil
+		return []error{}, nil
 	}
 
 	// data channels ; closed by this func
 	meterDefsChan := make(chan *marketplacev1alpha1.MeterDefinition, len(r.meterDefinitions))
-	promModelsChan := make(chan meterDefPromModel)
+	promModelsChan := make(chan []meterDefPromModel)
 
 	// error channels
 	errorsChan := make(chan error)
@@ -136,19 +135,19 @@ func (r *MarketplaceReporter) CollectMetrics(ctxIn context.Context) (map[MetricK
 	go r.process(
 		ctx,
 		promModelsChan,
-		resultsMap,
-		&resultsMapMutex,
+		writer,
 		r.report,
 		processDone,
 		errorsChan)
 
 	// send & close data pipe
-	for _, meterDef := range r.meterDefinitions {
-		meterDefsChan <- &meterDef
+	for i := range r.meterDefinitions {
+		meterDefsChan <- &r.meterDefinitions[i]
 	}
 	close(meterDefsChan)
 
 	errorList := []error{}
+	failures := []error{}
 
 	// Collect errors function
 	go func() {
@@ -156,6 +155,10 @@ func (r *MarketplaceReporter) CollectMetrics(ctxIn context.Context) (map[MetricK
 			if err, more := <-errorsChan; more {
 				logger.Error(err, "error occurred processing")
 				errorList = append(errorList, err)
+
+				if failure, ok := err.(*collectFailure); ok {
+					failures = append(failures, failure.error)
+				}
 			} else {
 				errorDone <- true
 				return
@@ -173,7 +176,25 @@ func (r *MarketplaceReporter) CollectMetrics(ctxIn context.Context) (map[MetricK
 
 	<-errorDone
 
-	return resultsMap, errorList, errors.Combine(errorList...)
+	return errorList, errors.Combine(failures...)
+}
+
+// collectFailure is an error that fails the report. Other errors only leave
+// a query's results out of the report and are returned for its query error
+// list.
+type collectFailure struct {
+	error
+}
+
+// queryFailed is the error for a query that failed after its retries. A
+// query Prometheus rejects is kept out of the report, any other failure
+// fails it.
+func queryFailed(err error) error {
+	if errors.Is(err, ClientError) && !errors.Is(err, ClientErrorUnauthorized) {
+		return err
+	}
+
+	return &collectFailure{err}
 }
 
 type meterDefPromModel struct {
@@ -182,65 +203,94 @@ type meterDefPromModel struct {
 	MetricName string
 	Type       v1alpha1.WorkloadType
 	Workload   v1alpha1.Workload
+	Step       time.Duration
 }
 
 func (r *MarketplaceReporter) query(
 	ctx context.Context,
 	startTime, endTime time.Time,
 	inMeterDefs <-chan *marketplacev1alpha1.MeterDefinition,
-	outPromModels chan<- meterDefPromModel,
+	outPromModels chan<- []meterDefPromModel,
 	done chan bool,
 	errorsch chan<- error,
 ) {
 	queryProcess := func(mdef *marketplacev1alpha1.MeterDefinition) {
 		for _, workload := range mdef.Spec.Workloads {
+			queries := []*prom.PromQuery{}
+
 			for _, metric := range workload.MetricLabels {
 				logger.Info("query", "metric", metric)
 				// TODO: use metadata to build a smart roll up
 				// Guage = delta
 				// Counter = increase
-				// Histogram and summary are unsupported
-				query := &PromQuery{
-					Metric: metric.Label,
-					Type:   workload.WorkloadType,
-					MeterDef: types.NamespacedName{
+				metricQueries, err := prom.NewPromQueries(
+					types.NamespacedName{
 						Name:      mdef.Name,
 						Namespace: mdef.Namespace,
 					},
-					Query:         metric.Query,
-					Time:          "60m",
-					Start:         startTime,
-					End:           endTime,
-					Step:          time.Hour,
-					AggregateFunc: metric.Aggregation,
+					workload,
+					metric,
+					startTime,
+					endTime,
+				)
+
+				if err != nil {
+					logger.Error(err, "error encountered")
+					errorsch <- err
+					continue
 				}
-				logger.Info("output", "query", query.String())
 
-				var val model.Value
-				var warnings v1.Warnings
+				for _, query := range metricQueries {
+					query.Recorded = !query.Instant && useRecordingRules(mdef, startTime)
+					queries = append(queries, query)
+				}
+			}
 
-				err := utils.Retry(func() error {
-					var err error
-					val, warnings, err = r.queryRange(query)
+			for _, chunk := range queryChunks(queries, startTime, endTime) {
+				promModels := []meterDefPromModel{}
 
-					if err != nil {
-						return errors.Wrap(err, "error with query")
+				for _, query := range chunk {
+					logger.Info("output", "query", query.String(), "start", query.Start, "end", query.End)
+
+					var val model.Value
+					var warnings v1.Warnings
+
+					err := utils.Retry(func() error {
+						var err error
+
+						if query.Instant {
+							val, warnings, err = r.queryInstant(query)
+						} else {
+							val, warnings, err = r.queryRange(query)
+						}
+
+						if err != nil {
+							return errors.Wrap(err, "error with query")
+						}
+
+						return nil
+					}, *r.Retry)
+
+					if warnings != nil {
+						logger.Info("warnings %v", warnings)
 					}
 
-					return nil
-				}, *r.Retry)
+					if err != nil {
+						logger.Error(err, "error encountered")
+						err = queryFailed(err)
+						errorsch <- err
 
-				if warnings != nil {
-					logger.Info("warnings %v", warnings)
-				}
+						if _, ok := err.(*collectFailure); ok {
+							return
+						}
 
-				if err != nil {
-					logger.Error(err, "error encountered")
-					errorsch <- err
-					return
+						continue
+					}
+
+					promModels = append(promModels, meterDefPromModel{mdef, val, query.Metric, query.Type, workload, query.Step})
 				}
 
-				outPromModels <- meterDefPromModel{mdef, val, metric.Label, query.Type, workload}
+				outPromModels <- promModels
 			}
 		}
 	}
@@ -252,131 +302,221 @@ func (r *MarketplaceReporter) query(
 	})
 }
 
+// queryChunkPoints is about how many points of its finest interval a
+// workload's range queries return per chunk of the report window.
+const queryChunkPoints = 60
+
+// queryChunks splits a workload's range queries into chunks of the report
+// window, so only one chunk of results is held at a time. The chunk length
+// is a multiple of every query's step, so a chunk holds whole intervals of
+// every query and the results of an interval are merged within one chunk.
+// Instant queries cover the whole window and run with the first chunk.
+func queryChunks(queries []*prom.PromQuery, start, end time.Time) [][]*prom.PromQuery {
+	var length, minStep time.Duration
+	first := []*prom.PromQuery{}
+
+	for _, query := range queries {
+		switch {
+		case query.Instant:
+			first = append(first, query)
+		case length == 0:
+			length, minStep = query.Step, query.Step
+		default:
+			length = length / gcd(length, query.Step) * query.Step
+
+			if query.Step < minStep {
+				minStep = query.Step
+			}
+		}
+	}
+
+	chunks := [][]*prom.PromQuery{first}
+
+	if length == 0 {
+		return chunks
+	}
+
+	if n := queryChunkPoints * minStep / length; n > 1 {
+		length = n * length
+	}
+
+	for chunkStart := start; ; chunkStart = chunkStart.Add(length) {
+		last := !chunkStart.Add(length).Before(end)
+		chunk := chunks[len(chunks)-1]
+
+		for _, query := range queries {
+			if query.Instant {
+				continue
+			}
+
+			chunkQuery := *query
+			chunkQuery.Start = chunkStart
+
+			// the next chunk starts with the point at the chunk end
+			if !last {
+				chunkQuery.End = chunkStart.Add(length - query.Step)
+			}
+
+			chunk = append(chunk, &chunkQuery)
+		}
+
+		chunks[len(chunks)-1] = chunk
+
+		if last {
+			return chunks
+		}
+
+		chunks = append(chunks, []*prom.PromQuery{})
+	}
+}
+
+func gcd(a, b time.Duration) time.Duration {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
+
+// recordingRuleDelay is how long Prometheus may take to load a changed
+// recording rule.
+const recordingRuleDelay = 5 * time.Minute
+
+// useRecordingRules is true if the meter definition's recording rules were
+// in place for the whole report window. Earlier windows join at query time.
+func useRecordingRules(mdef *marketplacev1alpha1.MeterDefinition, start time.Time) bool {
+	changed := mdef.Status.RecordingRulesTime
+	return changed != nil && !changed.Add(recordingRuleDelay).After(start)
+}
+
 func (r *MarketplaceReporter) process(
 	ctx context.Context,
-	inPromModels <-chan meterDefPromModel,
-	results map[MetricKey]*MetricBase,
-	mutex sync.Locker,
+	inPromModels <-chan []meterDefPromModel,
+	writer *ReportWriter,
 	report *marketplacev1alpha1.MeterReport,
 	done chan bool,
 	errorsch chan error,
 ) {
 	syncProcess := func(
+		results map[MetricKey]*MetricBase,
 		pmodel meterDefPromModel,
 		name string,
 		mdef *marketplacev1alpha1.MeterDefinition,
 		report *marketplacev1alpha1.MeterReport,
 		m model.Value,
 	) {
-		//# do the work
-		switch m.Type() {
-		case model.ValMatrix:
-			matrixVals := m.(model.Matrix)
-
-			for _, matrix := range matrixVals {
-				logger.Info("adding metric", "metric", matrix.Metric)
-
-				for _, pair := range matrix.Values {
-					func() {
-
-						labels := getKeysFromMetric(matrix.Metric, additionalLabels)
-						labelMatrix, err := kvToMap(labels)
+		addSample := func(
+			metric model.Metric,
+			intervalStart, intervalEnd time.Time,
+			value model.SampleValue,
+		) {
+			labels := getKeysFromMetric(metric, getAdditionalLabels(pmodel.Workload))
+			namespace := string(metric["namespace"])
+			objName := getResourceName(metric, getResourceLabels(pmodel.Workload))
+
+			if objName == "" {
+				errorsch <- errors.Errorf("can't find objName for %s in %s", name, metric)
+				return
+			}
 
-						if err != nil {
-							errorsch <- errors.Wrap(err, "failed adding additional labels")
-							return
-						}
+			key := MetricKey{
+				ReportPeriodStart: report.Spec.StartTime.Format(time.RFC3339),
+				ReportPeriodEnd:   report.Spec.EndTime.Format(time.RFC3339),
+				IntervalStart:     intervalStart.Format(time.RFC3339),
+				IntervalEnd:       intervalEnd.Format(time.RFC3339),
+				MeterDomain:       mdef.Spec.Group,
+				MeterKind:         mdef.Spec.Kind,
+				Namespace:         namespace,
+				ResourceName:      objName,
+				Workload:          pmodel.Workload.Name,
+			}
 
-						var objName string
-						namespace := labelMatrix["namespace"].(string)
-
-						switch pmodel.Type {
-						case v1alpha1.WorkloadTypePVC:
-							if pvc, ok := labelMatrix["persistentvolumeclaim"]; ok {
-								objName = pvc.(string)
-							}
-						case v1alpha1.WorkloadTypePod:
-							if pod, ok := labelMatrix["pod"]; ok {
-								objName = pod.(string)
-							}
-						case v1alpha1.WorkloadTypeServiceMonitor:
-							fallthrough
-						case v1alpha1.WorkloadTypeService:
-							if service, ok := labelMatrix["service"]; ok {
-								objName = service.(string)
-							}
-						}
+			key.Init(r.mktconfig.Spec.ClusterUUID)
 
-						if objName == "" {
-							errorsch <- errors.New("can't find objName")
-							return
-						}
+			base, ok := results[key]
 
-						key := MetricKey{
-							ReportPeriodStart: report.Spec.StartTime.Format(time.RFC3339),
-							ReportPeriodEnd:   report.Spec.EndTime.Format(time.RFC3339),
-							IntervalStart:     pair.Timestamp.Time().Format(time.RFC3339),
-							IntervalEnd:       pair.Timestamp.Add(time.Hour).Time().Format(time.RFC3339),
-							MeterDomain:       mdef.Spec.Group,
-							MeterKind:         mdef.Spec.Kind,
-							Namespace:         namespace,
-							ResourceName:      objName,
-							Workload:          pmodel.Workload.Name,
-						}
-
-						key.Init(r.mktconfig.Spec.ClusterUUID)
+			if !ok {
+				base = &MetricBase{
+					Key: key,
+				}
+			}
 
-						mutex.Lock()
-						defer mutex.Unlock()
+			logger.Info("adding sample", "metric", metric, "value", value)
+			metricPairs := []interface{}{name, value.String()}
 
-						base, ok := results[key]
+			err := base.AddAdditionalLabels(labels...)
 
-						if !ok {
-							base = &MetricBase{
-								Key: key,
-							}
-						}
+			if err != nil {
+				errorsch <- errors.Wrap(err, "failed adding additional labels")
+				return
+			}
 
-						logger.Info("adding pair", "metric", matrix.Metric, "pair", pair)
-						metricPairs := []interface{}{name, pair.Value.String()}
+			err = base.AddMetrics(metricPairs...)
 
-						err = base.AddAdditionalLabels(labels...)
+			if err != nil {
+				errorsch <- errors.Wrap(err, "failed adding metrics")
+				return
+			}
 
-						if err != nil {
-							errorsch <- errors.Wrap(err, "failed adding additional labels")
-							return
-						}
+			results[key] = base
+		}
 
-						err = base.AddMetrics(metricPairs...)
+		//# do the work
+		switch m.Type() {
+		case model.ValMatrix:
+			matrixVals := m.(model.Matrix)
 
-						if err != nil {
-							errorsch <- errors.Wrap(err, "failed adding metrics")
-							return
-						}
+			for _, matrix := range matrixVals {
+				logger.Info("adding metric", "metric", matrix.Metric)
 
-						results[key] = base
-					}()
+				for _, pair := range matrix.Values {
+					addSample(
+						matrix.Metric,
+						pair.Timestamp.Time(),
+						pair.Timestamp.Add(pmodel.Step).Time(),
+						pair.Value,
+					)
 				}
 			}
-		case model.ValString:
 		case model.ValVector:
-		case model.ValScalar:
-		case model.ValNone:
-			errorsch <- errors.Errorf("can't process model type=%s", m.Type())
+			// an instant query covers the whole report window
+			for _, sample := range m.(model.Vector) {
+				logger.Info("adding metric", "metric", sample.Metric)
+				addSample(
+					sample.Metric,
+					report.Spec.StartTime.Time,
+					report.Spec.EndTime.Time,
+					sample.Value,
+				)
+			}
+		default:
+			errorsch <- errors.Errorf("can't process query result for %s of meterdef %s/%s, unsupported type=%s",
+				name, mdef.Namespace, mdef.Name, m.Type())
 		}
 	}
 
 	wgWait(ctx, "syncProcess", *r.MaxRoutines, done, func() {
-		for pmodel := range inPromModels {
-			syncProcess(pmodel, pmodel.MetricName, pmodel.MeterDefinition, report, pmodel.Value)
+		for pmodels := range inPromModels {
+			results := make(map[MetricKey]*MetricBase)
+
+			for _, pmodel := range pmodels {
+				syncProcess(results, pmodel, pmodel.MetricName, pmodel.MeterDefinition, report, pmodel.Value)
+			}
+
+			metrics := make([]*MetricBase, 0, len(results))
+			for _, base := range results {
+				metrics = append(metrics, base)
+			}
+
+			if err := writer.Write(metrics...); err != nil {
+				errorsch <- &collectFailure{errors.Wrap(err, "failed writing metrics")}
+			}
 		}
 	})
 }
 
-func (r *MarketplaceReporter) WriteReport(
-	source uuid.UUID,
-	metrics map[MetricKey]*MetricBase) ([]string, error) {
-
+// NewReportWriter creates the writer for a report in the output directory.
+func (r *MarketplaceReporter) NewReportWriter(source uuid.UUID) (*ReportWriter, error) {
 	env := ReportProductionEnv
 	envAnnotation, ok := r.mktconfig.Annotations["marketplace.redhat.com/environment"]
 
@@ -390,75 +530,93 @@ func (r *MarketplaceReporter) WriteReport(
 		RhmEnvironment: env,
 		Version:        version.Version,
 	})
+	metadata.SupersedesReportID = r.report.Status.SupersedesReportID
 
-	var partitionSize = *r.MetricsPerFile
-
-	metricsArr := make([]*MetricBase, 0, len(metrics))
+	return NewReportWriter(
+		filepath.Join(r.Config.OutputDirectory, source.String()),
+		metadata,
+		*r.MetricsPerFile,
+	)
+}
 
-	filedir := filepath.Join(r.Config.OutputDirectory, source.String())
-	err := os.Mkdir(filedir, 0755)
+func (r *MarketplaceReporter) WriteReport(
+	source uuid.UUID,
+	metrics map[MetricKey]*MetricBase) ([]string, error) {
+	writer, err := r.NewReportWriter(source)
 
 	if err != nil {
-		return []string{}, errors.Wrap(err, "error creating directory")
+		return []string{}, err
 	}
 
 	for _, v := range metrics {
-		metricsArr = append(metricsArr, v)
-	}
+		err := writer.Write(v)
 
-	filenames := []string{}
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	for idxRange := range gopart.Partition(len(metricsArr), partitionSize) {
-		metricReport := NewReport()
-		metadata.AddMetricsReport(metricReport)
+	return writer.Close()
+}
 
-		err := metricReport.AddMetrics(metricsArr[idxRange.Low:idxRange.High]...)
+// getResourceLabels returns the result labels that identify the resource
+// usage is attributed to.
+func getResourceLabels(workload v1alpha1.Workload) []model.LabelName {
+	if len(workload.ResourceLabels) != 0 {
+		return toLabelNames(workload.ResourceLabels)
+	}
 
-		if err != nil {
-			return filenames, err
+	switch workload.WorkloadType {
+	case v1alpha1.WorkloadTypePVC:
+		return []model.LabelName{"persistentvolumeclaim"}
+	case v1alpha1.WorkloadTypePod:
+		return []model.LabelName{"pod"}
+	case v1alpha1.WorkloadTypeServiceMonitor:
+		fallthrough
+	case v1alpha1.WorkloadTypeService:
+		return []model.LabelName{"service"}
+	case v1alpha1.WorkloadTypeGeneric:
+		if workload.GroupVersionKind == nil {
+			return []model.LabelName{}
 		}
+		return []model.LabelName{model.LabelName(prom.GenericKindLabel(workload.GroupVersionKind.Kind))}
+	case v1alpha1.WorkloadTypeNode:
+		return []model.LabelName{"node"}
+	default:
+		return []model.LabelName{}
+	}
+}
 
-		metadata.UpdateMetricsReport(metricReport)
+func getAdditionalLabels(workload v1alpha1.Workload) []model.LabelName {
+	if len(workload.AdditionalLabels) != 0 {
+		return toLabelNames(workload.AdditionalLabels)
+	}
 
-		marshallBytes, err := json.Marshal(metricReport)
-		logger.Info(string(marshallBytes))
-		if err != nil {
-			logger.Error(err, "failed to marshal metrics report", "report", metricReport)
-			return nil, err
-		}
-		filename := filepath.Join(
-			filedir,
-			fmt.Sprintf("%s.json", metricReport.ReportSliceID.String()))
+	return additionalLabels
+}
 
-		err = ioutil.WriteFile(
-			filename,
-			marshallBytes,
-			0600)
+// getResourceName joins the values of the resource labels. It is empty if
+// any of the labels is missing.
+func getResourceName(metric model.Metric, labels []model.LabelName) string {
+	values := make([]string, 0, len(labels))
+	for _, label := range labels {
+		val, ok := metric[label]
 
-		if err != nil {
-			logger.Error(err, "failed to write file", "file", filename)
-			return nil, errors.Wrap(err, "failed to write file")
+		if !ok || val == "" {
+			return ""
 		}
 
-		filenames = append(filenames, filename)
-	}
-
-	marshallBytes, err := json.Marshal(metadata)
-	if err != nil {
-		logger.Error(err, "failed to marshal report metadata", "metadata", metadata)
-		return nil, err
+		values = append(values, string(val))
 	}
+	return strings.Join(values, "/")
+}
 
-	filename := filepath.Join(filedir, "metadata.json")
-	err = ioutil.WriteFile(filename, marshallBytes, 0600)
-	if err != nil {
-		logger.Error(err, "failed to write file", "file", filename)
-		return nil, err
+func toLabelNames(labels []string) []model.LabelName {
+	names := make([]model.LabelName, 0, len(labels))
+	for _, label := range labels {
+		names = append(names, model.LabelName(label))
 	}
-
-	filenames = append(filenames, filename)
-
-	return filenames, nil
+	return names
 }
 
 func getKeysFromMetric(metric model.Metric, labels []model.LabelName) []interface{} {
@@ -23,17 +23,23 @@ import (
 	"io/ioutil"
 	"math/rand"
 	"net/http"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/meirf/gopart"
+	"github.com/mitchellh/mapstructure"
 	"github.com/prometheus/client_golang/api"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
 	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+	prom "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/prometheus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -165,14 +171,16 @@ var _ = PDescribe("Reporter", func() {
 				m := &runtime.MemStats{}
 				m2 := &runtime.MemStats{}
 
+				writer, err := sut.NewReportWriter(uuid.New())
+				Expect(err).To(Succeed())
+
 				runtime.ReadMemStats(m)
-				results, errs, err := sut.CollectMetrics(context.TODO())
+				errs, err := sut.CollectMetrics(context.TODO(), writer)
 				runtime.ReadMemStats(m2)
 
 				Expect(err).To(Succeed())
 				Expect(errs).To(BeEmpty())
-				Expect(results).ToNot(BeEmpty())
-				Expect(len(results)).To(Equal(count))
+				Expect(writer.Count()).To(Equal(count))
 
 				b.RecordValue("disk usage (in MB)", float64((m2.Alloc-m.Alloc)/1024/1024))
 			})
@@ -184,18 +192,18 @@ var _ = PDescribe("Reporter", func() {
 
 	It("query, build and submit a report", func(done Done) {
 		By("collecting metrics")
-		results, errs, err := sut.CollectMetrics(context.TODO())
+		writer, err := sut.NewReportWriter(uuid.New())
+		Expect(err).To(Succeed())
+
+		errs, err := sut.CollectMetrics(context.TODO(), writer)
 
 		Expect(err).To(Succeed())
 		Expect(errs).To(BeEmpty())
-		Expect(results).ToNot(BeEmpty())
-		Expect(len(results)).To(Equal(count))
+		Expect(writer.Count()).To(Equal(count))
 
 		By("writing report")
 
-		files, err := sut.WriteReport(
-			uuid.New(),
-			results)
+		files, err := writer.Close()
 
 		Expect(err).To(Succeed())
 		Expect(files).ToNot(BeEmpty())
@@ -251,7 +259,8 @@ var _ = PDescribe("Reporter", func() {
 
 		By("uploading file")
 
-		Expect(uploader.UploadFile(fileName)).To(Succeed())
+		_, err = uploader.UploadFile(fileName)
+		Expect(err).To(Succeed())
 
 		close(done)
 	}, 20)
@@ -390,3 +399,336 @@ func GenerateRandomData(start, end time.Time) string {
 
 	return file.Name()
 }
+
+var _ = Describe("process", func() {
+	var (
+		sut      *MarketplaceReporter
+		report   *marketplacev1alpha1.MeterReport
+		mdef     *marketplacev1alpha1.MeterDefinition
+		workload marketplacev1alpha1.Workload
+		start, _ = time.Parse(time.RFC3339, "2020-04-19T00:00:00Z")
+		end, _   = time.Parse(time.RFC3339, "2020-04-20T00:00:00Z")
+	)
+
+	BeforeEach(func() {
+		cfg := &Config{}
+		cfg.SetDefaults()
+
+		sut = &MarketplaceReporter{
+			Config: cfg,
+			mktconfig: &marketplacev1alpha1.MarketplaceConfig{
+				Spec: marketplacev1alpha1.MarketplaceConfigSpec{
+					ClusterUUID: "foo-id",
+				},
+			},
+		}
+
+		report = &marketplacev1alpha1.MeterReport{
+			Spec: marketplacev1alpha1.MeterReportSpec{
+				StartTime: metav1.Time{Time: start},
+				EndTime:   metav1.Time{Time: end},
+			},
+		}
+
+		mdef = &marketplacev1alpha1.MeterDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "foons"},
+			Spec: marketplacev1alpha1.MeterDefinitionSpec{
+				Group: "apps.partner.metering.com",
+				Kind:  "App",
+			},
+		}
+
+		workload = marketplacev1alpha1.Workload{
+			Name:         "podcount",
+			WorkloadType: marketplacev1alpha1.WorkloadTypePod,
+			MetricLabels: []marketplacev1alpha1.MeterLabelQuery{
+				{Label: "count", Query: "kube_pod_info", Aggregation: "sum", Instant: true},
+			},
+		}
+	})
+
+	// run collects the meter definition's instant metric, answered with
+	// the result from the recorded responses.
+	run := func(resultType model.ValueType, result string) (map[MetricKey]*MetricBase, []error) {
+		dir, err := ioutil.TempDir("", "process")
+		Expect(err).To(Succeed())
+		defer os.RemoveAll(dir)
+
+		mdef.Spec.Workloads = []marketplacev1alpha1.Workload{workload}
+		queries, err := prom.NewPromQueries(
+			types.NamespacedName{Name: mdef.Name, Namespace: mdef.Namespace},
+			workload, workload.MetricLabels[0], start, end)
+		Expect(err).To(Succeed())
+
+		data, err := json.Marshal(&RecordedResponse{
+			Query:  queries[0].String(),
+			Status: "success",
+			Data:   json.RawMessage(fmt.Sprintf(`{"resultType":%q,"result":%s}`, resultType, result)),
+		})
+		Expect(err).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "response.json"), data, 0600)).To(Succeed())
+
+		fixture, err := LoadPrometheusFixture(dir)
+		Expect(err).To(Succeed())
+		apiClient, err := NewFixtureClient(fixture)
+		Expect(err).To(Succeed())
+
+		sut.api = v1.NewAPI(apiClient)
+		sut.report = report
+		sut.meterDefinitions = []marketplacev1alpha1.MeterDefinition{*mdef}
+
+		writer, err := NewReportWriter(filepath.Join(dir, "report"), NewReportMetadata(uuid.New(), ReportSourceMetadata{}), 500)
+		Expect(err).To(Succeed())
+
+		errs, err := sut.CollectMetrics(context.Background(), writer)
+		Expect(err).To(Succeed(), "query errors should not fail the report")
+		Expect(fixture.Unmatched()).To(BeEmpty())
+
+		files, err := writer.Close()
+		Expect(err).To(Succeed())
+
+		results := make(map[MetricKey]*MetricBase)
+		for _, file := range files[:len(files)-1] {
+			data, err := ioutil.ReadFile(file)
+			Expect(err).To(Succeed())
+
+			metricsReport := &MetricsReport{}
+			Expect(json.Unmarshal(data, metricsReport)).To(Succeed())
+
+			for _, metric := range metricsReport.Metrics {
+				base := &MetricBase{}
+				Expect(mapstructure.Decode(metric, base)).To(Succeed())
+				results[base.Key] = base
+			}
+		}
+
+		return results, errs
+	}
+
+	It("should map an instant query to the report window", func() {
+		results, errs := run(model.ValVector,
+			`[{"metric":{"pod":"foo-pod","namespace":"foons"},"value":[1587340800,"42"]}]`)
+
+		Expect(errs).To(BeEmpty())
+		Expect(results).To(HaveLen(1))
+
+		for key, base := range results {
+			Expect(key.ResourceName).To(Equal("foo-pod"))
+			Expect(key.IntervalStart).To(Equal(start.Format(time.RFC3339)))
+			Expect(key.IntervalEnd).To(Equal(end.Format(time.RFC3339)))
+			Expect(base.Metrics).To(HaveKeyWithValue("count", "42"))
+		}
+	})
+
+	It("should attribute usage to the workload resource labels", func() {
+		workload.ResourceLabels = []string{"tenant", "instance"}
+		workload.AdditionalLabels = []string{"namespace", "node"}
+
+		results, errs := run(model.ValVector, `[
+			{"metric":{"pod":"foo-pod","namespace":"foons","tenant":"acme","instance":"db-1","node":"worker-0"},"value":[1587340800,"1"]},
+			{"metric":{"pod":"foo-pod","namespace":"foons","tenant":"acme"},"value":[1587340800,"1"]}
+		]`)
+
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Error()).To(ContainSubstring("can't find objName"))
+		Expect(results).To(HaveLen(1))
+
+		for key, base := range results {
+			Expect(key.ResourceName).To(Equal("acme/db-1"))
+			Expect(key.Namespace).To(Equal("foons"))
+			Expect(base.AdditionalLabels).To(Equal(map[string]interface{}{"namespace": "foons", "node": "worker-0"}))
+		}
+	})
+
+	It("should report unsupported results", func() {
+		results, errs := run(model.ValScalar, `[1587340800,"3"]`)
+
+		Expect(results).To(BeEmpty())
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Error()).To(ContainSubstring("unsupported type=scalar"))
+	})
+
+	It("should query a range metric in chunks of the report window", func() {
+		workload.MetricLabels = []marketplacev1alpha1.MeterLabelQuery{
+			{Label: "count", Query: "kube_pod_info", Aggregation: "sum", Interval: &metav1.Duration{Duration: 15 * time.Minute}},
+		}
+
+		values := []string{}
+		for t := start; t.Before(end); t = t.Add(15 * time.Minute) {
+			values = append(values, fmt.Sprintf(`[%d,"1"]`, t.Unix()))
+		}
+
+		results, errs := run(model.ValMatrix, fmt.Sprintf(
+			`[{"metric":{"pod":"foo-pod","namespace":"foons"},"values":[%s]}]`, strings.Join(values, ",")))
+
+		Expect(errs).To(BeEmpty())
+		Expect(results).To(HaveLen(96))
+
+		for key, base := range results {
+			Expect(key.ResourceName).To(Equal("foo-pod"))
+			Expect(base.Metrics).To(HaveKeyWithValue("count", "1"))
+		}
+	})
+
+	It("should write the report a rerun supersedes", func() {
+		dir, err := ioutil.TempDir("", "rerun")
+		Expect(err).To(Succeed())
+		defer os.RemoveAll(dir)
+
+		sut.Config.OutputDirectory = dir
+		sut.report = report
+		report.Status.SupersedesReportID = "c6d4b1a8-4c9e-4f0d-9e63-6f0b1f4c2a11"
+
+		writer, err := sut.NewReportWriter(uuid.New())
+		Expect(err).To(Succeed())
+		files, err := writer.Close()
+		Expect(err).To(Succeed())
+
+		data, err := ioutil.ReadFile(files[len(files)-1])
+		Expect(err).To(Succeed())
+
+		metadata := &ReportMetadata{}
+		Expect(json.Unmarshal(data, metadata)).To(Succeed())
+		Expect(metadata.SupersedesReportID).To(Equal("c6d4b1a8-4c9e-4f0d-9e63-6f0b1f4c2a11"))
+		Expect(ValidateReportFolder(filepath.Dir(files[0]))).To(Succeed())
+	})
+})
+
+var _ = Describe("queryChunks", func() {
+	start, _ := time.Parse(time.RFC3339, "2020-04-19T00:00:00Z")
+	end := start.Add(24 * time.Hour)
+
+	It("should split the window into chunks of whole intervals", func() {
+		fine := &prom.PromQuery{Start: start, End: end, Step: 15 * time.Minute}
+		coarse := &prom.PromQuery{Start: start, End: end, Step: time.Hour}
+		instant := &prom.PromQuery{Start: start, End: end, Step: 24 * time.Hour, Instant: true}
+
+		chunks := queryChunks([]*prom.PromQuery{fine, instant, coarse}, start, end)
+		Expect(chunks).To(HaveLen(2))
+		Expect(chunks[0]).To(HaveLen(3))
+		Expect(chunks[0][0]).To(Equal(instant))
+		Expect(chunks[1]).To(HaveLen(2))
+
+		Expect(chunks[0][1].Start).To(Equal(start))
+		Expect(chunks[0][1].End).To(Equal(start.Add(15*time.Hour - 15*time.Minute)))
+		Expect(chunks[0][2].End).To(Equal(start.Add(14 * time.Hour)))
+		Expect(chunks[1][0].Start).To(Equal(start.Add(15 * time.Hour)))
+		Expect(chunks[1][0].End).To(Equal(end))
+		Expect(chunks[1][1].End).To(Equal(end))
+	})
+})
+
+func BenchmarkCollectMetrics(b *testing.B) {
+	dir, err := ioutil.TempDir("", "collect")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	start, _ := time.Parse(time.RFC3339, "2020-04-19T00:00:00Z")
+	end := start.Add(24 * time.Hour)
+	workload := marketplacev1alpha1.Workload{
+		Name:         "podcount",
+		WorkloadType: marketplacev1alpha1.WorkloadTypePod,
+		MetricLabels: []marketplacev1alpha1.MeterLabelQuery{
+			{Label: "count", Query: "kube_pod_info", Aggregation: "sum", Interval: &metav1.Duration{Duration: 15 * time.Minute}},
+		},
+	}
+	mdef := marketplacev1alpha1.MeterDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "foons"},
+		Spec: marketplacev1alpha1.MeterDefinitionSpec{
+			Group:     "apps.partner.metering.com",
+			Kind:      "App",
+			Workloads: []marketplacev1alpha1.Workload{workload},
+		},
+	}
+
+	queries, err := prom.NewPromQueries(
+		types.NamespacedName{Name: mdef.Name, Namespace: mdef.Namespace},
+		workload, workload.MetricLabels[0], start, end)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	matrix := model.Matrix{}
+	for i := 0; i < 500; i++ {
+		series := &model.SampleStream{Metric: model.Metric{
+			"pod":       model.LabelValue(fmt.Sprintf("pod-%d", i)),
+			"namespace": "foons",
+		}}
+		for t := start; t.Before(end); t = t.Add(15 * time.Minute) {
+			series.Values = append(series.Values, model.SamplePair{Timestamp: model.TimeFromUnix(t.Unix()), Value: 1})
+		}
+		matrix = append(matrix, series)
+	}
+
+	result, err := json.Marshal(matrix)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	data, err := json.Marshal(&RecordedResponse{
+		Query:  queries[0].String(),
+		Status: "success",
+		Data:   json.RawMessage(fmt.Sprintf(`{"resultType":"matrix","result":%s}`, result)),
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	fixtureDir := filepath.Join(dir, "fixture")
+	if err := os.Mkdir(fixtureDir, 0700); err != nil {
+		b.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(fixtureDir, "response.json"), data, 0600); err != nil {
+		b.Fatal(err)
+	}
+
+	fixture, err := LoadPrometheusFixture(fixtureDir)
+	if err != nil {
+		b.Fatal(err)
+	}
+	apiClient, err := NewFixtureClient(fixture)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	cfg := &Config{}
+	cfg.SetDefaults()
+
+	sut := &MarketplaceReporter{
+		api:    v1.NewAPI(apiClient),
+		Config: cfg,
+		mktconfig: &marketplacev1alpha1.MarketplaceConfig{
+			Spec: marketplacev1alpha1.MarketplaceConfigSpec{ClusterUUID: "foo-id"},
+		},
+		report: &marketplacev1alpha1.MeterReport{
+			Spec: marketplacev1alpha1.MeterReportSpec{
+				StartTime: metav1.Time{Time: start},
+				EndTime:   metav1.Time{Time: end},
+			},
+		},
+		meterDefinitions: []marketplacev1alpha1.MeterDefinition{mdef},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		writer, err := NewReportWriter(
+			filepath.Join(dir, fmt.Sprintf("report-%d", i)),
+			NewReportMetadata(uuid.New(), ReportSourceMetadata{}),
+			500)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := sut.CollectMetrics(context.Background(), writer); err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := writer.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
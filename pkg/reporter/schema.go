@@ -0,0 +1,400 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+//go:generate go run ../../cmd/reporter/main.go schema --outputDir ../../docs/schemas
+//go:generate go-bindata -o schema_bindata.go -prefix "../../docs/" -pkg reporter ../../docs/schemas/...
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// ReportSchemaVersion is the version of the metadata and slice file layout.
+// Bump it on any change to ReportMetadata, MetricsReport or MetricBase.
+const ReportSchemaVersion = "3"
+
+const (
+	MetadataSchemaFileName = "report-metadata.schema.json"
+	SliceSchemaFileName    = "report-slice.schema.json"
+
+	jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+)
+
+// JSONSchema is the subset of JSON Schema generated for the report files.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+}
+
+// UnmarshalJSON decodes additionalProperties back into a bool or a
+// schema so a published schema validates like a generated one.
+func (s *JSONSchema) UnmarshalJSON(data []byte) error {
+	type jsonSchema JSONSchema
+
+	doc := struct {
+		*jsonSchema
+		AdditionalProperties json.RawMessage `json:"additionalProperties,omitempty"`
+	}{jsonSchema: (*jsonSchema)(s)}
+
+	err := json.Unmarshal(data, &doc)
+
+	if err != nil {
+		return err
+	}
+
+	s.AdditionalProperties = nil
+
+	if len(doc.AdditionalProperties) == 0 {
+		return nil
+	}
+
+	var allowed bool
+
+	if json.Unmarshal(doc.AdditionalProperties, &allowed) == nil {
+		s.AdditionalProperties = allowed
+		return nil
+	}
+
+	additional := &JSONSchema{}
+	err = json.Unmarshal(doc.AdditionalProperties, additional)
+
+	if err != nil {
+		return err
+	}
+
+	s.AdditionalProperties = additional
+	return nil
+}
+
+// PublishedSchema is a schema from docs/schemas as it was built into the
+// reporter. Reports are validated against these so the files match what
+// was published, not whatever the current types generate.
+func PublishedSchema(name string) (*JSONSchema, error) {
+	data, err := Asset("schemas/" + name)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load schema %s", name)
+	}
+
+	schema := &JSONSchema{}
+	err = json.Unmarshal(data, schema)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse schema %s", name)
+	}
+
+	return schema, nil
+}
+
+// MetadataSchema is the schema of metadata.json.
+func MetadataSchema() *JSONSchema {
+	schema := generateSchema(reflect.TypeOf(ReportMetadata{}), "json")
+	schema.Schema = jsonSchemaDraft
+	schema.Title = "Red Hat Marketplace report metadata"
+	schema.Properties["schema_version"].Enum = []string{ReportSchemaVersion}
+	return schema
+}
+
+// SliceSchema is the schema of a report slice file. Metrics are written
+// with the mapstructure layout of MetricBase.
+func SliceSchema() *JSONSchema {
+	schema := generateSchema(reflect.TypeOf(MetricsReport{}), "json")
+	schema.Schema = jsonSchemaDraft
+	schema.Title = "Red Hat Marketplace report slice"
+	schema.Properties["metrics"].Items = generateSchema(reflect.TypeOf(MetricBase{}), "mapstructure")
+	return schema
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+func generateSchema(t reflect.Type, tagName string) *JSONSchema {
+	if t.Implements(textMarshalerType) {
+		schema := &JSONSchema{Type: "string"}
+		if t.Kind() == reflect.Array && t.Len() == 16 {
+			schema.Format = "uuid"
+		}
+		return schema
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return generateSchema(t.Elem(), tagName)
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: generateSchema(t.Elem(), tagName)}
+	case reflect.Map:
+		schema := &JSONSchema{Type: "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			schema.AdditionalProperties = generateSchema(t.Elem(), tagName)
+		}
+		return schema
+	case reflect.Struct:
+		schema := &JSONSchema{
+			Type:                 "object",
+			Properties:           map[string]*JSONSchema{},
+			Required:             []string{},
+			AdditionalProperties: false,
+		}
+		addStructProperties(schema, t, tagName)
+		sort.Strings(schema.Required)
+		return schema
+	default:
+		return &JSONSchema{}
+	}
+}
+
+func addStructProperties(schema *JSONSchema, t reflect.Type, tagName string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get(tagName), ",")
+		name, options := tag[0], tag[1:]
+
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+
+		if hasOption(options, "squash") || (field.Anonymous && name == "") {
+			addStructProperties(schema, field.Type, tagName)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = generateSchema(field.Type, tagName)
+
+		if !hasOption(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+func hasOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Validate checks a decoded JSON document against the schema.
+func (s *JSONSchema) Validate(data interface{}) error {
+	return errors.Combine(s.validate("$", data)...)
+}
+
+func (s *JSONSchema) validate(path string, data interface{}) []error {
+	switch s.Type {
+	case "object":
+		obj, ok := data.(map[string]interface{})
+
+		if !ok {
+			return []error{errors.Errorf("%s must be an object", path)}
+		}
+
+		errs := []error{}
+
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				errs = append(errs, errors.Errorf("%s.%s is required", path, name))
+			}
+		}
+
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fieldPath := fmt.Sprintf("%s.%s", path, key)
+
+			if prop, ok := s.Properties[key]; ok {
+				errs = append(errs, prop.validate(fieldPath, obj[key])...)
+				continue
+			}
+
+			switch additional := s.AdditionalProperties.(type) {
+			case bool:
+				if !additional {
+					errs = append(errs, errors.Errorf("%s is not allowed", fieldPath))
+				}
+			case *JSONSchema:
+				errs = append(errs, additional.validate(fieldPath, obj[key])...)
+			}
+		}
+
+		return errs
+	case "array":
+		arr, ok := data.([]interface{})
+
+		if !ok {
+			return []error{errors.Errorf("%s must be an array", path)}
+		}
+
+		errs := []error{}
+
+		if s.Items != nil {
+			for i, item := range arr {
+				errs = append(errs, s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+
+		return errs
+	case "string":
+		str, ok := data.(string)
+
+		if !ok {
+			return []error{errors.Errorf("%s must be a string", path)}
+		}
+
+		if s.Format == "uuid" && !uuidPattern.MatchString(str) {
+			return []error{errors.Errorf("%s must be a uuid", path)}
+		}
+
+		if len(s.Enum) != 0 && !hasOption(s.Enum, str) {
+			return []error{errors.Errorf("%s must be one of %v", path, s.Enum)}
+		}
+	case "integer":
+		num, ok := data.(float64)
+
+		if !ok || num != float64(int64(num)) {
+			return []error{errors.Errorf("%s must be an integer", path)}
+		}
+	case "number":
+		if _, ok := data.(float64); !ok {
+			return []error{errors.Errorf("%s must be a number", path)}
+		}
+	case "boolean":
+		if _, ok := data.(bool); !ok {
+			return []error{errors.Errorf("%s must be a boolean", path)}
+		}
+	}
+
+	return nil
+}
+
+// ValidateReportFolder validates every file of a written report against
+// the published metadata and slice schemas.
+func ValidateReportFolder(dir string) error {
+	metadataSchema, err := PublishedSchema(MetadataSchemaFileName)
+
+	if err != nil {
+		return err
+	}
+
+	sliceSchema, err := PublishedSchema(SliceSchemaFileName)
+
+	if err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+
+	if err != nil {
+		return errors.Wrap(err, "failed to list report files")
+	}
+
+	errs := []error{}
+
+	for _, file := range files {
+		schema := sliceSchema
+
+		if filepath.Base(file) == metadataFileName {
+			schema = metadataSchema
+		}
+
+		data, err := ioutil.ReadFile(file)
+
+		if err != nil {
+			return errors.Wrap(err, "failed to read report file")
+		}
+
+		var doc interface{}
+		err = json.Unmarshal(data, &doc)
+
+		if err == nil {
+			err = schema.Validate(doc)
+		}
+
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "%s is invalid", filepath.Base(file)))
+		}
+	}
+
+	return errors.Combine(errs...)
+}
+
+// WriteSchemas writes the report schemas to the directory.
+func WriteSchemas(dir string) error {
+	schemas := map[string]*JSONSchema{
+		MetadataSchemaFileName: MetadataSchema(),
+		SliceSchemaFileName:    SliceSchema(),
+	}
+
+	for name, schema := range schemas {
+		data, err := MarshalSchema(schema)
+
+		if err != nil {
+			return err
+		}
+
+		err = ioutil.WriteFile(filepath.Join(dir, name), data, 0644)
+
+		if err != nil {
+			return errors.Wrapf(err, "failed to write %s", name)
+		}
+	}
+
+	return nil
+}
+
+func MarshalSchema(schema *JSONSchema) ([]byte, error) {
+	data, err := json.MarshalIndent(schema, "", "  ")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal schema")
+	}
+
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,269 @@
+// Code generated for package reporter by go-bindata DO NOT EDIT. (@generated)
+// sources:
+// ../../docs/schemas/report-metadata.schema.json
+// ../../docs/schemas/report-slice.schema.json
+package reporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func bindataRead(data []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, gz)
+	clErr := gz.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+	if clErr != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type asset struct {
+	bytes []byte
+	info  os.FileInfo
+}
+
+type bindataFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// Name return file name
+func (fi bindataFileInfo) Name() string {
+	return fi.name
+}
+
+// Size return file size
+func (fi bindataFileInfo) Size() int64 {
+	return fi.size
+}
+
+// Mode return file mode
+func (fi bindataFileInfo) Mode() os.FileMode {
+	return fi.mode
+}
+
+// Mode return file modify time
+func (fi bindataFileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+
+// IsDir return file whether a directory
+func (fi bindataFileInfo) IsDir() bool {
+	return fi.mode&os.ModeDir != 0
+}
+
+// Sys return file is sys mode
+func (fi bindataFileInfo) Sys() interface{} {
+	return nil
+}
+
+var _schemasReportMetadataSchemaJson = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x96\xdf\x8b\xd4\x30\x10\xc7\xdf\xf3\x57\x84\xd1\xc7\x3d\x57\x14\x15\xee\x4d\x44\xd0\x07\x41\x7c\x95\xa3\xe4\xda\xd9\xdd\x9c\x6d\x52\x27\xd3\x85\x43\xfa\xbf\x4b\xb7\x3f\x68\xd3\x24\x5b\x4f\x91\xdd\xa7\x69\xe7\x33\xdf\xcc\x7c\x3b\xed\x2f\x21\x25\x3c\x77\xf9\x09\x2b\x05\xb7\x12\x4e\xcc\xf5\xed\x7e\xff\xe0\xac\xb9\xe9\xa3\x2f\x2c\x1d\xf7\x05\xa9\x03\xdf\xbc\x7c\xb7\xef\x63\xcf\x60\xd7\xe5\xb1\xe6\x12\xbb\xac\x6f\x58\xc8\x4f\x8a\xe5\x17\x45\x3f\x90\xeb\x52\xe5\x28\x09\x6b\x4b\x2c\x2b\x64\x55\x28\x56\x43\xc6\x63\x7d\x49\xb0\xf7\x0f\x98\x73\x1f\xab\xc9\xd6\x48\xac\xd1\xc1\xad\xec\xf4\x48\x09\xb9\x3d\x23\xa9\x23\x4e\x91\x70\xae\x94\xe1\xfc\xee\x07\x47\x55\x2f\x23\x33\x86\x22\x52\x8f\x13\xa2\xfb\x83\x66\xac\xfc\xfb\xe3\x55\xd3\xb5\x87\xab\x68\x8a\x40\x78\x06\x75\x4c\xda\x1c\xc1\xbb\xa1\x5d\x16\x91\x12\x1c\x2b\xe2\x27\xa1\x44\x02\x0c\x84\x3f\x1b\x4d\xd8\x89\xfc\x1e\xd2\x1e\xd1\xb1\x88\xde\x79\x4c\x55\x14\x9a\xb5\x35\xaa\xfc\x3a\xef\xcd\x41\x95\x0e\x67\x77\xb6\x22\x20\x0a\x2a\x64\xa4\xac\xc0\x83\x36\x17\x88\xdf\xd5\xff\x3c\xbf\x80\x83\x3c\xea\x5a\x47\x52\xcd\x16\x4d\x5b\x94\x5d\xf1\x97\x57\x26\x6c\x8d\x55\xf7\x03\x93\xfe\x5b\xb8\xd8\x58\x30\xe5\xc4\x84\x1f\xe3\xae\x0c\x7a\xf3\xcf\x1c\x1a\x3e\xc2\x4a\x3e\x18\x55\xcd\xd7\xd4\xe6\x16\x85\x49\xae\x56\xf9\x3f\xc2\xd5\x48\x39\x9a\x2b\x6b\xc3\x34\xd5\x3d\xd2\x0a\x26\x12\xe8\xe4\xda\xe8\xce\x00\x89\x93\x45\x55\x8a\xc4\xe0\xb6\x8e\xac\x15\x01\xc9\x91\x3e\x44\x3b\xd0\x0a\x0f\x11\x3e\xaf\x2f\x7c\x92\x7c\x5d\xec\x40\x86\xfe\x0d\x99\xe9\xf9\x43\xec\x4f\x79\xa2\x1e\x2c\x55\xaa\x1b\x26\x34\x8d\x2e\x20\x04\x72\xa5\xce\xd1\x85\x60\xde\xa2\x89\x49\x1c\xf3\xe2\x99\xa9\xc5\x34\x74\x32\xab\x90\x49\xe7\xfe\xd5\x19\x53\x1b\xc6\xe3\xd2\x74\xed\x6e\x0e\x72\x27\xf5\xea\xcd\xdb\x38\x60\xfd\x04\x84\x27\x1f\x31\xaa\x2f\x54\x04\x6c\x77\x7d\x8a\x63\xd1\xa1\x20\xf4\x5f\x46\xd9\x19\xc9\x69\x6b\x66\xe2\x7d\xd9\x63\x11\x40\xd3\x54\x4b\x4f\xbd\x1e\xb5\xdc\x2d\xd1\xb6\xa1\x1c\xb7\x20\x53\x2e\xe9\x29\xd9\xf4\x41\xb6\xc1\x27\x91\x59\x03\x9d\xaa\xf7\x79\x6e\x1b\xc3\x9f\xfd\x37\x90\x2f\x6d\xba\xd4\xee\x16\xf9\x1f\xca\xc6\x31\xd2\x93\xf3\x3f\x9a\xb3\x26\x6b\xaa\xc4\x93\x9d\x22\xac\xc7\x94\x4e\x15\x1e\x22\xb2\x14\x16\x8d\x89\x1d\x58\x78\x6e\xbb\xee\xb5\xa1\x2c\xb8\xa6\x46\x72\x58\xa0\xcb\xb6\x2c\x0f\x31\x4a\xbf\xe4\xaf\x25\xcf\x36\xd0\x6e\x11\x18\x36\x49\xd8\xd9\x63\xf4\x62\x27\x88\x98\x4b\x0c\xe7\x4b\x9f\xad\x15\xbf\x07\x00\x0f\x5d\x23\xe1\x80\x0c\x00\x00")
+
+func schemasReportMetadataSchemaJsonBytes() ([]byte, error) {
+	return bindataRead(
+		_schemasReportMetadataSchemaJson,
+		"schemas/report-metadata.schema.json",
+	)
+}
+
+func schemasReportMetadataSchemaJson() (*asset, error) {
+	bytes, err := schemasReportMetadataSchemaJsonBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "schemas/report-metadata.schema.json", size: 3200, mode: os.FileMode(420), modTime: time.Unix(1792278308, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _schemasReportSliceSchemaJson = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x54\xc1\x6e\xdb\x30\x0c\xbd\xfb\x2b\x04\x6d\xc7\x76\xde\x6d\x40\xbe\x60\x87\x15\x18\x06\xec\x34\x0c\x06\x6b\x31\x09\x6b\x59\xd2\x28\xba\x43\x31\xe4\xdf\x07\xd9\xce\xe2\x38\x8a\x81\xc4\xbb\xf2\x91\xef\x89\xe4\xa3\xfe\x14\x4a\xe9\xf7\xb1\xde\x63\x0b\x7a\xa3\xf4\x5e\x24\x6c\xca\xf2\x25\x7a\xf7\x38\x44\x3f\x78\xde\x95\x86\x61\x2b\x8f\x1f\x3f\x95\x43\xec\x9d\x7e\x48\x75\x42\x62\x31\x55\x7d\x43\xa3\x3e\x83\xa8\x27\xe0\x06\x25\x58\xa8\x51\x31\x06\xcf\xa2\xa2\xa5\x1a\xc7\xf4\xb7\xd0\x67\xfb\xe7\x17\xac\x65\x88\x05\xf6\x01\x59\x08\xa3\xde\xa8\xf4\x18\xa5\x74\x8b\xc2\x54\x9f\x02\x93\x52\x60\x86\xb7\xbe\xb2\x0f\x93\x60\x3b\xcd\xcb\x8b\x8c\x48\x46\x6a\x44\xc0\x18\x12\xf2\x0e\xec\x17\x78\x46\x3b\xc7\x2f\x59\x27\xe0\xe1\xa4\xa0\x94\x36\xbe\x05\x72\xd7\xeb\xa3\x30\xb9\xdd\xf5\x7a\x72\x82\xfc\x0a\xb6\x42\x67\xfe\x03\x4b\x14\x60\xb9\x9f\xa7\xa1\x35\xaf\x18\xd6\x58\xd1\x0a\x0a\x07\x2d\xc6\x00\x35\xde\x4f\x31\xd8\xb0\x0a\xc8\xe4\xcd\xba\xb1\x9e\x53\xad\x9c\x2d\x63\xf4\x1d\xd7\x58\xa5\x1e\x57\xd0\xec\xdb\xef\x11\x76\xf8\x74\x71\x33\x37\x3a\xf7\x15\x39\x92\x5f\x61\xdd\xdf\x9e\x1b\xeb\xe1\xb6\xf9\x16\x19\x2e\xcd\xf8\xab\x23\xc6\xc4\xf4\x63\xf1\x4c\x73\xa7\x97\x3f\x84\xb4\xf7\x3c\x32\xac\xf1\x0c\x6b\x68\x9e\x7d\xb2\xf2\x82\x21\xd0\x2d\xc2\x19\xa1\xf9\xf2\xfe\x61\x3f\x1f\x8a\x4c\xd7\x5f\xa7\x1f\xd8\x16\x6c\xc4\x31\xeb\x50\x4c\x46\x78\x94\xed\x3f\xde\xf3\xeb\x9b\x6f\xe2\xa8\xa2\xb7\x9e\x5b\x48\x66\xd6\x5d\x47\x66\x70\x5b\x22\xed\xbd\x76\xb9\x8f\x71\x1e\x51\x5f\x51\x2c\xc6\x0e\x96\xdf\x7e\x28\xfe\x0e\x00\xcb\x2a\xbd\xe8\x7d\x06\x00\x00")
+
+func schemasReportSliceSchemaJsonBytes() ([]byte, error) {
+	return bindataRead(
+		_schemasReportSliceSchemaJson,
+		"schemas/report-slice.schema.json",
+	)
+}
+
+func schemasReportSliceSchemaJson() (*asset, error) {
+	bytes, err := schemasReportSliceSchemaJsonBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "schemas/report-slice.schema.json", size: 1661, mode: os.FileMode(420), modTime: time.Unix(1792278308, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+// Asset loads and returns the asset for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func Asset(name string) ([]byte, error) {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[cannonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("Asset %s can't read by error: %v", name, err)
+		}
+		return a.bytes, nil
+	}
+	return nil, fmt.Errorf("Asset %s not found", name)
+}
+
+// MustAsset is like Asset but panics when Asset would return an error.
+// It simplifies safe initialization of global variables.
+func MustAsset(name string) []byte {
+	a, err := Asset(name)
+	if err != nil {
+		panic("asset: Asset(" + name + "): " + err.Error())
+	}
+
+	return a
+}
+
+// AssetInfo loads and returns the asset info for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func AssetInfo(name string) (os.FileInfo, error) {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[cannonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("AssetInfo %s can't read by error: %v", name, err)
+		}
+		return a.info, nil
+	}
+	return nil, fmt.Errorf("AssetInfo %s not found", name)
+}
+
+// AssetNames returns the names of the assets.
+func AssetNames() []string {
+	names := make([]string, 0, len(_bindata))
+	for name := range _bindata {
+		names = append(names, name)
+	}
+	return names
+}
+
+// _bindata is a table, holding each asset generator, mapped to its name.
+var _bindata = map[string]func() (*asset, error){
+	"schemas/report-metadata.schema.json": schemasReportMetadataSchemaJson,
+	"schemas/report-slice.schema.json":    schemasReportSliceSchemaJson,
+}
+
+// AssetDir returns the file names below a certain
+// directory embedded in the file by go-bindata.
+// For example if you run go-bindata on data/... and data contains the
+// following hierarchy:
+//     data/
+//       foo.txt
+//       img/
+//         a.png
+//         b.png
+// then AssetDir("data") would return []string{"foo.txt", "img"}
+// AssetDir("data/img") would return []string{"a.png", "b.png"}
+// AssetDir("foo.txt") and AssetDir("notexist") would return an error
+// AssetDir("") will return []string{"data"}.
+func AssetDir(name string) ([]string, error) {
+	node := _bintree
+	if len(name) != 0 {
+		cannonicalName := strings.Replace(name, "\\", "/", -1)
+		pathList := strings.Split(cannonicalName, "/")
+		for _, p := range pathList {
+			node = node.Children[p]
+			if node == nil {
+				return nil, fmt.Errorf("Asset %s not found", name)
+			}
+		}
+	}
+	if node.Func != nil {
+		return nil, fmt.Errorf("Asset %s not found", name)
+	}
+	rv := make([]string, 0, len(node.Children))
+	for childName := range node.Children {
+		rv = append(rv, childName)
+	}
+	return rv, nil
+}
+
+type bintree struct {
+	Func     func() (*asset, error)
+	Children map[string]*bintree
+}
+
+var _bintree = &bintree{nil, map[string]*bintree{
+	"schemas": &bintree{nil, map[string]*bintree{
+		"report-metadata.schema.json": &bintree{schemasReportMetadataSchemaJson, map[string]*bintree{}},
+		"report-slice.schema.json":    &bintree{schemasReportSliceSchemaJson, map[string]*bintree{}},
+	}},
+}}
+
+// RestoreAsset restores an asset under the given directory
+func RestoreAsset(dir, name string) error {
+	data, err := Asset(name)
+	if err != nil {
+		return err
+	}
+	info, err := AssetInfo(name)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(_filePath(dir, filepath.Dir(name)), os.FileMode(0755))
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(_filePath(dir, name), data, info.Mode())
+	if err != nil {
+		return err
+	}
+	err = os.Chtimes(_filePath(dir, name), info.ModTime(), info.ModTime())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RestoreAssets restores an asset under the given directory recursively
+func RestoreAssets(dir, name string) error {
+	children, err := AssetDir(name)
+	// File
+	if err != nil {
+		return RestoreAsset(dir, name)
+	}
+	// Dir
+	for _, child := range children {
+		err = RestoreAssets(dir, filepath.Join(name, child))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func _filePath(dir, name string) string {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	return filepath.Join(append([]string{dir}, strings.Split(cannonicalName, "/")...)...)
+}
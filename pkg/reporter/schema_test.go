@@ -0,0 +1,150 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schema", func() {
+	var (
+		dir    string
+		report string
+	)
+
+	decode := func(file string) map[string]interface{} {
+		data, err := ioutil.ReadFile(file)
+		Expect(err).To(Succeed())
+
+		doc := map[string]interface{}{}
+		Expect(json.Unmarshal(data, &doc)).To(Succeed())
+		return doc
+	}
+
+	encode := func(file string, doc map[string]interface{}) {
+		data, err := json.Marshal(doc)
+		Expect(err).To(Succeed())
+		Expect(ioutil.WriteFile(file, data, 0600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "schema")
+		Expect(err).To(Succeed())
+
+		report = filepath.Join(dir, "report")
+		metadata := NewReportMetadata(uuid.New(), ReportSourceMetadata{
+			RhmClusterID: "foo-id",
+			RhmAccountID: "bar-id",
+		})
+		sut, err := NewReportWriter(report, metadata, 10)
+		Expect(err).To(Succeed())
+
+		key := MetricKey{
+			ReportPeriodStart: "2020-04-19T00:00:00Z",
+			ReportPeriodEnd:   "2020-04-20T00:00:00Z",
+			IntervalStart:     "2020-04-19T00:00:00Z",
+			IntervalEnd:       "2020-04-19T01:00:00Z",
+			MeterDomain:       "apps.partner.metering.com",
+			MeterKind:         "App",
+		}
+		key.Init("foo-id")
+		base := &MetricBase{Key: key}
+		Expect(base.AddAdditionalLabels()).To(Succeed())
+		Expect(base.AddMetrics("rpc_durations_seconds_count", 42.0)).To(Succeed())
+		Expect(sut.Write(base)).To(Succeed())
+
+		_, err = sut.Close()
+		Expect(err).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should match the published schemas", func() {
+		for name, schema := range map[string]*JSONSchema{
+			MetadataSchemaFileName: MetadataSchema(),
+			SliceSchemaFileName:    SliceSchema(),
+		} {
+			expected, err := MarshalSchema(schema)
+			Expect(err).To(Succeed())
+
+			published, err := ioutil.ReadFile(filepath.Join("../../docs/schemas", name))
+			Expect(err).To(Succeed())
+			Expect(string(published)).To(Equal(string(expected)), "%s is out of date, run go generate", name)
+		}
+	})
+
+	It("should build in the published schemas", func() {
+		for _, name := range []string{MetadataSchemaFileName, SliceSchemaFileName} {
+			schema, err := PublishedSchema(name)
+			Expect(err).To(Succeed())
+
+			built, err := MarshalSchema(schema)
+			Expect(err).To(Succeed())
+
+			published, err := ioutil.ReadFile(filepath.Join("../../docs/schemas", name))
+			Expect(err).To(Succeed())
+			Expect(string(built)).To(Equal(string(published)), "%s is out of date, run go generate", name)
+		}
+	})
+
+	It("should validate a written report", func() {
+		Expect(decode(filepath.Join(report, metadataFileName))).To(HaveKeyWithValue("schema_version", ReportSchemaVersion))
+		Expect(ValidateReportFolder(report)).To(Succeed())
+	})
+
+	It("should reject a renamed metric key field", func() {
+		files, err := filepath.Glob(filepath.Join(report, "*.json"))
+		Expect(err).To(Succeed())
+
+		for _, file := range files {
+			if filepath.Base(file) == metadataFileName {
+				continue
+			}
+
+			doc := decode(file)
+			metric := doc["metrics"].([]interface{})[0].(map[string]interface{})
+			metric["metricId"] = metric["metric_id"]
+			delete(metric, "metric_id")
+			encode(file, doc)
+		}
+
+		err = ValidateReportFolder(report)
+		Expect(err).ToNot(Succeed())
+		Expect(err.Error()).To(ContainSubstring("$.metrics[0].metric_id is required"))
+		Expect(err.Error()).To(ContainSubstring("$.metrics[0].metricId is not allowed"))
+	})
+
+	It("should reject an unknown schema version", func() {
+		file := filepath.Join(report, metadataFileName)
+		doc := decode(file)
+		doc["schema_version"] = "0"
+		encode(file, doc)
+
+		err := ValidateReportFolder(report)
+		Expect(err).ToNot(Succeed())
+		Expect(err.Error()).To(ContainSubstring(fmt.Sprintf("$.schema_version must be one of [%s]", ReportSchemaVersion)))
+	})
+})
@@ -0,0 +1,182 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/managers"
+	. "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils/reconcileutils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// signingKeySecretKey is the key of the PEM encoded private key in the
+	// signing secret.
+	signingKeySecretKey = "signing.key"
+
+	metadataFileName  = "metadata.json"
+	signatureFileName = "metadata.json.sig"
+)
+
+// SigningConfig configures the detached signature over the report metadata.
+type SigningConfig struct {
+	KeySecret types.NamespacedName
+}
+
+// ReportSigner signs the report metadata. The metadata holds the digest of
+// every slice, so the signature covers the whole report.
+type ReportSigner struct {
+	signer crypto.Signer
+}
+
+// NewReportSigner parses a PEM encoded ed25519, RSA or ECDSA private key.
+func NewReportSigner(keyPEM []byte) (*ReportSigner, error) {
+	block, _ := pem.Decode(keyPEM)
+
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+
+	var key interface{}
+	var err error
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse signing key")
+	}
+
+	signer, ok := key.(crypto.Signer)
+
+	if !ok {
+		return nil, errors.Errorf("signing key type %T is not supported", key)
+	}
+
+	return &ReportSigner{signer: signer}, nil
+}
+
+// Sign returns the base64 encoded signature of the data.
+func (s *ReportSigner) Sign(data []byte) ([]byte, error) {
+	var sig []byte
+	var err error
+
+	switch s.signer.(type) {
+	case ed25519.PrivateKey:
+		sig, err = s.signer.Sign(rand.Reader, data, crypto.Hash(0))
+	default:
+		digest := sha256.Sum256(data)
+		sig, err = s.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign")
+	}
+
+	return []byte(base64.StdEncoding.EncodeToString(sig)), nil
+}
+
+// VerifySignature checks a base64 encoded signature of the data against a
+// PEM encoded public key.
+func VerifySignature(publicKeyPEM []byte, data []byte, signature []byte) error {
+	block, _ := pem.Decode(publicKeyPEM)
+
+	if block == nil {
+		return errors.New("public key is not PEM encoded")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to parse public key")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+
+	if err != nil {
+		return errors.Wrap(err, "signature is not base64 encoded")
+	}
+
+	digest := sha256.Sum256(data)
+
+	switch pub := key.(type) {
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, data, sig) {
+			return errors.New("signature does not match")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+			return errors.Wrap(err, "signature does not match")
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest[:], sig) {
+			return errors.New("signature does not match")
+		}
+	default:
+		return errors.Errorf("public key type %T is not supported", key)
+	}
+
+	return nil
+}
+
+// ProvideReportSigner reads the signing key from the configured secret. No
+// signer is returned if signing is not configured.
+func ProvideReportSigner(
+	ctx context.Context,
+	cc ClientCommandRunner,
+	log logr.Logger,
+	isCacheStarted managers.CacheIsStarted,
+	reporterConfig *Config,
+) (*ReportSigner, error) {
+	if reporterConfig.Signing.KeySecret.Name == "" {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	result, _ := cc.Do(ctx, GetAction(reporterConfig.Signing.KeySecret, secret))
+
+	if !result.Is(Continue) {
+		return nil, errors.Wrap(result, "failed to get signing key secret")
+	}
+
+	key, ok := secret.Data[signingKeySecretKey]
+
+	if !ok {
+		return nil, errors.Errorf("%s is not found in secret", signingKeySecretKey)
+	}
+
+	log.Info("retrieved signing key", "secret", reporterConfig.Signing.KeySecret)
+	return NewReportSigner(key)
+}
@@ -0,0 +1,267 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+const (
+	spoolIndexFile = "index.json"
+	spoolLockFile  = ".lock"
+)
+
+// ErrSpoolLocked is returned when another process holds the spool.
+var ErrSpoolLocked = errors.New("spool is locked by another process")
+
+// Spool is a directory of report tarballs waiting to be uploaded. The
+// index file keeps the order the reports were spooled in and which targets
+// each report was already uploaded to, so a report is never sent to the
+// same target twice.
+type Spool struct {
+	Directory string
+}
+
+type SpoolIndex struct {
+	Entries []*SpoolEntry `json:"entries"`
+}
+
+type SpoolEntry struct {
+	ReportName      ReportName `json:"reportName"`
+	ReportID        string     `json:"reportID"`
+	File            string     `json:"file"`
+	SpooledTime     time.Time  `json:"spooledTime"`
+	UploadedTargets []string   `json:"uploadedTargets,omitempty"`
+	UploadedTime    *time.Time `json:"uploadedTime,omitempty"`
+	Held            bool       `json:"held,omitempty"`
+}
+
+func (e *SpoolEntry) IsUploadedTo(target UploaderTarget) bool {
+	for _, t := range e.UploadedTargets {
+		if t == target.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *SpoolEntry) IsUploadedToAll(targets UploaderTargets) bool {
+	for _, target := range targets {
+		if !e.IsUploadedTo(target) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *SpoolEntry) IsDone() bool {
+	return e.UploadedTime != nil
+}
+
+// SpoolOption sets a field of a new spool entry.
+type SpoolOption func(entry *SpoolEntry)
+
+// SpoolUploadedTo records targets the report was already uploaded to, so
+// they are skipped when the spool is drained.
+func SpoolUploadedTo(targets ...string) SpoolOption {
+	return func(entry *SpoolEntry) {
+		entry.UploadedTargets = append(entry.UploadedTargets, targets...)
+	}
+}
+
+// SpoolHeld holds the report in the spool until it is released.
+func SpoolHeld() SpoolOption {
+	return func(entry *SpoolEntry) {
+		entry.Held = true
+	}
+}
+
+func NewSpool(directory string) (*Spool, error) {
+	if directory == "" {
+		return nil, errors.New("spool directory is required")
+	}
+
+	err := os.MkdirAll(directory, 0755)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create spool directory")
+	}
+
+	return &Spool{Directory: directory}, nil
+}
+
+// Lock takes an exclusive flock on the spool's lock file. The returned
+// func releases it. The kernel drops the lock when the holder exits, so a
+// crashed reporter never leaves a stale lock behind.
+func (s *Spool) Lock() (func(), error) {
+	lockFile := filepath.Join(s.Directory, spoolLockFile)
+	f, err := os.OpenFile(lockFile, os.O_CREATE|os.O_RDWR, 0600)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to lock spool")
+	}
+
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+
+	if err == syscall.EWOULDBLOCK {
+		f.Close()
+		return nil, ErrSpoolLocked
+	}
+
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "failed to lock spool")
+	}
+
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// Add copies the report tarball into the spool and appends it to the index.
+func (s *Spool) Add(
+	reportName ReportName,
+	reportID string,
+	fileName string,
+	opts ...SpoolOption,
+) (*SpoolEntry, error) {
+	index, err := s.ReadIndex()
+
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &SpoolEntry{
+		ReportName:  reportName,
+		ReportID:    reportID,
+		File:        filepath.Base(fileName),
+		SpooledTime: time.Now().UTC(),
+	}
+
+	for _, opt := range opts {
+		opt(entry)
+	}
+
+	err = copyFile(fileName, s.Path(entry))
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to copy report to spool")
+	}
+
+	index.Entries = append(index.Entries, entry)
+
+	err = s.WriteIndex(index)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// Path is the location of the entry's tarball.
+func (s *Spool) Path(entry *SpoolEntry) string {
+	return filepath.Join(s.Directory, entry.File)
+}
+
+func (s *Spool) ReadIndex() (*SpoolIndex, error) {
+	index := &SpoolIndex{}
+	data, err := ioutil.ReadFile(filepath.Join(s.Directory, spoolIndexFile))
+
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read spool index")
+	}
+
+	err = json.Unmarshal(data, index)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse spool index")
+	}
+
+	return index, nil
+}
+
+// WriteIndex replaces the index file. The new index is written to a temp
+// file and renamed so a crash never leaves a partial index behind.
+func (s *Spool) WriteIndex(index *SpoolIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal spool index")
+	}
+
+	f, err := ioutil.TempFile(s.Directory, ".index-")
+
+	if err != nil {
+		return errors.Wrap(err, "failed to create spool index")
+	}
+
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(data)
+
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "failed to write spool index")
+	}
+
+	return errors.Wrap(
+		os.Rename(f.Name(), filepath.Join(s.Directory, spoolIndexFile)),
+		"failed to write spool index")
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Spool", func() {
+	var (
+		dir, fileName string
+		sut           *Spool
+		reportName    = ReportName{Namespace: "openshift-redhat-marketplace", Name: "report-a"}
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "spool")
+		Expect(err).To(Succeed())
+
+		fileName = filepath.Join(dir, "report-a.tar.gz")
+		Expect(ioutil.WriteFile(fileName, []byte("report"), 0600)).To(Succeed())
+
+		sut, err = NewSpool(filepath.Join(dir, "spool"))
+		Expect(err).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should add reports to the index in order", func() {
+		_, err := sut.Add(reportName, "id-a", fileName)
+		Expect(err).To(Succeed())
+
+		fileNameB := filepath.Join(dir, "report-b.tar.gz")
+		Expect(ioutil.WriteFile(fileNameB, []byte("report"), 0600)).To(Succeed())
+		_, err = sut.Add(reportName, "id-b", fileNameB)
+		Expect(err).To(Succeed())
+
+		index, err := sut.ReadIndex()
+		Expect(err).To(Succeed())
+		Expect(index.Entries).To(HaveLen(2))
+		Expect(index.Entries[0].ReportID).To(Equal("id-a"))
+		Expect(index.Entries[1].ReportID).To(Equal("id-b"))
+		Expect(sut.Path(index.Entries[1])).To(BeAnExistingFile())
+	})
+
+	It("should not spool the same file twice", func() {
+		_, err := sut.Add(reportName, "id-a", fileName)
+		Expect(err).To(Succeed())
+		_, err = sut.Add(reportName, "id-a", fileName)
+		Expect(err).ToNot(Succeed())
+	})
+
+	It("should persist uploaded targets", func() {
+		entry, err := sut.Add(reportName, "id-a", fileName)
+		Expect(err).To(Succeed())
+		Expect(entry.IsUploadedTo(UploaderTargetRedHatInsights)).To(BeFalse())
+
+		index, err := sut.ReadIndex()
+		Expect(err).To(Succeed())
+		index.Entries[0].UploadedTargets = []string{UploaderTargetRedHatInsights.String()}
+		Expect(sut.WriteIndex(index)).To(Succeed())
+
+		index, err = sut.ReadIndex()
+		Expect(err).To(Succeed())
+		entry = index.Entries[0]
+		Expect(entry.IsUploadedTo(UploaderTargetRedHatInsights)).To(BeTrue())
+		Expect(entry.IsUploadedToAll(UploaderTargets{UploaderTargetRedHatInsights, UploaderTargetS3})).To(BeFalse())
+		Expect(entry.IsDone()).To(BeFalse())
+	})
+
+	It("should only be locked once", func() {
+		unlock, err := sut.Lock()
+		Expect(err).To(Succeed())
+
+		_, err = sut.Lock()
+		Expect(err).To(Equal(ErrSpoolLocked))
+
+		unlock()
+
+		unlock, err = sut.Lock()
+		Expect(err).To(Succeed())
+		unlock()
+	})
+
+	It("should not treat a leftover lock file as locked", func() {
+		lockFile := filepath.Join(sut.Directory, spoolLockFile)
+		Expect(ioutil.WriteFile(lockFile, []byte{}, 0600)).To(Succeed())
+
+		unlock, err := sut.Lock()
+		Expect(err).To(Succeed())
+		unlock()
+	})
+})
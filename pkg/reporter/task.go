@@ -18,7 +18,9 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"time"
 
 	"emperror.dev/errors"
 	"github.com/google/uuid"
@@ -30,6 +32,7 @@ import (
 	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils"
 	. "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils/reconcileutils"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -46,7 +49,8 @@ type Task struct {
 	Ctx       context.Context
 	Config    *Config
 	K8SScheme *runtime.Scheme
-	Uploader
+	Uploaders Uploaders
+	Signer    *ReportSigner
 }
 
 func (r *Task) Run() error {
@@ -63,58 +67,485 @@ func (r *Task) Run() error {
 		return err
 	}
 
-	logger.Info("starting collection")
-	metrics, errorList, err := reporter.CollectMetrics(r.Ctx)
+	reportID := uuid.New()
+	collected, err := collectReport(r.Ctx, reporter, reportID, r.Signer)
 
 	if err != nil {
-		logger.Error(err, "error collecting metrics")
 		return err
 	}
 
-	reportID := uuid.New()
+	fileName := collected.File
 
-	logger.Info("writing report", "reportID", reportID)
+	var dryRunSummary *marketplacev1alpha1.DryRunSummary
+
+	if r.Config.DryRun {
+		dryRunSummary, err = NewDryRunSummary(collected.Directory, marketplacev1alpha1.DryRunSummaryLimit)
+
+		if err != nil {
+			return errors.Wrap(err, "error summarizing dry run")
+		}
+
+		logger.Info("dry run, skipping upload", "metrics", collected.Count)
+	}
+
+	var coverageStatus *marketplacev1alpha1.ReportCoverage
+
+	if collected.Coverage != nil {
+		coverageStatus = collected.Coverage.Status(marketplacev1alpha1.CoverageLimit)
+	}
+
+	held := r.Config.Upload && r.Config.Coverage.ShouldHold(collected.Coverage)
+
+	if held {
+		coverageStatus.Held = true
+		logger.Info("coverage below minimum, holding upload",
+			"coverage", collected.Coverage.Percent,
+			"minimum", r.Config.Coverage.MinPercent)
+	}
+
+	uploadStatus := []marketplacev1alpha1.UploadDetails{}
+
+	switch {
+	case held && r.Config.SpoolDirectory != "":
+		entry, err := r.spoolReport(reportID.String(), fileName, SpoolHeld())
+
+		if err != nil {
+			return err
+		}
+
+		fileName = entry
+	case held:
+	case r.Config.Upload && r.Config.Spool:
+		entry, err := r.spoolReport(reportID.String(), fileName)
+
+		if err != nil {
+			return err
+		}
+
+		fileName = entry
+		uploadStatus = pendingUploadStatus(r.Config.UploaderTargets)
+	case r.Config.Upload:
+		fileName, uploadStatus, err = r.uploadReport(reportID.String(), fileName)
+
+		if err != nil {
+			return err
+		}
+
+		logger.Info("uploaded metrics", "metrics", collected.Count)
+	}
+
+	err = r.updateReportStatus(r.ReportName, func(report *marketplacev1alpha1.MeterReport) {
+		report.Status.MetricUploadCount = ptr.Int(collected.Count)
+		report.Status.ReportFile = filepath.Clean(fileName)
+		report.Status.ReportID = collected.ReportID
+		report.Status.DryRunSummary = dryRunSummary
+		report.Status.Coverage = coverageStatus
+
+		report.Status.QueryErrorList = []string{}
+
+		for _, err := range collected.Errors {
+			report.Status.QueryErrorList = append(report.Status.QueryErrorList, err.Error())
+		}
 
-	files, err := reporter.WriteReport(
-		reportID,
-		metrics)
+		r.setUploadStatus(report, uploadStatus)
+	})
 
 	if err != nil {
-		return errors.Wrap(err, "error writing report")
+		log.Error(err, "failed to update report")
 	}
 
-	dirpath := filepath.Dir(files[0])
-	fileName := fmt.Sprintf("%s/../upload-%s.tar.gz", dirpath, reportID.String())
-	err = TargzFolder(dirpath, fileName)
+	return r.uploadsFailed(uploadStatus)
+}
 
-	logger.Info("tarring", "outputfile", fileName)
+// RetryFailedUploads uploads the report file recorded on the MeterReport
+// to every target whose last upload failed. Prometheus is not queried.
+func (r *Task) RetryFailedUploads() error {
+	logger.Info("retry uploads start")
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	r.Cache.WaitForCacheSync(stopCh)
+
+	report, err := getMarketplaceReport(r.Ctx, r.CC, r.ReportName)
+
+	if err != nil {
+		return err
+	}
+
+	targets := report.Status.FailedUploadTargets()
+
+	if len(targets) == 0 {
+		logger.Info("no failed uploads to retry")
+		return nil
+	}
+
+	fileName := report.Status.ReportFile
+
+	if fileName == "" {
+		return errors.New("report does not have a report file to retry")
+	}
+
+	if _, err := os.Stat(fileName); err != nil {
+		return errors.Wrap(err, "report file is not available")
+	}
+
+	uploadTargets := make(UploaderTargets, 0, len(targets))
+	for _, target := range targets {
+		uploadTargets = append(uploadTargets, UploaderTarget(target))
+	}
+
+	uploadStatus := r.upload(fileName, uploadTargets)
+
+	err = r.updateReportStatus(r.ReportName, func(report *marketplacev1alpha1.MeterReport) {
+		r.setUploadStatus(report, uploadStatus)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "failed to update report")
+	}
+
+	for _, details := range uploadStatus {
+		if details.Status != marketplacev1alpha1.UploadStatusSuccess {
+			return errors.Errorf("failed to upload to %s: %s", details.Target, details.Error)
+		}
+	}
+
+	return nil
+}
+
+// ReleaseHeld releases the report's upload held in the spool for low
+// coverage. The report is uploaded by the next upload-pending run.
+func (r *Task) ReleaseHeld() error {
+	logger.Info("release held start")
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	r.Cache.WaitForCacheSync(stopCh)
+
+	spool, err := NewSpool(r.Config.SpoolDirectory)
+
+	if err != nil {
+		return err
+	}
+
+	unlock, err := spool.Lock()
+
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	index, err := spool.ReadIndex()
+
+	if err != nil {
+		return err
+	}
+
+	released := false
+	for _, entry := range index.Entries {
+		if entry.ReportName == r.ReportName && entry.Held {
+			entry.Held = false
+			released = true
+		}
+	}
+
+	if !released {
+		return errors.New("report does not have a held upload in the spool")
+	}
+
+	if err := spool.WriteIndex(index); err != nil {
+		return err
+	}
+
+	return r.updateReportStatus(r.ReportName, func(report *marketplacev1alpha1.MeterReport) {
+		if report.Status.Coverage != nil {
+			report.Status.Coverage.Held = false
+		}
+
+		r.setUploadStatus(report, pendingUploadStatus(r.Config.UploaderTargets))
+	})
+}
+
+// spoolReport moves the report into the spool for a later upload-pending
+// run and returns the spooled file.
+func (r *Task) spoolReport(reportID string, fileName string, opts ...SpoolOption) (string, error) {
+	spool, err := NewSpool(r.Config.SpoolDirectory)
+
+	if err != nil {
+		return "", err
+	}
+
+	unlock, err := spool.Lock()
+
+	if err != nil {
+		return "", err
+	}
+
+	defer unlock()
+
+	entry, err := spool.Add(r.ReportName, reportID, fileName, opts...)
+
+	if err != nil {
+		return "", errors.Wrap(err, "error spooling report")
+	}
+
+	logger.Info("spooled report", "file", spool.Path(entry))
+	return spool.Path(entry), nil
+}
+
+// uploadReport uploads the report to every target. If a target fails and
+// a spool directory is set, the report is spooled for the failed targets
+// and their uploads are left pending; the spool skips the targets that
+// already have the report. It returns the file the report status records.
+func (r *Task) uploadReport(reportID string, fileName string) (string, []marketplacev1alpha1.UploadDetails, error) {
+	uploadStatus := r.upload(fileName, r.Config.UploaderTargets)
+
+	uploaded := []string{}
+	for _, details := range uploadStatus {
+		if details.Status == marketplacev1alpha1.UploadStatusSuccess {
+			uploaded = append(uploaded, details.Target)
+		}
+	}
+
+	if r.Config.SpoolDirectory == "" || len(uploaded) == len(uploadStatus) {
+		return fileName, uploadStatus, nil
+	}
+
+	entry, err := r.spoolReport(reportID, fileName, SpoolUploadedTo(uploaded...))
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	for i := range uploadStatus {
+		if uploadStatus[i].Status == marketplacev1alpha1.UploadStatusFailure {
+			uploadStatus[i].Status = marketplacev1alpha1.UploadStatusPending
+		}
+	}
+
+	return entry, uploadStatus, nil
+}
+
+// UploadPending drains the spool in the order reports were spooled. Each
+// successful upload is written to the spool index before the next one, so
+// a report is never sent to a target twice. Draining stops at the first
+// report that fails to upload to keep the order. Held reports are skipped
+// until they are released.
+func (r *Task) UploadPending() error {
+	logger.Info("upload pending start")
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	r.Cache.WaitForCacheSync(stopCh)
 
-	if r.Config.Upload {
-		err = r.Uploader.UploadFile(fileName)
+	spool, err := NewSpool(r.Config.SpoolDirectory)
+
+	if err != nil {
+		return err
+	}
+
+	unlock, err := spool.Lock()
+
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	index, err := spool.ReadIndex()
+
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range index.Entries {
+		if entry.IsDone() {
+			continue
+		}
+
+		log := logger.WithValues("report", entry.ReportName, "file", entry.File)
+
+		if entry.Held {
+			log.Info("skipping held report")
+			continue
+		}
+		uploadStatus := []marketplacev1alpha1.UploadDetails{}
+
+		for _, target := range r.Config.UploaderTargets {
+			if entry.IsUploadedTo(target) {
+				log.Info("skipping target already uploaded to", "target", target)
+				continue
+			}
+
+			details := r.upload(spool.Path(entry), UploaderTargets{target})[0]
+
+			if details.Status != marketplacev1alpha1.UploadStatusSuccess {
+				// the report stays in the spool, so it is not retried
+				// through the report's failed targets
+				details.Status = marketplacev1alpha1.UploadStatusPending
+				uploadStatus = append(uploadStatus, details)
+				break
+			}
+
+			uploadStatus = append(uploadStatus, details)
+
+			entry.UploadedTargets = append(entry.UploadedTargets, target.String())
+
+			if err := spool.WriteIndex(index); err != nil {
+				return err
+			}
+		}
+
+		err := r.updateReportStatus(entry.ReportName, func(report *marketplacev1alpha1.MeterReport) {
+			r.setUploadStatus(report, uploadStatus)
+		})
+
+		if err != nil {
+			log.Error(err, "failed to update report")
+		}
+
+		if !entry.IsUploadedToAll(r.Config.UploaderTargets) {
+			return errors.Errorf("stopped draining spool at %s", entry.File)
+		}
+
+		now := time.Now().UTC()
+		entry.UploadedTime = &now
+
+		if err := spool.WriteIndex(index); err != nil {
+			return err
+		}
+
+		if err := os.Remove(spool.Path(entry)); err != nil {
+			log.Error(err, "failed to remove uploaded report")
+		}
+
+		log.Info("uploaded spooled report")
+	}
+
+	return nil
+}
+
+func pendingUploadStatus(targets UploaderTargets) []marketplacev1alpha1.UploadDetails {
+	uploadStatus := make([]marketplacev1alpha1.UploadDetails, 0, len(targets))
+	for _, target := range targets {
+		uploadStatus = append(uploadStatus, marketplacev1alpha1.UploadDetails{
+			Target: target.String(),
+			Status: marketplacev1alpha1.UploadStatusPending,
+		})
+	}
+	return uploadStatus
+}
+
+// upload sends the file to each target. An error from one target does not
+// stop the upload to the others; each result is returned instead.
+func (r *Task) upload(fileName string, targets UploaderTargets) []marketplacev1alpha1.UploadDetails {
+	uploadStatus := make([]marketplacev1alpha1.UploadDetails, 0, len(targets))
+
+	for _, target := range targets {
+		details := marketplacev1alpha1.UploadDetails{
+			Target:          target.String(),
+			Status:          marketplacev1alpha1.UploadStatusSuccess,
+			LastAttemptTime: metav1.Now(),
+		}
+
+		var err error
+		var result *UploadResult
+		uploader, ok := r.Uploaders[target]
+
+		if ok {
+			result, err = uploader.UploadFile(fileName)
+		} else {
+			err = errors.Errorf("uploader target not configured %s", target)
+		}
+
+		if result != nil {
+			details.Attempts = result.Attempts
+			details.UploadID = result.ID
+		}
 
 		if err != nil {
-			return errors.Wrap(err, "error uploading file")
+			logger.Error(err, "error uploading file", "target", target, "attempts", details.Attempts)
+			details.Status = marketplacev1alpha1.UploadStatusFailure
+			details.Error = err.Error()
+		} else {
+			logger.Info("uploaded file", "target", target, "file", fileName, "attempts", details.Attempts)
+		}
+
+		uploadStatus = append(uploadStatus, details)
+	}
+
+	return uploadStatus
+}
+
+// setUploadStatus records each upload result on the report. The receipt
+// from the primary target is also recorded as the report's UploadID.
+func (r *Task) setUploadStatus(
+	report *marketplacev1alpha1.MeterReport,
+	uploadStatus []marketplacev1alpha1.UploadDetails,
+) {
+	for _, details := range uploadStatus {
+		report.Status.SetUploadStatus(details)
+
+		if details.Status != marketplacev1alpha1.UploadStatusSuccess ||
+			len(r.Config.UploaderTargets) == 0 ||
+			details.Target != r.Config.UploaderTargets[0].String() {
+			continue
 		}
 
-		logger.Info("uploaded metrics", "metrics", len(metrics))
+		uploadTime := details.LastAttemptTime
+		report.Status.UploadTime = &uploadTime
+
+		if details.UploadID != "" {
+			uploadID := types.UID(details.UploadID)
+			report.Status.UploadID = &uploadID
+		}
+
+		logger.Info("recorded upload receipt", "uploadID", details.UploadID, "target", details.Target)
 	}
+}
 
+// uploadsFailed is an error if the upload to the primary target or to
+// every target failed. Uploads left pending in the spool are not failures.
+func (r *Task) uploadsFailed(uploadStatus []marketplacev1alpha1.UploadDetails) error {
+	if len(uploadStatus) == 0 {
+		return nil
+	}
+
+	primaryFailed := false
+	errs := []error{}
+	for _, details := range uploadStatus {
+		if details.Status != marketplacev1alpha1.UploadStatusFailure {
+			continue
+		}
+
+		if len(r.Config.UploaderTargets) > 0 && details.Target == r.Config.UploaderTargets[0].String() {
+			primaryFailed = true
+		}
+
+		errs = append(errs, errors.Errorf("%s: %s", details.Target, details.Error))
+	}
+
+	if !primaryFailed && len(errs) < len(uploadStatus) {
+		return nil
+	}
+
+	return errors.Wrap(errors.Combine(errs...), "error uploading file")
+}
+
+func (r *Task) updateReportStatus(
+	reportName ReportName,
+	update func(report *marketplacev1alpha1.MeterReport),
+) error {
 	report := &marketplacev1alpha1.MeterReport{}
-	err = utils.Retry(func() error {
+	return utils.Retry(func() error {
 		result, _ := r.CC.Do(
 			r.Ctx,
 			HandleResult(
-				GetAction(types.NamespacedName(r.ReportName), report),
+				GetAction(types.NamespacedName(reportName), report),
 				OnContinue(Call(func() (ClientAction, error) {
-					report.Status.MetricUploadCount = ptr.Int(len(metrics))
-
-					report.Status.QueryErrorList = []string{}
-
-					for _, err := range errorList {
-						report.Status.QueryErrorList = append(report.Status.QueryErrorList, err.Error())
-					}
-
-					return UpdateAction(report), nil
+					update(report)
+					return UpdateAction(report, UpdateStatusOnly(true)), nil
 				})),
 			),
 		)
@@ -125,12 +556,6 @@ func (r *Task) Run() error {
 
 		return nil
 	}, 3)
-
-	if err != nil {
-		log.Error(err, "failed to update report")
-	}
-
-	return nil
 }
 
 func provideApiClient(
@@ -139,6 +564,16 @@ func provideApiClient(
 	config *Config,
 ) (api.Client, error) {
 
+	if config.PrometheusFixture != "" {
+		fixture, err := LoadPrometheusFixture(config.PrometheusFixture)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return NewFixtureClient(fixture)
+	}
+
 	if config.Local {
 		client, err := api.NewClient(api.Config{
 			Address: "http://localhost:9090",
@@ -289,3 +724,84 @@ func getMeterDefinitions(
 
 	return defs.Items, nil
 }
+
+type collectedReport struct {
+	ReportID  string
+	Directory string
+	File      string
+	Count     int
+	Errors    []error
+	Coverage  *ReportCoverage
+}
+
+// collectReport queries the reporter's meter definitions and writes the
+// validated report tarball next to the report directory.
+func collectReport(
+	ctx context.Context,
+	reporter *MarketplaceReporter,
+	reportID uuid.UUID,
+	signer *ReportSigner,
+) (*collectedReport, error) {
+	writer, err := reporter.NewReportWriter(reportID)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error writing report")
+	}
+
+	writer.Signer = signer
+
+	logger.Info("starting collection", "reportID", reportID)
+	errorList, err := reporter.CollectMetrics(ctx, writer)
+
+	if err != nil {
+		logger.Error(err, "error collecting metrics")
+		writer.Discard()
+		return nil, err
+	}
+
+	var coverage *ReportCoverage
+
+	if reporter.Coverage.Enabled {
+		coverage, err = reporter.CheckCoverage(ctx)
+
+		if err != nil {
+			logger.Error(err, "error checking coverage")
+			errorList = append(errorList, errors.Wrap(err, "error checking coverage"))
+		}
+
+		writer.metadata.Coverage = coverage
+	}
+
+	logger.Info("writing report", "reportID", reportID)
+
+	files, err := writer.Close()
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error writing report")
+	}
+
+	dirpath := filepath.Dir(files[0])
+	err = ValidateReportFolder(dirpath)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "report does not match the report schema")
+	}
+
+	fileName := fmt.Sprintf("%s/../upload-%s.tar.gz", dirpath, reportID.String())
+	err = TargzFolder(dirpath, fileName)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error tarring report")
+	}
+
+	logger.Info("tarring", "outputfile", fileName)
+
+	return &collectedReport{
+		ReportID:  writer.metadata.ReportID.String(),
+		Directory: dirpath,
+		File:      fileName,
+		Count:     writer.Count(),
+		Errors:    errorList,
+		Coverage:  coverage,
+	}, nil
+}
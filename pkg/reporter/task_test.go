@@ -0,0 +1,469 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/common"
+	marketplacev1alpha1 "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/apis/marketplace/v1alpha1"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils"
+	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils/reconcileutils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeUploader struct {
+	files []string
+	id    string
+	err   error
+}
+
+func (f *fakeUploader) UploadFile(path string) (*UploadResult, error) {
+	f.files = append(f.files, path)
+	return &UploadResult{ID: f.id, Attempts: 1}, f.err
+}
+
+var _ = Describe("Task", func() {
+	var (
+		sut              *Task
+		primary, archive *fakeUploader
+	)
+
+	BeforeEach(func() {
+		primary = &fakeUploader{}
+		archive = &fakeUploader{}
+
+		sut = &Task{
+			Config: &Config{
+				UploaderTargets: UploaderTargets{UploaderTargetRedHatInsights, UploaderTargetS3},
+			},
+			Uploaders: Uploaders{
+				UploaderTargetRedHatInsights: primary,
+				UploaderTargetS3:             archive,
+			},
+		}
+	})
+
+	It("should upload to every target", func() {
+		status := sut.upload("report.tar.gz", UploaderTargets{UploaderTargetRedHatInsights, UploaderTargetS3})
+
+		Expect(primary.files).To(ConsistOf("report.tar.gz"))
+		Expect(archive.files).To(ConsistOf("report.tar.gz"))
+		Expect(status).To(HaveLen(2))
+		Expect(sut.uploadsFailed(status)).To(Succeed())
+	})
+
+	It("should record a failed target without stopping the others", func() {
+		archive.err = errors.New("bucket not found")
+
+		status := sut.upload("report.tar.gz", UploaderTargets{UploaderTargetS3, UploaderTargetRedHatInsights})
+
+		Expect(primary.files).To(ConsistOf("report.tar.gz"))
+		Expect(status[0].Target).To(Equal("s3"))
+		Expect(status[0].Status).To(Equal(marketplacev1alpha1.UploadStatusFailure))
+		Expect(status[0].Error).To(ContainSubstring("bucket not found"))
+		Expect(status[1].Status).To(Equal(marketplacev1alpha1.UploadStatusSuccess))
+		Expect(sut.uploadsFailed(status)).To(Succeed())
+
+		reportStatus := &marketplacev1alpha1.MeterReportStatus{}
+		for _, details := range status {
+			reportStatus.SetUploadStatus(details)
+		}
+		Expect(reportStatus.FailedUploadTargets()).To(ConsistOf("s3"))
+	})
+
+	It("should record the primary receipt on the report", func() {
+		primary.id = "request-id"
+		archive.id = "reports/upload.tar.gz"
+		report := &marketplacev1alpha1.MeterReport{}
+
+		status := sut.upload("report.tar.gz", sut.Config.UploaderTargets)
+		sut.setUploadStatus(report, status)
+
+		Expect(report.Status.UploadID).ToNot(BeNil())
+		Expect(string(*report.Status.UploadID)).To(Equal("request-id"))
+		Expect(report.Status.UploadTime).ToNot(BeNil())
+		Expect(report.Status.UploadStatus).To(HaveLen(2))
+		Expect(report.Status.UploadStatus[1].UploadID).To(Equal("reports/upload.tar.gz"))
+	})
+
+	It("should not record a receipt from a secondary target", func() {
+		primary.err = errors.New("unavailable")
+		archive.id = "reports/upload.tar.gz"
+		report := &marketplacev1alpha1.MeterReport{}
+
+		status := sut.upload("report.tar.gz", sut.Config.UploaderTargets)
+		sut.setUploadStatus(report, status)
+
+		Expect(report.Status.UploadID).To(BeNil())
+		Expect(report.Status.UploadTime).To(BeNil())
+	})
+
+	It("should error when the primary target fails", func() {
+		primary.err = errors.New("unavailable")
+
+		status := sut.upload("report.tar.gz", sut.Config.UploaderTargets)
+
+		Expect(status[1].Status).To(Equal(marketplacev1alpha1.UploadStatusSuccess))
+		Expect(sut.uploadsFailed(status)).ToNot(Succeed())
+	})
+
+	It("should error when every target fails", func() {
+		primary.err = errors.New("unavailable")
+
+		status := sut.upload("report.tar.gz", UploaderTargets{UploaderTargetRedHatInsights, UploaderTargetLocal})
+
+		Expect(status[1].Error).To(ContainSubstring("not configured"))
+		Expect(sut.uploadsFailed(status)).ToNot(Succeed())
+	})
+})
+
+var _ = Describe("Task UploadPending", func() {
+	var (
+		dir              string
+		sut              *Task
+		spool            *Spool
+		k8sClient        client.Client
+		primary, archive *fakeUploader
+		reportA, reportB = ReportName{Namespace: "openshift-redhat-marketplace", Name: "report-a"},
+			ReportName{Namespace: "openshift-redhat-marketplace", Name: "report-b"}
+	)
+
+	newReport := func(name ReportName) *marketplacev1alpha1.MeterReport {
+		return &marketplacev1alpha1.MeterReport{
+			ObjectMeta: metav1.ObjectMeta{Namespace: name.Namespace, Name: name.Name},
+		}
+	}
+
+	getReport := func(name ReportName) *marketplacev1alpha1.MeterReport {
+		report := &marketplacev1alpha1.MeterReport{}
+		Expect(k8sClient.Get(context.TODO(), types.NamespacedName(name), report)).To(Succeed())
+		return report
+	}
+
+	spoolReport := func(name ReportName, id string) string {
+		fileName := filepath.Join(dir, id+".tar.gz")
+		Expect(ioutil.WriteFile(fileName, []byte(id), 0600)).To(Succeed())
+		entry, err := spool.Add(name, id, fileName)
+		Expect(err).To(Succeed())
+		return spool.Path(entry)
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "upload-pending")
+		Expect(err).To(Succeed())
+
+		spool, err = NewSpool(filepath.Join(dir, "spool"))
+		Expect(err).To(Succeed())
+
+		s := scheme.Scheme
+		s.AddKnownTypes(marketplacev1alpha1.SchemeGroupVersion, &marketplacev1alpha1.MeterReport{})
+		k8sClient = fake.NewFakeClientWithScheme(s, newReport(reportA), newReport(reportB))
+
+		primary = &fakeUploader{}
+		archive = &fakeUploader{}
+
+		sut = &Task{
+			CC:    reconcileutils.NewClientCommand(k8sClient, s, logf.Log.WithName("task")),
+			Cache: &informertest.FakeInformers{},
+			Ctx:   context.TODO(),
+			Config: &Config{
+				SpoolDirectory:  spool.Directory,
+				UploaderTargets: UploaderTargets{UploaderTargetRedHatInsights, UploaderTargetS3},
+			},
+			Uploaders: Uploaders{
+				UploaderTargetRedHatInsights: primary,
+				UploaderTargetS3:             archive,
+			},
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should drain the spool in order", func() {
+		fileA := spoolReport(reportA, "id-a")
+		fileB := spoolReport(reportB, "id-b")
+
+		Expect(sut.UploadPending()).To(Succeed())
+
+		Expect(primary.files).To(Equal([]string{fileA, fileB}))
+		Expect(archive.files).To(Equal([]string{fileA, fileB}))
+		Expect(fileA).ToNot(BeAnExistingFile())
+		Expect(fileB).ToNot(BeAnExistingFile())
+
+		index, err := spool.ReadIndex()
+		Expect(err).To(Succeed())
+		Expect(index.Entries[0].IsDone()).To(BeTrue())
+		Expect(index.Entries[1].IsDone()).To(BeTrue())
+
+		report := getReport(reportB)
+		Expect(report.Status.UploadStatus).To(HaveLen(2))
+		Expect(report.Status.UploadStatus[0].Status).To(Equal(marketplacev1alpha1.UploadStatusSuccess))
+		Expect(report.Status.UploadStatus[1].Status).To(Equal(marketplacev1alpha1.UploadStatusSuccess))
+	})
+
+	It("should resume a partly uploaded report without submitting it twice", func() {
+		fileA := spoolReport(reportA, "id-a")
+		fileB := spoolReport(reportB, "id-b")
+		archive.err = errors.New("bucket not found")
+
+		Expect(sut.UploadPending()).ToNot(Succeed())
+
+		By("stopping at the first report that failed")
+		Expect(primary.files).To(Equal([]string{fileA}))
+		Expect(archive.files).To(Equal([]string{fileA}))
+		Expect(fileA).To(BeAnExistingFile())
+
+		index, err := spool.ReadIndex()
+		Expect(err).To(Succeed())
+		Expect(index.Entries[0].UploadedTargets).To(ConsistOf(UploaderTargetRedHatInsights.String()))
+		Expect(index.Entries[0].IsDone()).To(BeFalse())
+		Expect(index.Entries[1].UploadedTargets).To(BeEmpty())
+
+		report := getReport(reportA)
+		Expect(report.Status.UploadStatus).To(HaveLen(2))
+		Expect(report.Status.UploadStatus[1].Status).To(Equal(marketplacev1alpha1.UploadStatusPending))
+
+		By("resuming with the target that failed")
+		archive.err = nil
+		Expect(sut.UploadPending()).To(Succeed())
+
+		Expect(primary.files).To(Equal([]string{fileA, fileB}))
+		Expect(archive.files).To(Equal([]string{fileA, fileA, fileB}))
+
+		By("never submitting a drained report again")
+		Expect(sut.UploadPending()).To(Succeed())
+		Expect(primary.files).To(HaveLen(2))
+		Expect(archive.files).To(HaveLen(3))
+	})
+
+	It("should spool a report for the targets that failed", func() {
+		fileName := filepath.Join(dir, "upload-id-a.tar.gz")
+		Expect(ioutil.WriteFile(fileName, []byte("id-a"), 0600)).To(Succeed())
+		archive.err = errors.New("bucket not found")
+		sut.ReportName = reportA
+
+		spooled, status, err := sut.uploadReport("id-a", fileName)
+		Expect(err).To(Succeed())
+		Expect(spooled).To(BeAnExistingFile())
+		Expect(status[0].Status).To(Equal(marketplacev1alpha1.UploadStatusSuccess))
+		Expect(status[1].Status).To(Equal(marketplacev1alpha1.UploadStatusPending))
+		Expect(status[1].Error).To(ContainSubstring("bucket not found"))
+		Expect(sut.uploadsFailed(status)).To(Succeed())
+
+		index, err := spool.ReadIndex()
+		Expect(err).To(Succeed())
+		Expect(index.Entries).To(HaveLen(1))
+		Expect(index.Entries[0].UploadedTargets).To(ConsistOf(UploaderTargetRedHatInsights.String()))
+
+		archive.err = nil
+		Expect(sut.UploadPending()).To(Succeed())
+
+		Expect(primary.files).To(Equal([]string{fileName}))
+		Expect(archive.files).To(Equal([]string{fileName, spooled}))
+	})
+
+	It("should not spool a report every target has", func() {
+		fileName := filepath.Join(dir, "upload-id-a.tar.gz")
+		Expect(ioutil.WriteFile(fileName, []byte("id-a"), 0600)).To(Succeed())
+
+		uploaded, _, err := sut.uploadReport("id-a", fileName)
+		Expect(err).To(Succeed())
+		Expect(uploaded).To(Equal(fileName))
+
+		index, err := spool.ReadIndex()
+		Expect(err).To(Succeed())
+		Expect(index.Entries).To(BeEmpty())
+	})
+
+	It("should not drain a locked spool", func() {
+		spoolReport(reportA, "id-a")
+
+		unlock, err := spool.Lock()
+		Expect(err).To(Succeed())
+		defer unlock()
+
+		Expect(sut.UploadPending()).To(Equal(ErrSpoolLocked))
+		Expect(primary.files).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Task Run", func() {
+	var (
+		dir        string
+		sut        *Task
+		spool      *Spool
+		k8sClient  client.Client
+		primary    *fakeUploader
+		reportName = ReportName{Namespace: "openshift-redhat-marketplace", Name: "report-a"}
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "task-run")
+		Expect(err).To(Succeed())
+
+		spool, err = NewSpool(filepath.Join(dir, "spool"))
+		Expect(err).To(Succeed())
+
+		meterDefinitionFile := filepath.Join(dir, "meterdefinitions.yaml")
+		Expect(ioutil.WriteFile(meterDefinitionFile, []byte(offlineMeterDefinitions), 0600)).To(Succeed())
+		meterDefinitions, err := LoadMeterDefinitions(meterDefinitionFile)
+		Expect(err).To(Succeed())
+
+		// every query is empty, so the report window has no coverage
+		fixture := filepath.Join(dir, "fixture.json")
+		Expect(ioutil.WriteFile(fixture, []byte(`{"default":true,"status":"success","data":{"resultType":"matrix","result":[]}}`), 0600)).To(Succeed())
+
+		start, _ := time.Parse(time.RFC3339, "2020-04-19T00:00:00Z")
+		report := &marketplacev1alpha1.MeterReport{
+			ObjectMeta: metav1.ObjectMeta{Namespace: reportName.Namespace, Name: reportName.Name},
+			Spec: marketplacev1alpha1.MeterReportSpec{
+				StartTime: metav1.NewTime(start),
+				EndTime:   metav1.NewTime(start.Add(time.Hour)),
+				PrometheusService: &common.ServiceReference{
+					Name:       "rhm-prometheus-meterbase",
+					Namespace:  reportName.Namespace,
+					TargetPort: intstr.FromString("rbac"),
+				},
+				MeterDefinitions: meterDefinitions,
+			},
+		}
+		mktconfig := &marketplacev1alpha1.MarketplaceConfig{
+			ObjectMeta: metav1.ObjectMeta{Namespace: reportName.Namespace, Name: utils.MARKETPLACECONFIG_NAME},
+			Spec: marketplacev1alpha1.MarketplaceConfigSpec{
+				ClusterUUID: "foo-id",
+			},
+		}
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: reportName.Namespace, Name: "rhm-prometheus-meterbase"},
+		}
+
+		s := scheme.Scheme
+		s.AddKnownTypes(marketplacev1alpha1.SchemeGroupVersion,
+			&marketplacev1alpha1.MeterReport{}, &marketplacev1alpha1.MarketplaceConfig{})
+		k8sClient = fake.NewFakeClientWithScheme(s, report, mktconfig, service)
+
+		primary = &fakeUploader{}
+
+		sut = &Task{
+			ReportName: reportName,
+			CC:         reconcileutils.NewClientCommand(k8sClient, s, logf.Log.WithName("task")),
+			Cache:      &informertest.FakeInformers{},
+			K8SClient:  k8sClient,
+			K8SScheme:  s,
+			Ctx:        context.TODO(),
+			Config: &Config{
+				OutputDirectory:   dir,
+				Upload:            true,
+				SpoolDirectory:    spool.Directory,
+				PrometheusFixture: fixture,
+				Coverage: CoverageConfig{
+					Enabled:    true,
+					MinPercent: 50,
+				},
+			},
+			Uploaders: Uploaders{
+				UploaderTargetRedHatInsights: primary,
+			},
+		}
+		sut.Config.SetDefaults()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should spool a held report until it is released", func() {
+		Expect(sut.Run()).To(Succeed())
+		Expect(primary.files).To(BeEmpty())
+
+		report := &marketplacev1alpha1.MeterReport{}
+		Expect(k8sClient.Get(context.TODO(), types.NamespacedName(reportName), report)).To(Succeed())
+		Expect(report.Status.Coverage).ToNot(BeNil())
+		Expect(report.Status.Coverage.Held).To(BeTrue())
+		Expect(report.Status.UploadStatus).To(BeEmpty())
+		Expect(report.Status.ReportFile).To(BeAnExistingFile())
+
+		index, err := spool.ReadIndex()
+		Expect(err).To(Succeed())
+		Expect(index.Entries).To(HaveLen(1))
+		Expect(index.Entries[0].Held).To(BeTrue())
+		Expect(spool.Path(index.Entries[0])).To(Equal(report.Status.ReportFile))
+
+		By("skipping the held report")
+		Expect(sut.UploadPending()).To(Succeed())
+		Expect(primary.files).To(BeEmpty())
+
+		By("uploading the released report")
+		Expect(sut.ReleaseHeld()).To(Succeed())
+
+		report = &marketplacev1alpha1.MeterReport{}
+		Expect(k8sClient.Get(context.TODO(), types.NamespacedName(reportName), report)).To(Succeed())
+		Expect(report.Status.Coverage.Held).To(BeFalse())
+		Expect(report.Status.PendingUploadTargets()).To(ConsistOf(UploaderTargetRedHatInsights.String()))
+
+		Expect(sut.UploadPending()).To(Succeed())
+		Expect(primary.files).To(HaveLen(1))
+
+		Expect(sut.ReleaseHeld()).ToNot(Succeed())
+	})
+	It("should record query errors on the report without failing it", func() {
+		report := &marketplacev1alpha1.MeterReport{}
+		Expect(k8sClient.Get(context.TODO(), types.NamespacedName(reportName), report)).To(Succeed())
+
+		// the report window isn't a multiple of the interval
+		invalid := report.Spec.MeterDefinitions[0]
+		invalid.Name = "invalid-interval"
+		invalid.Spec.Workloads = []marketplacev1alpha1.Workload{{
+			Name:         "app-pods",
+			WorkloadType: marketplacev1alpha1.WorkloadTypePod,
+			MetricLabels: []marketplacev1alpha1.MeterLabelQuery{{
+				Label:       "rpc_durations_seconds_count",
+				Aggregation: "sum",
+				Interval:    &metav1.Duration{Duration: 50 * time.Minute},
+			}},
+		}}
+		report.Spec.MeterDefinitions = append(report.Spec.MeterDefinitions, invalid)
+		Expect(k8sClient.Update(context.TODO(), report)).To(Succeed())
+
+		Expect(sut.Run()).To(Succeed())
+
+		report = &marketplacev1alpha1.MeterReport{}
+		Expect(k8sClient.Get(context.TODO(), types.NamespacedName(reportName), report)).To(Succeed())
+		Expect(report.Status.QueryErrorList).To(HaveLen(1))
+		Expect(report.Status.QueryErrorList[0]).To(ContainSubstring("invalid interval for rpc_durations_seconds_count"))
+		Expect(report.Status.ReportFile).To(BeAnExistingFile())
+	})
+})
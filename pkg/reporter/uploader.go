@@ -26,11 +26,14 @@ import (
 	"net/textproto"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"emperror.dev/errors"
 	"github.com/go-logr/logr"
 	"github.com/gotidy/ptr"
+	"github.com/jpillora/backoff"
 	"github.com/redhat-marketplace/redhat-marketplace-operator/pkg/managers"
 	. "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils/reconcileutils"
 	"github.com/redhat-marketplace/redhat-marketplace-operator/version"
@@ -46,17 +49,35 @@ type UploaderTarget string
 var (
 	UploaderTargetRedHatInsights UploaderTarget = "redhat-insights"
 	UploaderTargetNoOp           UploaderTarget = "noop"
+	UploaderTargetS3             UploaderTarget = "s3"
+	UploaderTargetLocal          UploaderTarget = "local"
 )
 
 func (u UploaderTarget) String() string {
 	return string(u)
 }
 
+// UploaderTargets is an ordered list of targets. The first target is the
+// primary target for the report.
+type UploaderTargets []UploaderTarget
+
+func MustParseUploaderTargets(ss []string) UploaderTargets {
+	targets := make(UploaderTargets, 0, len(ss))
+	for _, s := range ss {
+		targets = append(targets, MustParseUploaderTarget(s))
+	}
+	return targets
+}
+
 func MustParseUploaderTarget(s string) UploaderTarget {
 	switch s {
 	case string(UploaderTargetRedHatInsights):
 		fallthrough
 	case string(UploaderTargetNoOp):
+		fallthrough
+	case string(UploaderTargetS3):
+		fallthrough
+	case string(UploaderTargetLocal):
 		return UploaderTarget(s)
 	default:
 		panic(errors.Errorf("provided string is not a valid upload target %s", s))
@@ -64,21 +85,82 @@ func MustParseUploaderTarget(s string) UploaderTarget {
 }
 
 type Uploader interface {
-	UploadFile(path string) error
+	// UploadFile uploads the file at path. The result is returned
+	// even if the upload failed.
+	UploadFile(path string) (*UploadResult, error)
 }
 
+// UploadResult describes a finished upload.
+type UploadResult struct {
+	// ID is the receipt returned by the target for the upload, if any.
+	ID string
+	// Attempts is the number of requests made to upload the file.
+	Attempts int
+}
+
+// Uploaders are the configured uploaders by target.
+type Uploaders map[UploaderTarget]Uploader
+
 type RedHatInsightsUploaderConfig struct {
 	URL                 string   `json:"url"`
 	Token               string   `json:"-"`
 	OperatorVersion     string   `json:"operatorVersion"`
 	ClusterID           string   `json:"clusterID"`
 	AdditionalCertFiles []string `json:"additionalCertFiles,omitempty"`
+	UploadRetryConfig   `json:",inline"`
 	httpVersion         *int
 }
 
+// UploadRetryConfig configures how failed uploads are retried.
+type UploadRetryConfig struct {
+	// MaxAttempts is the total number of requests to make, including the first.
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// MinBackoff is the wait after the first failed attempt.
+	MinBackoff time.Duration `json:"minBackoff,omitempty"`
+	// MaxBackoff is the longest wait between attempts.
+	MaxBackoff time.Duration `json:"maxBackoff,omitempty"`
+	// AttemptTimeout bounds a single request.
+	AttemptTimeout time.Duration `json:"attemptTimeout,omitempty"`
+	// Deadline bounds the whole upload, including the waits between
+	// attempts.
+	Deadline time.Duration `json:"deadline,omitempty"`
+}
+
+const (
+	defaultUploadMaxAttempts    = 5
+	defaultUploadMinBackoff     = time.Second
+	defaultUploadMaxBackoff     = time.Minute
+	defaultUploadAttemptTimeout = 5 * time.Minute
+	defaultUploadDeadline       = 15 * time.Minute
+)
+
+func (c *UploadRetryConfig) SetDefaults() {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaultUploadMaxAttempts
+	}
+
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = defaultUploadMinBackoff
+	}
+
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaultUploadMaxBackoff
+	}
+
+	if c.AttemptTimeout <= 0 {
+		c.AttemptTimeout = defaultUploadAttemptTimeout
+	}
+
+	if c.Deadline <= 0 {
+		c.Deadline = defaultUploadDeadline
+	}
+}
+
 type RedHatInsightsUploader struct {
 	RedHatInsightsUploaderConfig
 	client *http.Client
+	sleep  func(time.Duration)
+	now    func() time.Time
 }
 
 var _ Uploader = &RedHatInsightsUploader{}
@@ -93,6 +175,7 @@ func NewRedHatInsightsUploader(
 	}
 
 	client := &http.Client{}
+	config.UploadRetryConfig.SetDefaults()
 
 	// default to 2 unless otherwise overridden
 	if config.httpVersion == nil {
@@ -114,6 +197,8 @@ func NewRedHatInsightsUploader(
 	return &RedHatInsightsUploader{
 		client:                       client,
 		RedHatInsightsUploaderConfig: *config,
+		sleep:                        time.Sleep,
+		now:                          time.Now,
 	}, nil
 }
 
@@ -132,16 +217,35 @@ func getUserAgent(version, clusterID string) string {
 	return fmt.Sprintf(userAgentFmt, version, clusterID)
 }
 
-func (r *RedHatInsightsUploader) uploadFileRequest(path string) (*http.Request, error) {
+func (r *RedHatInsightsUploader) uploadFileRequest(ctx context.Context, path string) (*http.Request, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+	// stream the multipart body so the report is never buffered in memory
+	body, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
 
+	go func() {
+		defer file.Close()
+		pw.CloseWithError(writeMultipartFile(writer, file, path))
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf(uploadURL, r.URL), body)
+
+	if err != nil {
+		body.CloseWithError(err)
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", r.Token))
+	req.Header.Set("User-Agent", getUserAgent(r.OperatorVersion, r.ClusterID))
+	return req, nil
+}
+
+func writeMultipartFile(writer *multipart.Writer, file io.Reader, path string) error {
 	h := make(textproto.MIMEHeader)
 	h.Set("Content-Disposition",
 		fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
@@ -150,85 +254,194 @@ func (r *RedHatInsightsUploader) uploadFileRequest(path string) (*http.Request,
 	part, err := writer.CreatePart(h)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	_, err = io.Copy(part, file)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	_ = writer.WriteField("type", mktplaceFileUploadType)
+	err = writer.WriteField("type", mktplaceFileUploadType)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	err = writer.Close()
-	if err != nil {
-		return nil, err
+	return writer.Close()
+}
+
+// UploadFile posts the file to the ingress service. Requests that fail with
+// a connection error, a 429 or a 5xx response are retried with exponential
+// backoff. A longer Retry-After from the server is waited for instead, as
+// long as the retry starts before the upload deadline.
+func (r *RedHatInsightsUploader) UploadFile(path string) (*UploadResult, error) {
+	start := r.now()
+	result := &UploadResult{}
+	retryBackoff := &backoff.Backoff{
+		Min:    r.MinBackoff,
+		Max:    r.MaxBackoff,
+		Factor: 2,
+		Jitter: true,
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf(uploadURL, r.URL), body)
+	for {
+		result.Attempts++
+		id, retryable, retryAfter, err := r.uploadFileAttempt(path, result.Attempts)
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", r.Token))
-	req.Header.Set("User-Agent", getUserAgent(r.OperatorVersion, r.ClusterID))
-	return req, err
+		if err == nil {
+			result.ID = id
+			return result, nil
+		}
+
+		if !retryable || result.Attempts >= r.MaxAttempts {
+			return result, err
+		}
+
+		wait := retryBackoff.Duration()
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+
+		if r.now().Sub(start)+wait > r.Deadline {
+			return result, errors.WrapWithDetails(err, "retry would start after the upload deadline",
+				"wait", wait.String(),
+				"deadline", r.Deadline.String())
+		}
+
+		logger.Info("retrying upload", "attempt", result.Attempts, "maxAttempts", r.MaxAttempts, "wait", wait.String())
+		r.sleep(wait)
+	}
 }
 
-func (r *RedHatInsightsUploader) UploadFile(path string) error {
-	req, err := r.uploadFileRequest(path)
+func (r *RedHatInsightsUploader) uploadFileAttempt(path string, attempt int) (id string, retryable bool, retryAfter time.Duration, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.AttemptTimeout)
+	defer cancel()
+
+	req, err := r.uploadFileRequest(ctx, path)
 
 	if err != nil {
-		return errors.Wrap(err, "failed to get upload file req")
+		return "", false, 0, errors.Wrap(err, "failed to get upload file req")
 	}
 
 	// Perform the request
 	resp, err := r.client.Do(req)
 	if err != nil {
-		logger.Error(err, "failed to post")
-		return errors.Wrap(err, "failed to post")
+		logger.Error(err, "failed to post", "attempt", attempt)
+		return "", true, 0, errors.Wrap(err, "failed to post")
 	}
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return errors.Wrap(err, "failed to read response body")
+		return "", true, 0, errors.Wrap(err, "failed to read response body")
 	}
 
 	logger.Info(
 		"retrieved response",
+		"attempt", attempt,
 		"statusCode", resp.StatusCode,
 		"proto", resp.Proto,
 		"body", string(body),
 		"headers", resp.Header)
 
 	if resp.StatusCode >= 300 || resp.StatusCode < 200 {
-		return errors.NewWithDetails("failed to upload field",
-			"statusCode", resp.StatusCode,
-			"proto", resp.Proto,
-			"body", string(body),
-			"headers", resp.Header)
+		retryable = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return "", retryable, parseRetryAfter(resp.Header.Get("Retry-After"), r.now()),
+			errors.NewWithDetails("failed to upload file",
+				"statusCode", resp.StatusCode,
+				"proto", resp.Proto,
+				"body", string(body),
+				"headers", resp.Header)
+	}
+
+	id = parseIngressRequestID(resp.Header, body)
+	logger.Info("uploaded file", "attempt", attempt, "requestID", id)
+	return id, false, 0, nil
+}
+
+const ingressRequestIDHeader = "X-Rh-Insights-Request-Id"
+
+type ingressResponse struct {
+	RequestID string `json:"request_id"`
+}
+
+// parseIngressRequestID reads the request id the ingress service assigns
+// to an upload, preferring the response body over the header.
+func parseIngressRequestID(header http.Header, body []byte) string {
+	resp := &ingressResponse{}
+
+	if err := json.Unmarshal(body, resp); err == nil && resp.RequestID != "" {
+		return resp.RequestID
+	}
+
+	return header.Get(ingressRequestIDHeader)
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as
+// an HTTP date. Zero is returned if the header is missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
 	}
-	return nil
+
+	return 0
 }
 
 type NoOpUploader struct{}
 
 var _ Uploader = &NoOpUploader{}
 
-func (r *NoOpUploader) UploadFile(path string) error {
+func (r *NoOpUploader) UploadFile(path string) (*UploadResult, error) {
 	log := logger.WithValues("uploader", "noop")
 	log.Info("upload is a no op")
-	return nil
+	return &UploadResult{}, nil
 }
 
-func ProvideUploader(
+func ProvideUploaders(
 	ctx context.Context,
 	cc ClientCommandRunner,
 	log logr.Logger,
 	isCacheStarted managers.CacheIsStarted,
+	reporterConfig *Config,
+) (Uploaders, error) {
+	uploaders := make(Uploaders)
+
+	// a dry run never uploads, so it doesn't need upload credentials
+	if reporterConfig.DryRun {
+		return uploaders, nil
+	}
+
+	for _, target := range reporterConfig.UploaderTargets {
+		if _, ok := uploaders[target]; ok {
+			continue
+		}
+
+		uploader, err := provideUploader(ctx, cc, log, reporterConfig, target)
+
+		if err != nil {
+			return nil, errors.WrapWithDetails(err, "failed to create uploader", "target", target)
+		}
+
+		uploaders[target] = uploader
+	}
+
+	return uploaders, nil
+}
+
+func provideUploader(
+	ctx context.Context,
+	cc ClientCommandRunner,
+	log logr.Logger,
+	reporterConfig *Config,
 	uploaderTarget UploaderTarget,
 ) (Uploader, error) {
 	switch uploaderTarget {
@@ -239,7 +452,18 @@ func ProvideUploader(
 			return nil, err
 		}
 
+		config.UploadRetryConfig = reporterConfig.UploadRetry
 		return NewRedHatInsightsUploader(config)
+	case UploaderTargetS3:
+		config, err := provideS3Config(ctx, cc, log, reporterConfig.S3Uploader)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return NewS3Uploader(config)
+	case UploaderTargetLocal:
+		return NewLocalUploader(&reporterConfig.LocalUploader)
 	case UploaderTargetNoOp:
 		return &NoOpUploader{}, nil
 	}
@@ -0,0 +1,100 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"emperror.dev/errors"
+)
+
+// LocalUploaderConfig configures copying reports to a local directory,
+// usually a mounted PVC used for archival.
+type LocalUploaderConfig struct {
+	Directory string `json:"directory"`
+}
+
+type LocalUploader struct {
+	LocalUploaderConfig
+}
+
+var _ Uploader = &LocalUploader{}
+
+func NewLocalUploader(
+	config *LocalUploaderConfig,
+) (Uploader, error) {
+	if config.Directory == "" {
+		return nil, errors.New("local upload directory is required")
+	}
+
+	return &LocalUploader{
+		LocalUploaderConfig: *config,
+	}, nil
+}
+
+// UploadFile copies the file into the configured directory. The copy is
+// written to a temp file first and renamed so a partial file is never
+// visible under the final name.
+func (r *LocalUploader) UploadFile(path string) (*UploadResult, error) {
+	log := logger.WithValues("uploader", UploaderTargetLocal, "directory", r.Directory)
+
+	err := os.MkdirAll(r.Directory, 0755)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create upload directory")
+	}
+
+	src, err := os.Open(path)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open file")
+	}
+
+	defer src.Close()
+
+	dest, err := ioutil.TempFile(r.Directory, ".upload-")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temp file")
+	}
+
+	defer os.Remove(dest.Name())
+
+	_, err = io.Copy(dest, src)
+
+	if err != nil {
+		dest.Close()
+		return nil, errors.Wrap(err, "failed to copy file")
+	}
+
+	err = dest.Close()
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to close file")
+	}
+
+	destName := filepath.Join(r.Directory, filepath.Base(path))
+	err = os.Rename(dest.Name(), destName)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to rename file")
+	}
+
+	log.Info("copied file", "file", destName)
+	return &UploadResult{ID: destName, Attempts: 1}, nil
+}
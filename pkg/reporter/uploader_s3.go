@@ -0,0 +1,332 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	. "github.com/redhat-marketplace/redhat-marketplace-operator/pkg/utils/reconcileutils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	s3AccessKeyIDKey     = "accessKeyId"
+	s3SecretAccessKeyKey = "secretAccessKey"
+
+	s3DefaultRegion  = "us-east-1"
+	s3SigningAlgo    = "AWS4-HMAC-SHA256"
+	s3AmzDateFormat  = "20060102T150405Z"
+	s3AmzShortFormat = "20060102"
+)
+
+// S3UploaderConfig configures an upload to an S3 compatible object
+// store such as AWS S3, IBM COS or MinIO. Objects are addressed
+// path-style (endpoint/bucket/key) so that any S3 compatible endpoint
+// works without DNS changes.
+type S3UploaderConfig struct {
+	Endpoint            string   `json:"endpoint"`
+	Region              string   `json:"region,omitempty"`
+	Bucket              string   `json:"bucket"`
+	Prefix              string   `json:"prefix,omitempty"`
+	AccessKeyID         string   `json:"-"`
+	SecretAccessKey     string   `json:"-"`
+	AdditionalCertFiles []string `json:"additionalCertFiles,omitempty"`
+
+	// CredentialsSecret is the secret holding the accessKeyId and
+	// secretAccessKey keys used to sign requests.
+	CredentialsSecret types.NamespacedName `json:"credentialsSecret,omitempty"`
+}
+
+type S3Uploader struct {
+	S3UploaderConfig
+	client *http.Client
+	now    func() time.Time
+}
+
+var _ Uploader = &S3Uploader{}
+
+func NewS3Uploader(
+	config *S3UploaderConfig,
+) (Uploader, error) {
+	if config.Endpoint == "" {
+		return nil, errors.New("s3 endpoint is required")
+	}
+
+	if config.Bucket == "" {
+		return nil, errors.New("s3 bucket is required")
+	}
+
+	if config.Region == "" {
+		config.Region = s3DefaultRegion
+	}
+
+	tlsConfig, err := generateCACertPool(config.AdditionalCertFiles...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Uploader{
+		S3UploaderConfig: *config,
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: tlsConfig,
+			},
+		},
+		now: time.Now,
+	}, nil
+}
+
+func (r *S3Uploader) objectURL(key string) (*url.URL, error) {
+	u, err := url.Parse(r.Endpoint)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse s3 endpoint")
+	}
+
+	// send the path escaped as it is signed, url escaping would leave
+	// characters such as ! and $ that s3 expects escaped
+	u.Path = path.Join("/", u.Path, r.Bucket, key)
+	u.RawPath = s3EscapePath(u.Path)
+	return u, nil
+}
+
+func (r *S3Uploader) objectKey(filePath string) string {
+	return strings.TrimPrefix(path.Join(r.Prefix, filepath.Base(filePath)), "/")
+}
+
+func (r *S3Uploader) UploadFile(filePath string) (*UploadResult, error) {
+	log := logger.WithValues("uploader", UploaderTargetS3, "bucket", r.Bucket)
+
+	payloadHash, size, err := sha256File(filePath)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to hash file")
+	}
+
+	file, err := os.Open(filePath)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open file")
+	}
+
+	defer file.Close()
+
+	key := r.objectKey(filePath)
+	u, err := r.objectURL(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPut, u.String(), file)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create s3 request")
+	}
+
+	req.ContentLength = size
+	req.Header.Set("Content-Type", mktplaceFileUploadType)
+	r.sign(req, payloadHash, r.now().UTC())
+	result := &UploadResult{Attempts: 1}
+
+	resp, err := r.client.Do(req)
+
+	if err != nil {
+		log.Error(err, "failed to put object")
+		return result, errors.Wrap(err, "failed to put object")
+	}
+
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+
+	if err != nil {
+		return result, errors.Wrap(err, "failed to read response body")
+	}
+
+	if resp.StatusCode >= 300 || resp.StatusCode < 200 {
+		return result, errors.NewWithDetails("failed to upload file",
+			"statusCode", resp.StatusCode,
+			"key", key,
+			"body", string(body))
+	}
+
+	log.Info("uploaded object", "key", key, "etag", resp.Header.Get("ETag"))
+	result.ID = path.Join(r.Bucket, key)
+	return result, nil
+}
+
+// sign adds an AWS signature version 4 Authorization header to the request.
+// All headers set on the request at the time of signing are included.
+func (r *S3Uploader) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format(s3AmzDateFormat)
+	shortDate := now.Format(s3AmzShortFormat)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	canonicalHeaders := &strings.Builder{}
+	for _, name := range names {
+		fmt.Fprintf(canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{shortDate, r.Region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		s3SigningAlgo,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+r.SecretAccessKey), []byte(shortDate))
+	signingKey = hmacSHA256(signingKey, []byte(r.Region))
+	signingKey = hmacSHA256(signingKey, []byte("s3"))
+	signingKey = hmacSHA256(signingKey, []byte("aws4_request"))
+	signature := hex.EncodeToString(hmacSHA256(signingKey, []byte(stringToSign)))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3SigningAlgo, r.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func s3EscapePath(p string) string {
+	if p == "" {
+		return "/"
+	}
+
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func s3Escape(s string) string {
+	b := &strings.Builder{}
+	for _, c := range []byte(s) {
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func sha256File(filePath string) (string, int64, error) {
+	file, err := os.Open(filePath)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	defer file.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+func provideS3Config(
+	ctx context.Context,
+	cc ClientCommandRunner,
+	log logr.Logger,
+	config S3UploaderConfig,
+) (*S3UploaderConfig, error) {
+	if config.CredentialsSecret.Name == "" {
+		return &config, nil
+	}
+
+	secret := &corev1.Secret{}
+	result, _ := cc.Do(ctx, GetAction(config.CredentialsSecret, secret))
+
+	if !result.Is(Continue) {
+		return nil, errors.Wrap(result, "failed to get s3 credentials secret")
+	}
+
+	accessKeyID, ok := secret.Data[s3AccessKeyIDKey]
+
+	if !ok {
+		return nil, errors.Errorf("%s is not found in secret", s3AccessKeyIDKey)
+	}
+
+	secretAccessKey, ok := secret.Data[s3SecretAccessKeyKey]
+
+	if !ok {
+		return nil, errors.Errorf("%s is not found in secret", s3SecretAccessKeyKey)
+	}
+
+	log.Info("retrieved s3 credentials", "secret", config.CredentialsSecret)
+
+	config.AccessKeyID = strings.TrimSpace(string(accessKeyID))
+	config.SecretAccessKey = strings.TrimSpace(string(secretAccessKey))
+	return &config, nil
+}
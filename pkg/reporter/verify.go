@@ -0,0 +1,175 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"emperror.dev/errors"
+)
+
+// VerifyResult describes a verified report tarball.
+type VerifyResult struct {
+	ReportID          string
+	Slices            int
+	Metrics           int
+	SignatureVerified bool
+}
+
+// ReadReportFiles reads every file of a report tarball into memory, keyed
+// by the name in the tarball.
+func ReadReportFiles(tarball string) (map[string][]byte, error) {
+	f, err := os.Open(tarball)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open report")
+	}
+
+	defer f.Close()
+
+	gzr, err := gzip.NewReader(f)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "report is not gzipped")
+	}
+
+	defer gzr.Close()
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(gzr)
+
+	for {
+		header, err := tr.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read report")
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := ioutil.ReadAll(tr)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", header.Name)
+		}
+
+		files[header.Name] = data
+	}
+
+	return files, nil
+}
+
+// VerifyReport checks a report tarball offline. Every slice listed in the
+// metadata must be present with a matching digest and metric count, and no
+// unlisted files are allowed. If a public key is given the metadata
+// signature must be present and valid.
+func VerifyReport(tarball string, publicKeyPEM []byte) (*VerifyResult, error) {
+	files, err := ReadReportFiles(tarball)
+
+	if err != nil {
+		return nil, err
+	}
+
+	metadataBytes, ok := files[metadataFileName]
+
+	if !ok {
+		return nil, errors.Errorf("%s is missing", metadataFileName)
+	}
+
+	metadata := &ReportMetadata{}
+	err = json.Unmarshal(metadataBytes, metadata)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", metadataFileName)
+	}
+
+	result := &VerifyResult{
+		ReportID: metadata.ReportID.String(),
+		Slices:   len(metadata.ReportSlices),
+	}
+
+	errs := []error{}
+	seen := map[string]bool{
+		metadataFileName:  true,
+		signatureFileName: true,
+	}
+
+	for sliceID, slice := range metadata.ReportSlices {
+		name := fmt.Sprintf("%s.json", sliceID.String())
+		seen[name] = true
+		data, ok := files[name]
+
+		if !ok {
+			errs = append(errs, errors.Errorf("slice %s is missing", name))
+			continue
+		}
+
+		if slice.SHA256 == "" {
+			errs = append(errs, errors.Errorf("slice %s has no digest", name))
+		} else if digest := sha256Hex(data); digest != slice.SHA256 {
+			errs = append(errs, errors.Errorf("slice %s digest %s does not match %s", name, digest, slice.SHA256))
+		}
+
+		metricsReport := &MetricsReport{}
+
+		if err := json.Unmarshal(data, metricsReport); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to parse slice %s", name))
+			continue
+		}
+
+		if metricsReport.ReportSliceID != sliceID {
+			errs = append(errs, errors.Errorf("slice %s has id %s", name, metricsReport.ReportSliceID))
+		}
+
+		if len(metricsReport.Metrics) != slice.NumberMetrics {
+			errs = append(errs, errors.Errorf("slice %s has %d metrics, metadata lists %d",
+				name, len(metricsReport.Metrics), slice.NumberMetrics))
+		}
+
+		result.Metrics = result.Metrics + len(metricsReport.Metrics)
+	}
+
+	for name := range files {
+		if !seen[name] {
+			errs = append(errs, errors.Errorf("%s is not listed in the metadata", name))
+		}
+	}
+
+	if len(publicKeyPEM) != 0 {
+		signature, ok := files[signatureFileName]
+
+		if !ok {
+			errs = append(errs, errors.Errorf("%s is missing", signatureFileName))
+		} else if err := VerifySignature(publicKeyPEM, metadataBytes, signature); err != nil {
+			errs = append(errs, err)
+		} else {
+			result.SignatureVerified = true
+		}
+	}
+
+	return result, errors.Combine(errs...)
+}
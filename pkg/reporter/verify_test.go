@@ -0,0 +1,163 @@
+// Copyright 2020 IBM Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+func generateKeyPair(newKey func() (crypto.Signer, error)) ([]byte, []byte) {
+	key, err := newKey()
+	Expect(err).To(Succeed())
+
+	privateBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	Expect(err).To(Succeed())
+
+	publicBytes, err := x509.MarshalPKIXPublicKey(key.Public())
+	Expect(err).To(Succeed())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateBytes}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicBytes})
+}
+
+func newEd25519Key() (crypto.Signer, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	return key, err
+}
+
+func newRSAKey() (crypto.Signer, error) {
+	return rsa.GenerateKey(rand.Reader, 2048)
+}
+
+func newECDSAKey() (crypto.Signer, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+var _ = Describe("VerifyReport", func() {
+	var (
+		dir                   string
+		privateKey, publicKey []byte
+	)
+
+	writeReport := func(signer *ReportSigner) string {
+		writer, err := NewReportWriter(
+			filepath.Join(dir, "report"),
+			NewReportMetadata(uuid.New(), ReportSourceMetadata{}),
+			2,
+		)
+		Expect(err).To(Succeed())
+		writer.Signer = signer
+
+		for i := 0; i < 3; i++ {
+			Expect(writer.Write(&MetricBase{
+				Key:     MetricKey{MetricID: uuid.New().String()},
+				Metrics: map[string]interface{}{"count": "1"},
+			})).To(Succeed())
+		}
+
+		_, err = writer.Close()
+		Expect(err).To(Succeed())
+
+		return filepath.Join(dir, "report")
+	}
+
+	tarReport := func(folder string) string {
+		fileName := filepath.Join(dir, "upload.tar.gz")
+		Expect(TargzFolder(folder, fileName)).To(Succeed())
+		return fileName
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "verify")
+		Expect(err).To(Succeed())
+
+		privateKey, publicKey = generateKeyPair(newEd25519Key)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	DescribeTable("should verify a signed report",
+		func(newKey func() (crypto.Signer, error)) {
+			privateKey, publicKey = generateKeyPair(newKey)
+			signer, err := NewReportSigner(privateKey)
+			Expect(err).To(Succeed())
+
+			result, err := VerifyReport(tarReport(writeReport(signer)), publicKey)
+			Expect(err).To(Succeed())
+			Expect(result.Slices).To(Equal(2))
+			Expect(result.Metrics).To(Equal(3))
+			Expect(result.SignatureVerified).To(BeTrue())
+		},
+		Entry("ed25519", newEd25519Key),
+		Entry("rsa", newRSAKey),
+		Entry("ecdsa", newECDSAKey),
+	)
+
+	It("should verify digests without a key", func() {
+		result, err := VerifyReport(tarReport(writeReport(nil)), nil)
+		Expect(err).To(Succeed())
+		Expect(result.SignatureVerified).To(BeFalse())
+	})
+
+	It("should fail a tampered slice", func() {
+		folder := writeReport(nil)
+		files, err := filepath.Glob(filepath.Join(folder, "*-*.json"))
+		Expect(err).To(Succeed())
+		Expect(ioutil.WriteFile(files[0], []byte(`{"metrics":[]}`), 0600)).To(Succeed())
+
+		_, err = VerifyReport(tarReport(folder), nil)
+		Expect(err).To(MatchError(ContainSubstring("does not match")))
+	})
+
+	It("should fail an unlisted file", func() {
+		folder := writeReport(nil)
+		Expect(ioutil.WriteFile(filepath.Join(folder, "extra.json"), []byte(`{}`), 0600)).To(Succeed())
+
+		_, err := VerifyReport(tarReport(folder), nil)
+		Expect(err).To(MatchError(ContainSubstring("extra.json is not listed")))
+	})
+
+	It("should fail a signature from another key", func() {
+		signer, err := NewReportSigner(privateKey)
+		Expect(err).To(Succeed())
+		_, otherPublicKey := generateKeyPair(newEd25519Key)
+
+		_, err = VerifyReport(tarReport(writeReport(signer)), otherPublicKey)
+		Expect(err).To(MatchError(ContainSubstring("signature does not match")))
+	})
+
+	It("should fail a missing signature", func() {
+		_, err := VerifyReport(tarReport(writeReport(nil)), publicKey)
+		Expect(err).To(MatchError(ContainSubstring("metadata.json.sig is missing")))
+	})
+})
@@ -47,8 +47,11 @@ func NewTask(ctx context.Context, reportName ReportName, config2 *Config) (*Task
 	clientCommandRunner := reconcileutils.NewClientCommand(client, scheme, logrLogger)
 	cacheIsIndexed := managers.CacheIsIndexed{}
 	cacheIsStarted := managers.StartCache(ctx, cache, logrLogger, cacheIsIndexed)
-	uploaderTarget := config2.UploaderTarget
-	uploader, err := ProvideUploader(ctx, clientCommandRunner, logrLogger, cacheIsStarted, uploaderTarget)
+	uploaders, err := ProvideUploaders(ctx, clientCommandRunner, logrLogger, cacheIsStarted, config2)
+	if err != nil {
+		return nil, err
+	}
+	reportSigner, err := ProvideReportSigner(ctx, clientCommandRunner, logrLogger, cacheIsStarted, config2)
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +63,8 @@ func NewTask(ctx context.Context, reportName ReportName, config2 *Config) (*Task
 		Ctx:        ctx,
 		Config:     config2,
 		K8SScheme:  scheme,
-		Uploader:   uploader,
+		Uploaders:  uploaders,
+		Signer:     reportSigner,
 	}
 	return task, nil
 }
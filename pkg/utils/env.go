@@ -40,6 +40,8 @@ const (
 	WATCH_KEEPER_LIMITPOLL_NAME            = "watch-keeper-limit-poll"
 	WATCH_KEEPER_CONFIG_NAME               = "watch-keeper-config"
 	WATCH_KEEPER_SECRET_NAME               = "watch-keeper-secret"
+	REPORTER_SPOOL_PVC_NAME                = "rhm-meter-report-spool"
+	REPORTER_UPLOAD_PENDING_JOB_NAME       = "rhm-meter-report-upload-pending"
 
 	/* All Controllers */
 	CONTROLLER_FINALIZER = "finalizer.marketplace.redhat.com"